@@ -15,12 +15,17 @@ const (
 	ActivityIssueFieldUpdate         ActivityType = "bb.issue.field.update"
 	ActivityIssueStatusUpdate        ActivityType = "bb.issue.status.update"
 	ActivityPipelineTaskStatusUpdate ActivityType = "bb.pipeline.task.status.update"
+	ActivityPipelineTaskSQLReview    ActivityType = "bb.pipeline.task.sqlreview"
 
 	// Member related
 	ActivityMemberCreate     ActivityType = "bb.member.create"
 	ActivityMemberRoleUpdate ActivityType = "bb.member.role.update"
 	ActivityMemberActivate   ActivityType = "bb.member.activate"
 	ActivityMemberDeactivate ActivityType = "bb.member.deactivate"
+
+	// Database related
+	ActivityDatabaseSchemaDrift ActivityType = "bb.database.schema.drift"
+	ActivityDatabaseTaskCancel  ActivityType = "bb.database.task.cancel"
 )
 
 func (e ActivityType) String() string {
@@ -35,6 +40,8 @@ func (e ActivityType) String() string {
 		return "bb.issue.status.update"
 	case ActivityPipelineTaskStatusUpdate:
 		return "bb.pipeline.task.status.update"
+	case ActivityPipelineTaskSQLReview:
+		return "bb.pipeline.task.sqlreview"
 	case ActivityMemberCreate:
 		return "bb.member.create"
 	case ActivityMemberRoleUpdate:
@@ -43,6 +50,10 @@ func (e ActivityType) String() string {
 		return "bb.member.activate"
 	case ActivityMemberDeactivate:
 		return "bb.member.deactivate"
+	case ActivityDatabaseSchemaDrift:
+		return "bb.database.schema.drift"
+	case ActivityDatabaseTaskCancel:
+		return "bb.database.task.cancel"
 	}
 	return "bb.activity.unknown"
 }
@@ -105,6 +116,14 @@ type ActivityPipelineTaskStatusUpdatePayload struct {
 	TaskName  string `json:"taskName"`
 }
 
+type ActivityPipelineTaskSQLReviewPayload struct {
+	TaskId     int               `json:"taskId"`
+	AdviceList []SQLReviewAdvice `json:"adviceList"`
+	// Used by inbox to display info without paying the join cost
+	IssueName string `json:"issueName"`
+	TaskName  string `json:"taskName"`
+}
+
 type ActivityMemberCreatePayload struct {
 	PrincipalId    int          `json:"principalId"`
 	PrincipalName  string       `json:"principalName"`
@@ -128,6 +147,25 @@ type ActivityMemberActivateDeactivatePayload struct {
 	Role           Role   `json:"role"`
 }
 
+type ActivityDatabaseSchemaDriftPayload struct {
+	DatabaseId   int    `json:"databaseId"`
+	DatabaseName string `json:"databaseName"`
+	// The latest migration version whose schema snapshot differs from the live schema.
+	Version string `json:"version"`
+	// The lines only in the snapshot are prefixed with "- ", and the lines only in the live schema are prefixed with "+ ".
+	DiffList []string `json:"diffList"`
+}
+
+// ActivityDatabaseTaskCancelPayload is the payload for canceling the task not belonging to an issue, e.g. the backup
+// and restore task, which is recorded on the database instead.
+type ActivityDatabaseTaskCancelPayload struct {
+	TaskId    int        `json:"taskId"`
+	TaskType  TaskType   `json:"taskType"`
+	OldStatus TaskStatus `json:"oldStatus,omitempty"`
+	// Used by inbox to display info without paying the join cost
+	TaskName string `json:"taskName"`
+}
+
 type Activity struct {
 	ID int `jsonapi:"primary,activity"`
 
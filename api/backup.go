@@ -55,12 +55,68 @@ type BackupStorageBackend string
 const (
 	// BackupStorageBackendLocal is the local storage backend for a backup.
 	BackupStorageBackendLocal BackupStorageBackend = "LOCAL"
+	// BackupStorageBackendS3 is the S3-compatible object storage backend for a backup.
+	BackupStorageBackendS3 BackupStorageBackend = "S3"
 )
 
 func (e BackupStorageBackend) String() string {
 	switch e {
 	case BackupStorageBackendLocal:
 		return "LOCAL"
+	case BackupStorageBackendS3:
+		return "S3"
+	}
+	return "UNKNOWN"
+}
+
+// BackupCompression is the compression of a backup file.
+type BackupCompression string
+
+const (
+	// BackupCompressionNone is the compression for an uncompressed backup.
+	BackupCompressionNone BackupCompression = "NONE"
+	// BackupCompressionGzip is the compression for a gzip compressed backup.
+	BackupCompressionGzip BackupCompression = "GZIP"
+	// BackupCompressionZstd is the zstd compression, which is rejected since no zstd implementation is available yet.
+	BackupCompressionZstd BackupCompression = "ZSTD"
+)
+
+func (e BackupCompression) String() string {
+	switch e {
+	case BackupCompressionNone:
+		return "NONE"
+	case BackupCompressionGzip:
+		return "GZIP"
+	}
+	return "UNKNOWN"
+}
+
+// BackupVerificationStatus is the verification status of a backup.
+type BackupVerificationStatus string
+
+const (
+	// BackupVerificationStatusNone is the verification status for the backup not to be verified.
+	BackupVerificationStatusNone BackupVerificationStatus = "NONE"
+	// BackupVerificationStatusPending is the verification status for the backup to be verified after it's taken.
+	BackupVerificationStatusPending BackupVerificationStatus = "PENDING"
+	// BackupVerificationStatusPassed is the verification status for the backup restored with the same tables and
+	// row counts as dumped.
+	BackupVerificationStatusPassed BackupVerificationStatus = "PASSED"
+	// BackupVerificationStatusFailed is the verification status for the backup failed to be restored, or restored
+	// with different tables or row counts.
+	BackupVerificationStatusFailed BackupVerificationStatus = "FAILED"
+)
+
+func (e BackupVerificationStatus) String() string {
+	switch e {
+	case BackupVerificationStatusNone:
+		return "NONE"
+	case BackupVerificationStatusPending:
+		return "PENDING"
+	case BackupVerificationStatusPassed:
+		return "PASSED"
+	case BackupVerificationStatusFailed:
+		return "FAILED"
 	}
 	return "UNKNOWN"
 }
@@ -69,6 +125,8 @@ type Backup struct {
 	ID int `jsonapi:"primary,backup"`
 
 	// Standard fields
+	// RowStatus is ARCHIVED after the backup is pruned, and its file has been deleted.
+	RowStatus RowStatus `jsonapi:"attr,rowStatus"`
 	CreatorId int
 	Creator   *Principal `jsonapi:"attr,creator"`
 	CreatedTs int64      `jsonapi:"attr,createdTs"`
@@ -90,6 +148,14 @@ type Backup struct {
 	MigrationHistoryVersion string               `jsonapi:"attr,migrationHistoryVersion"`
 	Path                    string               `jsonapi:"attr,path"`
 	Comment                 string               `jsonapi:"attr,comment"`
+	Compression             BackupCompression    `jsonapi:"attr,compression"`
+	// Encrypted is true if the backup is encrypted with AES-GCM using the server-managed key.
+	Encrypted bool `jsonapi:"attr,encrypted"`
+	// StorageLocation is the JSON encoded BackupStorageLocation where the backup is stored, empty for the local storage.
+	StorageLocation string `jsonapi:"attr,storageLocation"`
+	// Payload is the JSON encoded BackupPayload.
+	Payload            string                   `jsonapi:"attr,payload"`
+	VerificationStatus BackupVerificationStatus `jsonapi:"attr,verificationStatus"`
 }
 
 type BackupCreate struct {
@@ -108,11 +174,25 @@ type BackupCreate struct {
 	MigrationHistoryVersion string               `jsonapi:"attr,migrationHistoryVersion"`
 	Path                    string               `jsonapi:"attr,path"`
 	Comment                 string               `jsonapi:"attr,comment"`
+	Compression             BackupCompression    `jsonapi:"attr,compression"`
+	// Encrypted is true if the backup is encrypted with AES-GCM using the server-managed key.
+	Encrypted bool `jsonapi:"attr,encrypted"`
+	// StorageLocation is the JSON encoded BackupStorageLocation where the backup is stored, empty for the local storage.
+	// Value is assigned by the server according to the backup storage of the database environment.
+	StorageLocation string
+	// VerificationEnabled verifies the backup by restoring it into a scratch database after it's taken.
+	VerificationEnabled bool `jsonapi:"attr,verificationEnabled"`
+	// VerificationInstanceId is the sandbox instance to verify the backup on, it's the instance of the database if
+	// not set. It's not stored with the backup.
+	VerificationInstanceId int `jsonapi:"attr,verificationInstanceId"`
 }
 
 type BackupFind struct {
 	ID *int
 
+	// Standard fields
+	RowStatus *RowStatus
+
 	// Related fields
 	DatabaseId *int
 
@@ -132,11 +212,39 @@ type BackupPatch struct {
 	ID int
 
 	// Standard fields
+	RowStatus *string
 	// Value is assigned from the jwt subject field passed by the client.
 	UpdaterId int
 
 	// Domain specific fields
-	Status string
+	Status             *string
+	Payload            *string
+	VerificationStatus *string
+}
+
+// BackupStorageLocation is the location of the backup in the S3-compatible object storage, recorded when the backup
+// is created, so that changing the backup storage of the environment won't lose track of the existing backups.
+// The credentials are not recorded, they're always taken from the backup storage of the environment.
+type BackupStorageLocation struct {
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Bucket   string `json:"bucket"`
+	// Prefix is the prefix of the backup storage when the backup is created, which is already part of the backup path.
+	Prefix       string `json:"prefix"`
+	UsePathStyle bool   `json:"usePathStyle"`
+}
+
+// BackupPayload contains the metadata of a backup taken in a consistent snapshot.
+type BackupPayload struct {
+	// BinlogFile and BinlogPosition are the binlog coordinates of the snapshot, from which the changes after the
+	// backup can be replayed. They're empty if the binlog of the instance isn't archived.
+	BinlogFile     string `json:"binlogFile,omitempty"`
+	BinlogPosition int64  `json:"binlogPosition,omitempty"`
+	// SnapshotTs is the time of the snapshot on the instance.
+	SnapshotTs int64 `json:"snapshotTs,omitempty"`
+	// TableRowCount is the number of rows dumped for each table, which is compared with the restored database to
+	// verify the backup.
+	TableRowCount map[string]int64 `json:"tableRowCount,omitempty"`
 }
 
 // RestoreBackup is the message to restore from a backup.
@@ -148,6 +256,16 @@ type RestoreBackup struct {
 
 	// Domain specific fields
 	BackupId int `jsonapi:"attr,backupId"`
+	// TargetInstanceId is the instance to restore the backup into, it's the instance of the backup database if not set.
+	// Another instance must host a database in the project of the backup database, and it requires the Owner or DBA
+	// role if it's in another environment.
+	TargetInstanceId int `jsonapi:"attr,targetInstanceId"`
+	// TargetDatabaseName is the name of the new database to restore the backup into, which is created and registered
+	// in the project of the backup database. The backup is restored into the backup database itself if not set.
+	TargetDatabaseName string `jsonapi:"attr,targetDatabaseName"`
+	// PointInTimeTs recovers the database to the time by replaying the archived binlog after the backup. The backup
+	// is the latest one taken before the time if BackupId is not set. It requires TargetDatabaseName.
+	PointInTimeTs int64 `jsonapi:"attr,pointInTimeTs"`
 }
 
 // BackupSetting is the backup setting for a database.
@@ -172,6 +290,20 @@ type BackupSetting struct {
 	Enabled   bool `jsonapi:"attr,enabled"`
 	Hour      int  `jsonapi:"attr,hour"`
 	DayOfWeek int  `jsonapi:"attr,dayOfWeek"`
+	// An automatic backup is kept if it's one of the latest RetentionCount backups, or if it's created within
+	// RetentionDays days. 0 disables the rule, and the backups are kept forever if both are 0.
+	RetentionCount int `jsonapi:"attr,retentionCount"`
+	RetentionDays  int `jsonapi:"attr,retentionDays"`
+	// The automatic backups are verified on the sandbox instance VerificationInstanceId if VerificationEnabled is set,
+	// or on the instance of the database if VerificationInstanceId is 0.
+	VerificationEnabled    bool `jsonapi:"attr,verificationEnabled"`
+	VerificationInstanceId int  `jsonapi:"attr,verificationInstanceId"`
+	// Schedule is the cron expression in UTC to take the automatic backups, e.g. "0 * * * *" for hourly backups.
+	// The automatic backups are taken at Hour on DayOfWeek, or daily at Hour if DayOfWeek is -1, if it's empty.
+	Schedule string `jsonapi:"attr,schedule"`
+	// Compression and Encrypted are the encoding of the automatic backups, see Backup.
+	Compression BackupCompression `jsonapi:"attr,compression"`
+	Encrypted   bool              `jsonapi:"attr,encrypted"`
 }
 
 // BackupSettingFind is the message to get a backup settings.
@@ -182,6 +314,7 @@ type BackupSettingFind struct {
 	DatabaseId *int
 
 	// Domain specific fields
+	Enabled *bool
 }
 
 // BackupSettingUpsert is the message to upsert a backup settings.
@@ -196,15 +329,16 @@ type BackupSettingUpsert struct {
 	DatabaseId int `jsonapi:"attr,databaseId"`
 
 	// Domain specific fields
-	Enabled   bool `jsonapi:"attr,enabled"`
-	Hour      int  `jsonapi:"attr,hour"`
-	DayOfWeek int  `jsonapi:"attr,dayOfWeek"`
-}
-
-// BackupSettingsMatch is the message to find backup settings matching the conditions.
-type BackupSettingsMatch struct {
-	Hour      int
-	DayOfWeek int
+	Enabled                bool              `jsonapi:"attr,enabled"`
+	Hour                   int               `jsonapi:"attr,hour"`
+	DayOfWeek              int               `jsonapi:"attr,dayOfWeek"`
+	RetentionCount         int               `jsonapi:"attr,retentionCount"`
+	RetentionDays          int               `jsonapi:"attr,retentionDays"`
+	VerificationEnabled    bool              `jsonapi:"attr,verificationEnabled"`
+	VerificationInstanceId int               `jsonapi:"attr,verificationInstanceId"`
+	Schedule               string            `jsonapi:"attr,schedule"`
+	Compression            BackupCompression `jsonapi:"attr,compression"`
+	Encrypted              bool              `jsonapi:"attr,encrypted"`
 }
 
 // BackupService is the backend for backups.
@@ -214,6 +348,6 @@ type BackupService interface {
 	FindBackupList(ctx context.Context, find *BackupFind) ([]*Backup, error)
 	PatchBackup(ctx context.Context, patch *BackupPatch) (*Backup, error)
 	FindBackupSetting(ctx context.Context, find *BackupSettingFind) (*BackupSetting, error)
+	FindBackupSettingList(ctx context.Context, find *BackupSettingFind) ([]*BackupSetting, error)
 	UpsertBackupSetting(ctx context.Context, upsert *BackupSettingUpsert) (*BackupSetting, error)
-	FindBackupSettingsMatch(ctx context.Context, match *BackupSettingsMatch) ([]*BackupSetting, error)
 }
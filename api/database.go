@@ -19,6 +19,8 @@ type SyncStatus string
 const (
 	OK       SyncStatus = "OK"
 	NotFound SyncStatus = "NOT_FOUND"
+	// Drifted means the database schema has been changed outside of the migration, only applies to database.
+	Drifted SyncStatus = "DRIFTED"
 )
 
 func (e SyncStatus) String() string {
@@ -27,6 +29,8 @@ func (e SyncStatus) String() string {
 		return "OK"
 	case NotFound:
 		return "NOT_FOUND"
+	case Drifted:
+		return "DRIFTED"
 	}
 	return ""
 }
@@ -110,6 +114,20 @@ type DatabasePatch struct {
 	LastSuccessfulSyncTs *int64
 }
 
+// DatabaseDiff is the DDL that makes the target database schema match the source database schema.
+type DatabaseDiff struct {
+	// Related fields
+	SourceDatabaseId int `jsonapi:"attr,sourceDatabaseId"`
+	TargetDatabaseId int `jsonapi:"attr,targetDatabaseId"`
+	// IssueId is the schema update issue created from the diff, only set if requested.
+	IssueId *int `jsonapi:"attr,issueId"`
+
+	// Domain specific fields
+	StatementList []string `jsonapi:"attr,statementList"`
+	// Statement joins the statement list, and is empty if both schemas are the same.
+	Statement string `jsonapi:"attr,statement"`
+}
+
 type DatabaseService interface {
 	CreateDatabase(ctx context.Context, create *DatabaseCreate) (*Database, error)
 	// This is specifically used to create the * database when creating the instance.
@@ -3,6 +3,9 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 )
 
 // Approval policy only controls updating schema on the existing database.
@@ -25,6 +28,129 @@ func (e ApprovalPolicy) String() string {
 	return "UNKNOWN"
 }
 
+// MaintenanceWindow is the daily time range in UTC during which the database create and schema update tasks in the
+// environment can start.
+// The window ends on the next day if EndTime is not after StartTime, e.g. 23:00 to 01:00.
+type MaintenanceWindow struct {
+	// StartTime is in HH:MM format.
+	StartTime string `json:"startTime"`
+	// EndTime is in HH:MM format.
+	EndTime string `json:"endTime"`
+
+	start time.Duration
+	end   time.Duration
+}
+
+// UnmarshalMaintenanceWindow parses the maintenance window stored as json string.
+// Returns nil if s is empty, which means there is no maintenance window.
+func UnmarshalMaintenanceWindow(s string) (*MaintenanceWindow, error) {
+	if s == "" {
+		return nil, nil
+	}
+	window := &MaintenanceWindow{}
+	if err := json.Unmarshal([]byte(s), window); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	var err error
+	if window.start, err = parseTimeOfDay(window.StartTime); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window start time: %w", err)
+	}
+	if window.end, err = parseTimeOfDay(window.EndTime); err != nil {
+		return nil, fmt.Errorf("invalid maintenance window end time: %w", err)
+	}
+	return window, nil
+}
+
+// parseTimeOfDay parses HH:MM as the duration since midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Contains returns true if t is within the maintenance window.
+func (w *MaintenanceWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	d := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+	if w.start < w.end {
+		return w.start <= d && d < w.end
+	}
+	return w.start <= d || d < w.end
+}
+
+// TaskRetryPolicy controls how a task is retried after a transient failure. The first retry waits BackoffSeconds,
+// and the wait doubles for each subsequent retry.
+type TaskRetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, 1 means no retry.
+	MaxAttempts    int `json:"maxAttempts"`
+	BackoffSeconds int `json:"backoffSeconds"`
+}
+
+// UnmarshalTaskRetryPolicy parses the task retry policy map stored as json string. Empty string means an empty map.
+func UnmarshalTaskRetryPolicy(s string) (map[TaskType]TaskRetryPolicy, error) {
+	policyMap := make(map[TaskType]TaskRetryPolicy)
+	if s == "" {
+		return policyMap, nil
+	}
+	if err := json.Unmarshal([]byte(s), &policyMap); err != nil {
+		return nil, fmt.Errorf("invalid task retry policy: %w", err)
+	}
+	for taskType, policy := range policyMap {
+		if !strings.HasPrefix(string(taskType), "bb.task.") {
+			return nil, fmt.Errorf("invalid task retry policy, unknown task type %q", taskType)
+		}
+		if policy.MaxAttempts < 1 {
+			return nil, fmt.Errorf("invalid task retry policy, max attempts must be at least 1 for task type %q", taskType)
+		}
+		if policy.BackoffSeconds < 0 {
+			return nil, fmt.Errorf("invalid task retry policy, backoff seconds must not be negative for task type %q", taskType)
+		}
+	}
+	return policyMap, nil
+}
+
+// BackupStorage is the storage of the backups of the databases in the environment.
+type BackupStorage struct {
+	Backend BackupStorageBackend `json:"backend"`
+
+	// The following fields are for BackupStorageBackendS3.
+	// Endpoint is the URL of the S3-compatible storage, e.g. https://s3.us-west-2.amazonaws.com or http://localhost:9000.
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	Bucket   string `json:"bucket"`
+	// Prefix is prepended to the object keys of the backups, e.g. "bytebase/".
+	Prefix          string `json:"prefix"`
+	AccessKeyID     string `json:"accessKeyId"`
+	SecretAccessKey string `json:"secretAccessKey"`
+	// UsePathStyle addresses the bucket in the path instead of the host, it's usually required by MinIO.
+	UsePathStyle bool `json:"usePathStyle"`
+}
+
+// UnmarshalBackupStorage parses the backup storage stored as json string. Empty string means the local storage.
+func UnmarshalBackupStorage(s string) (*BackupStorage, error) {
+	storage := &BackupStorage{
+		Backend: BackupStorageBackendLocal,
+	}
+	if s == "" {
+		return storage, nil
+	}
+	if err := json.Unmarshal([]byte(s), storage); err != nil {
+		return nil, fmt.Errorf("invalid backup storage: %w", err)
+	}
+	switch storage.Backend {
+	case BackupStorageBackendLocal:
+	case BackupStorageBackendS3:
+		if storage.Endpoint == "" || storage.Bucket == "" {
+			return nil, fmt.Errorf("invalid backup storage, endpoint and bucket are required for %s", storage.Backend)
+		}
+	default:
+		return nil, fmt.Errorf("invalid backup storage, unknown backend %q", storage.Backend)
+	}
+	return storage, nil
+}
+
 type Environment struct {
 	ID int `jsonapi:"primary,environment"`
 
@@ -41,6 +167,15 @@ type Environment struct {
 	Name           string         `jsonapi:"attr,name"`
 	Order          int            `jsonapi:"attr,order"`
 	ApprovalPolicy ApprovalPolicy `jsonapi:"attr,approvalPolicy"`
+	// SQLReviewPolicy is a json object mapping the SQL review rule type to its level, see plugin/advisor.
+	SQLReviewPolicy string `jsonapi:"attr,sqlReviewPolicy"`
+	// MaintenanceWindow is a json object of MaintenanceWindow, empty means no maintenance window.
+	MaintenanceWindow string `jsonapi:"attr,maintenanceWindow"`
+	// TaskRetryPolicy is a json object mapping the task type to its TaskRetryPolicy.
+	TaskRetryPolicy string `jsonapi:"attr,taskRetryPolicy"`
+	// BackupStorage is a json object of BackupStorage, empty means the local storage.
+	// It's not returned to the client since it contains the credentials.
+	BackupStorage string
 }
 
 type EnvironmentCreate struct {
@@ -49,8 +184,12 @@ type EnvironmentCreate struct {
 	CreatorId int
 
 	// Domain specific fields
-	Name           string         `jsonapi:"attr,name"`
-	ApprovalPolicy ApprovalPolicy `jsonapi:"attr,approvalPolicy"`
+	Name              string         `jsonapi:"attr,name"`
+	ApprovalPolicy    ApprovalPolicy `jsonapi:"attr,approvalPolicy"`
+	SQLReviewPolicy   string         `jsonapi:"attr,sqlReviewPolicy"`
+	MaintenanceWindow string         `jsonapi:"attr,maintenanceWindow"`
+	TaskRetryPolicy   string         `jsonapi:"attr,taskRetryPolicy"`
+	BackupStorage     string         `jsonapi:"attr,backupStorage"`
 }
 
 type EnvironmentFind struct {
@@ -77,9 +216,13 @@ type EnvironmentPatch struct {
 	UpdaterId int
 
 	// Domain specific fields
-	Name           *string `jsonapi:"attr,name"`
-	Order          *int    `jsonapi:"attr,order"`
-	ApprovalPolicy *string `jsonapi:"attr,approvalPolicy"`
+	Name              *string `jsonapi:"attr,name"`
+	Order             *int    `jsonapi:"attr,order"`
+	ApprovalPolicy    *string `jsonapi:"attr,approvalPolicy"`
+	SQLReviewPolicy   *string `jsonapi:"attr,sqlReviewPolicy"`
+	MaintenanceWindow *string `jsonapi:"attr,maintenanceWindow"`
+	TaskRetryPolicy   *string `jsonapi:"attr,taskRetryPolicy"`
+	BackupStorage     *string `jsonapi:"attr,backupStorage"`
 }
 
 type EnvironmentDelete struct {
@@ -32,6 +32,11 @@ type Instance struct {
 	Username     string  `jsonapi:"attr,username"`
 	// Password is not returned to the client
 	Password string
+	// BinlogArchiveEnabled archives the binlog of the MySQL instance for the point-in-time recovery.
+	BinlogArchiveEnabled bool `jsonapi:"attr,binlogArchiveEnabled"`
+	// SSLMode is the sslmode of the PostgreSQL instance, e.g. "require" for the managed PostgreSQL requiring TLS. Empty
+	// is the same as "disable".
+	SSLMode string `jsonapi:"attr,sslMode"`
 }
 
 type InstanceCreate struct {
@@ -50,6 +55,11 @@ type InstanceCreate struct {
 	Port         string  `jsonapi:"attr,port"`
 	Username     string  `jsonapi:"attr,username"`
 	Password     string  `jsonapi:"attr,password"`
+	// BinlogArchiveEnabled archives the binlog of the MySQL instance for the point-in-time recovery.
+	BinlogArchiveEnabled bool `jsonapi:"attr,binlogArchiveEnabled"`
+	// SSLMode is the sslmode of the PostgreSQL instance, e.g. "require" for the managed PostgreSQL requiring TLS. Empty
+	// is the same as "disable".
+	SSLMode string `jsonapi:"attr,sslMode"`
 }
 
 type InstanceFind struct {
@@ -82,6 +92,11 @@ type InstancePatch struct {
 	Port         *string `jsonapi:"attr,port"`
 	Username     *string `jsonapi:"attr,username"`
 	Password     *string `jsonapi:"attr,password"`
+	// BinlogArchiveEnabled archives the binlog of the MySQL instance for the point-in-time recovery.
+	BinlogArchiveEnabled *bool `jsonapi:"attr,binlogArchiveEnabled"`
+	// SSLMode is the sslmode of the PostgreSQL instance, e.g. "require" for the managed PostgreSQL requiring TLS. Empty
+	// is the same as "disable".
+	SSLMode *string `jsonapi:"attr,sslMode"`
 }
 
 // Instance migration schema status
@@ -132,6 +147,7 @@ type MigrationHistory struct {
 	// This is a string instead of int as the issue id may come from other issue tracking system in the future
 	IssueId string `jsonapi:"attr,issueId"`
 	Payload string `jsonapi:"attr,payload"`
+	Schema  string `jsonapi:"attr,schema"`
 }
 
 type InstanceService interface {
@@ -28,6 +28,7 @@ type Repository struct {
 	WebURL             string `jsonapi:"attr,webURL"`
 	BaseDirectory      string `jsonapi:"attr,baseDirectory"`
 	BranchFilter       string `jsonapi:"attr,branchFilter"`
+	TagFilter          string `jsonapi:"attr,tagFilter"`
 	ExternalId         string `jsonapi:"attr,externalId"`
 	ExternalWebhookId  string
 	WebhookURLHost     string
@@ -54,6 +55,7 @@ type RepositoryCreate struct {
 	WebURL        string `jsonapi:"attr,webURL"`
 	BaseDirectory string `jsonapi:"attr,baseDirectory"`
 	BranchFilter  string `jsonapi:"attr,branchFilter"`
+	TagFilter     string `jsonapi:"attr,tagFilter"`
 	ExternalId    string `jsonapi:"attr,externalId"`
 	// Token belonged by the user linking the project to the VCS repository. We store this token together
 	// with the refresh token in the new repository record so we can use it to call VCS API on
@@ -96,6 +98,11 @@ type RepositoryPatch struct {
 	// Domain specific fields
 	BaseDirectory *string `jsonapi:"attr,baseDirectory"`
 	BranchFilter  *string `jsonapi:"attr,branchFilter"`
+	TagFilter     *string `jsonapi:"attr,tagFilter"`
+	// The OAuth token is not patched by the client, it's updated when the server refreshes the expired token.
+	AccessToken  *string
+	ExpiresTs    *int64
+	RefreshToken *string
 }
 
 type RepositoryDelete struct {
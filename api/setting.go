@@ -14,6 +14,9 @@ const (
 	// e.g. For a phpmyadmin instance running on http://myphpadmin.example.com:8080, the setting would be:
 	// http://myphpadmin.example.com:8080/index.php?route=/database/sql&db={{DB_NAME}}
 	SettingConsoleURL SettingName = "bb.console.url"
+	// The backup encryption key generated by the earlier versions, it's only read to move it over to
+	// --backup-encryption-key. Never store the key here, since it sits next to the backups in the data directory.
+	SettingBackupEncryptionKey SettingName = "bb.backup.encryption_key"
 )
 
 type Setting struct {
@@ -12,6 +12,7 @@ type ConnectionInfo struct {
 	Port       string  `jsonapi:"attr,port"`
 	Username   string  `jsonapi:"attr,username"`
 	Password   string  `jsonapi:"attr,password"`
+	SSLMode    string  `jsonapi:"attr,sslMode"`
 	InstanceId *int    `jsonapi:"attr,instanceId"`
 }
 
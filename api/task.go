@@ -65,16 +65,44 @@ type TaskDatabaseSchemaUpdatePayload struct {
 	Statement         string               `json:"statement,omitempty"`
 	RollbackStatement string               `json:"rollbackStatement,omitempty"`
 	VCSPushEvent      *common.VCSPushEvent `json:"pushEvent,omitempty"`
+	// If true, the task only validates the migration without applying it.
+	DryRun bool `json:"dryRun,omitempty"`
+	// AdviceList is the SQL review result of the statement. The task can't be approved if any advice is at the ERROR level.
+	AdviceList []SQLReviewAdvice `json:"adviceList,omitempty"`
+}
+
+// SQLReviewAdviceLevel is the level of the SQL review advice, it's the level the environment assigns to the rule.
+type SQLReviewAdviceLevel string
+
+const (
+	SQLReviewAdviceError   SQLReviewAdviceLevel = "ERROR"
+	SQLReviewAdviceWarning SQLReviewAdviceLevel = "WARNING"
+)
+
+// SQLReviewAdvice is a single finding reported by the SQL review, see plugin/advisor for the rule types.
+type SQLReviewAdvice struct {
+	Type    string               `json:"type"`
+	Level   SQLReviewAdviceLevel `json:"level"`
+	Title   string               `json:"title"`
+	Content string               `json:"content"`
 }
 
 // TaskDatabaseBackupPayload is the task payload for database backup.
 type TaskDatabaseBackupPayload struct {
 	BackupID int `jsonapi:"primary,backupId"`
+	// VerificationInstanceID is the sandbox instance to verify the backup on if its verification is pending, the
+	// backup is verified on the task instance if it's 0.
+	VerificationInstanceID int `json:"verificationInstanceId,omitempty"`
 }
 
 // TaskDatabaseRestorePayload is the task payload for database restore.
 type TaskDatabaseRestorePayload struct {
 	BackupID int `jsonapi:"primary,backupId"`
+	// DatabaseName is the name of the new database to create on the task instance and restore the backup into.
+	// The backup is restored into the task database if it's empty.
+	DatabaseName string `json:"databaseName,omitempty"`
+	// PointInTimeTs is the time to recover the database to by replaying the archived binlog after the backup.
+	PointInTimeTs int64 `json:"pointInTimeTs,omitempty"`
 }
 
 type Task struct {
@@ -104,6 +132,9 @@ type Task struct {
 	Status  TaskStatus `jsonapi:"attr,status"`
 	Type    TaskType   `jsonapi:"attr,type"`
 	Payload string     `jsonapi:"attr,payload"`
+	// EarliestAllowedTs is the unix timestamp before which the task stays PENDING, 0 means no restriction.
+	// The scheduler also postpones the retry of a RUNNING task by setting it.
+	EarliestAllowedTs int64 `jsonapi:"attr,earliestAllowedTs"`
 }
 
 type TaskCreate struct {
@@ -130,6 +161,8 @@ type TaskCreate struct {
 	CharacterSet      string `jsonapi:"attr,characterSet"`
 	Collation         string `jsonapi:"attr,collation"`
 	VCSPushEvent      *common.VCSPushEvent
+	DryRun            bool  `jsonapi:"attr,dryRun"`
+	EarliestAllowedTs int64 `jsonapi:"attr,earliestAllowedTs"`
 }
 
 type TaskFind struct {
@@ -161,11 +194,44 @@ type TaskStatusPatch struct {
 	// Domain specific fields
 	Status  TaskStatus `jsonapi:"attr,status"`
 	Comment string     `jsonapi:"attr,comment"`
+	// OldStatus is the status the transition is validated against. If specified, the patch fails with ECONFLICT if
+	// the task is no longer in this status, e.g. it's canceled while the worker is finishing it.
+	OldStatus TaskStatus
+}
+
+// TaskPatch is the API message for patching a task.
+type TaskPatch struct {
+	ID int
+
+	// Standard fields
+	// Value is assigned from the jwt subject field passed by the client.
+	UpdaterId int
+
+	// Domain specific fields
+	EarliestAllowedTs *int64 `jsonapi:"attr,earliestAllowedTs"`
+}
+
+// TaskRetry is the API message for retrying a RUNNING task after a transient failure.
+type TaskRetry struct {
+	ID int
+
+	// Standard fields
+	UpdaterId int
+
+	// Domain specific fields
+	// Detail is the error of the failed attempt.
+	Detail string
+	// RetryTs is the unix timestamp to start the next attempt.
+	RetryTs int64
 }
 
 type TaskService interface {
 	CreateTask(ctx context.Context, create *TaskCreate) (*Task, error)
 	FindTaskList(ctx context.Context, find *TaskFind) ([]*Task, error)
 	FindTask(ctx context.Context, find *TaskFind) (*Task, error)
+	PatchTask(ctx context.Context, patch *TaskPatch) (*Task, error)
+	RetryTask(ctx context.Context, retry *TaskRetry) (*Task, error)
 	PatchTaskStatus(ctx context.Context, patch *TaskStatusPatch) (*Task, error)
+	CreateTaskRunLog(ctx context.Context, create *TaskRunLogCreate) (*TaskRunLog, error)
+	FindTaskRunLogList(ctx context.Context, find *TaskRunLogFind) ([]*TaskRunLog, error)
 }
@@ -50,6 +50,10 @@ type TaskRun struct {
 	Type    TaskType      `jsonapi:"attr,type"`
 	Detail  string        `jsonapi:"attr,detail"`
 	Payload string        `jsonapi:"attr,payload"`
+	// Attempt is the attempt number of the task, starting from 1.
+	Attempt int `jsonapi:"attr,attempt"`
+	// ScheduledTs is the unix timestamp the attempt starts after, which is the retry backoff. 0 means right away.
+	ScheduledTs int64 `jsonapi:"attr,scheduledTs"`
 }
 
 type TaskRunCreate struct {
@@ -61,9 +65,11 @@ type TaskRunCreate struct {
 	TaskId int
 
 	// Domain specific fields
-	Name    string   `jsonapi:"attr,name"`
-	Type    TaskType `jsonapi:"attr,type"`
-	Payload string   `jsonapi:"attr,payload"`
+	Name        string   `jsonapi:"attr,name"`
+	Type        TaskType `jsonapi:"attr,type"`
+	Payload     string   `jsonapi:"attr,payload"`
+	Attempt     int
+	ScheduledTs int64
 }
 
 type TaskRunFind struct {
@@ -96,9 +102,86 @@ type TaskRunStatusPatch struct {
 	Detail string
 }
 
+type TaskRunLogType string
+
+const (
+	// TaskRunLogStatementStart is the log written right before executing the statement, the payload is
+	// TaskRunLogStatementStartPayload.
+	TaskRunLogStatementStart TaskRunLogType = "bb.task-run-log.statement.start"
+	// TaskRunLogStatement is the log of executing the statement, the payload is TaskRunLogStatementPayload.
+	TaskRunLogStatement TaskRunLogType = "bb.task-run-log.statement"
+	// TaskRunLogBackupProgress is the log of the backup progress, the payload is TaskRunLogBackupProgressPayload.
+	TaskRunLogBackupProgress TaskRunLogType = "bb.task-run-log.backup.progress"
+)
+
+// TaskRunLogStatementStartPayload is the payload of TaskRunLogStatementStart.
+type TaskRunLogStatementStartPayload struct {
+	Statement string `json:"statement"`
+}
+
+// TaskRunLogStatementPayload is the payload of TaskRunLogStatement.
+type TaskRunLogStatementPayload struct {
+	Statement    string `json:"statement"`
+	DurationMs   int64  `json:"durationMs"`
+	RowsAffected int64  `json:"rowsAffected"`
+	// Error is empty if the statement succeeds.
+	Error string `json:"error,omitempty"`
+}
+
+// TaskRunLogBackupProgressPayload is the payload of TaskRunLogBackupProgress.
+type TaskRunLogBackupProgressPayload struct {
+	// Table is the table just dumped.
+	Table            string `json:"table"`
+	DumpedTableCount int    `json:"dumpedTableCount"`
+	TotalTableCount  int    `json:"totalTableCount"`
+}
+
+// TaskRunLog is a structured log entry recorded while the task run is running.
+type TaskRunLog struct {
+	ID int `jsonapi:"primary,taskRunLog"`
+
+	// Standard fields
+	CreatedTs int64 `jsonapi:"attr,createdTs"`
+
+	// Related fields
+	TaskRunId int `jsonapi:"attr,taskRunId"`
+
+	// Domain specific fields
+	Type    TaskRunLogType `jsonapi:"attr,type"`
+	Payload string         `jsonapi:"attr,payload"`
+}
+
+type TaskRunLogCreate struct {
+	// Related fields
+	TaskRunId int
+
+	// Domain specific fields
+	Type    TaskRunLogType
+	Payload string
+}
+
+type TaskRunLogFind struct {
+	// Related fields
+	TaskRunId *int
+
+	// Domain specific fields
+	// Only return the logs whose id is greater than AfterId, used for following the logs.
+	AfterId *int
+}
+
+func (find *TaskRunLogFind) String() string {
+	str, err := json.Marshal(*find)
+	if err != nil {
+		return err.Error()
+	}
+	return string(str)
+}
+
 type TaskRunService interface {
 	CreateTaskRun(ctx context.Context, tx *sql.Tx, create *TaskRunCreate) (*TaskRun, error)
 	FindTaskRunList(ctx context.Context, tx *sql.Tx, find *TaskRunFind) ([]*TaskRun, error)
 	FindTaskRun(ctx context.Context, tx *sql.Tx, find *TaskRunFind) (*TaskRun, error)
 	PatchTaskRunStatus(ctx context.Context, tx *sql.Tx, patch *TaskRunStatusPatch) (*TaskRun, error)
+	CreateTaskRunLog(ctx context.Context, tx *sql.Tx, create *TaskRunLogCreate) (*TaskRunLog, error)
+	FindTaskRunLogList(ctx context.Context, tx *sql.Tx, find *TaskRunLogFind) ([]*TaskRunLog, error)
 }
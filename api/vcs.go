@@ -19,12 +19,14 @@ type VCS struct {
 	UpdatedTs int64      `jsonapi:"attr,updatedTs"`
 
 	// Domain specific fields
-	Name          string         `jsonapi:"attr,name"`
-	Type          common.VCSType `jsonapi:"attr,type"`
-	InstanceURL   string         `jsonapi:"attr,instanceURL"`
-	ApiURL        string         `jsonapi:"attr,apiURL"`
-	ApplicationId string         `jsonapi:"attr,applicationId"`
-	Secret        string         `jsonapi:"attr,secret"`
+	Name        string         `jsonapi:"attr,name"`
+	Type        common.VCSType `jsonapi:"attr,type"`
+	InstanceURL string         `jsonapi:"attr,instanceURL"`
+	ApiURL      string         `jsonapi:"attr,apiURL"`
+	// ApplicationId and Secret are the client ID and secret of the OAuth application registered on the VCS, i.e.
+	// the GitLab application, the GitHub OAuth App or the Gitea OAuth2 application.
+	ApplicationId string `jsonapi:"attr,applicationId"`
+	Secret        string `jsonapi:"attr,secret"`
 }
 
 type VCSCreate struct {
@@ -75,6 +77,33 @@ type VCSDelete struct {
 	DeleterId int
 }
 
+// VCSTokenCreate is the message to exchange the OAuth code for the token on the server, which is required by the
+// VCS not allowing the browser to do so, e.g. GitHub.
+type VCSTokenCreate struct {
+	Code        string `jsonapi:"attr,code"`
+	RedirectURL string `jsonapi:"attr,redirectURL"`
+}
+
+// VCSToken is the OAuth token of the user on the VCS.
+type VCSToken struct {
+	ID string `jsonapi:"primary,vcsToken"`
+
+	AccessToken string `jsonapi:"attr,accessToken"`
+	// ExpiresTs is 0 if the token doesn't expire.
+	ExpiresTs    int64  `jsonapi:"attr,expiresTs"`
+	RefreshToken string `jsonapi:"attr,refreshToken"`
+}
+
+// ExternalRepository is a repository on the VCS which can be linked to a project.
+type ExternalRepository struct {
+	// ID is the ExternalId to link the repository with.
+	ID string `jsonapi:"primary,externalRepository"`
+
+	Name     string `jsonapi:"attr,name"`
+	FullPath string `jsonapi:"attr,fullPath"`
+	WebURL   string `jsonapi:"attr,webURL"`
+}
+
 type VCSService interface {
 	CreateVCS(ctx context.Context, create *VCSCreate) (*VCS, error)
 	FindVCSList(ctx context.Context, find *VCSFind) ([]*VCS, error)
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -76,7 +77,7 @@ func dumpDatabase(databaseType, username, password, hostname, port, database, di
 		defer conn.Close()
 
 		dp := mysqldump.New(conn)
-		databases, err := dp.GetDumpableDatabases(database)
+		databases, err := dp.GetDumpableDatabases(context.Background(), database)
 		if err != nil {
 			return err
 		}
@@ -88,13 +89,13 @@ func dumpDatabase(databaseType, username, password, hostname, port, database, di
 			}
 			defer out.Close()
 
-			if err := dp.Dump(dbName, out, schemaOnly, dumpAll); err != nil {
+			if err := dp.Dump(context.Background(), dbName, out, schemaOnly, dumpAll); err != nil {
 				return err
 			}
 		}
 		return nil
 	case "pg":
-		conn, err := connect.NewPostgres(username, password, hostname, port, database, tlsCfg.SslCA, tlsCfg.SslCert, tlsCfg.SslKey)
+		conn, err := connect.NewPostgres(username, password, hostname, port, database, "" /* sslMode */, tlsCfg.SslCA, tlsCfg.SslCert, tlsCfg.SslKey)
 		if err != nil {
 			return fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %v", username, password, hostname, port, err)
 		}
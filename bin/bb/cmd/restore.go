@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 
@@ -70,12 +71,12 @@ func restoreDatabase(databaseType, username, password, hostname, port, database,
 		}
 		defer conn.Close()
 
-		if err := mysqlrestore.Restore(conn, sc); err != nil {
+		if err := mysqlrestore.Restore(context.Background(), conn, sc); err != nil {
 			return fmt.Errorf("mysqlrestore.Restore() got error: %v", err)
 		}
 		return nil
 	case "pg":
-		conn, err := connect.NewPostgres(username, password, hostname, port, database, tlsCfg.SslCA, tlsCfg.SslCert, tlsCfg.SslKey)
+		conn, err := connect.NewPostgres(username, password, hostname, port, database, "" /* sslMode */, tlsCfg.SslCA, tlsCfg.SslCert, tlsCfg.SslKey)
 		if err != nil {
 			return fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %v", username, password, hostname, port, err)
 		}
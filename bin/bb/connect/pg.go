@@ -16,12 +16,12 @@ type PostgresConnect struct {
 }
 
 // New creates a new Postgres connection.
-func NewPostgres(username, password, hostname, port, database, sslCA, sslCert, sslKey string) (*PostgresConnect, error) {
+func NewPostgres(username, password, hostname, port, database, sslMode, sslCA, sslCert, sslKey string) (*PostgresConnect, error) {
 	if (sslCert == "" && sslKey != "") || (sslCert != "" && sslKey == "") {
 		return nil, fmt.Errorf("ssl-cert and ssl-key must be both set or unset.")
 	}
 
-	dns, err := guessDNS(username, password, hostname, port, database, sslCA, sslCert, sslKey)
+	dns, err := guessDNS(username, password, hostname, port, database, sslMode, sslCA, sslCert, sslKey)
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +46,7 @@ func (c *PostgresConnect) Close() error {
 }
 
 // guessDNS will guess the dns of a valid DB connection.
-func guessDNS(username, password, hostname, port, database, sslCA, sslCert, sslKey string) (string, error) {
+func guessDNS(username, password, hostname, port, database, sslMode, sslCA, sslCert, sslKey string) (string, error) {
 	// dbname is guessed if not specified.
 	m := map[string]string{
 		"host":     hostname,
@@ -56,7 +56,11 @@ func guessDNS(username, password, hostname, port, database, sslCA, sslCert, sslK
 	}
 
 	if sslCA == "" {
-		m["sslmode"] = "disable"
+		// The sslMode is used if there is no CA to verify the server with, e.g. "require" for the managed PostgreSQL.
+		m["sslmode"] = sslMode
+		if sslMode == "" {
+			m["sslmode"] = "disable"
+		}
 	} else {
 		m["sslmode"] = "verify-ca"
 		m["sslrootcert"] = sslCA
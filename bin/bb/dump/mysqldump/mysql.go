@@ -2,9 +2,10 @@
 package mysqldump
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
+	"io"
 	"strings"
 
 	"github.com/bytebase/bytebase/bin/bb/connect"
@@ -66,23 +67,78 @@ const (
 		"DELIMITER ;\n"
 )
 
+// TableProgressFunc is called after dumping each table or view, dumped is the number of the dumped ones out of total.
+type TableProgressFunc func(table string, dumped, total int)
+
+// queryer is implemented by both *sql.DB and *sql.Conn.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Snapshot is the consistent snapshot which the table data is dumped in.
+type Snapshot struct {
+	// BinlogFile and BinlogPosition are the binlog coordinates of the snapshot.
+	BinlogFile     string
+	BinlogPosition int64
+	// Ts is the unix timestamp of the snapshot on the instance.
+	Ts int64
+}
+
 // Dumper is a class for dumping schemas of a MySQL instance.
 type Dumper struct {
-	conn *connect.MysqlConnect
+	conn          *connect.MysqlConnect
+	tableProgress TableProgressFunc
+	// dataQueryer queries the table data, it's the connection holding the snapshot transaction in DumpConsistent.
+	dataQueryer queryer
+	// tableRowCount is the number of rows of each table dumped by the last Dump.
+	tableRowCount map[string]int64
 }
 
 // New creates a new MySQL dumper.
 func New(conn *connect.MysqlConnect) *Dumper {
 	return &Dumper{
-		conn: conn,
+		conn:        conn,
+		dataQueryer: conn.DB,
+	}
+}
+
+// SetTableProgressFunc sets f to report the dump progress of the tables and views.
+func (dp *Dumper) SetTableProgressFunc(f TableProgressFunc) {
+	dp.tableProgress = f
+}
+
+// TableRowCount returns the number of rows of each base table dumped by the last Dump, keyed by the table name.
+// It's empty if the dump is schema only.
+func (dp *Dumper) TableRowCount() map[string]int64 {
+	return dp.tableRowCount
+}
+
+// CountTableRows returns the number of rows of each base table in the database, keyed the same as TableRowCount.
+func (dp *Dumper) CountTableRows(ctx context.Context, dbName string) (map[string]int64, error) {
+	tables, err := dp.getTables(ctx, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables of database %q: %w", dbName, err)
+	}
+	counts := make(map[string]int64)
+	for _, tbl := range tables {
+		if tbl.tableType != "BASE TABLE" {
+			continue
+		}
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM `%s`.`%s`;", dbName, tbl.name)
+		if err := dp.conn.DB.QueryRowContext(ctx, query).Scan(&count); err != nil {
+			return nil, err
+		}
+		counts[tbl.name] = count
 	}
+	return counts, nil
 }
 
 // GetDumpableDatabases gets the databases to be exported.
-func (dp *Dumper) GetDumpableDatabases(database string) ([]string, error) {
-	dbNames, err := dp.getDatabases()
+func (dp *Dumper) GetDumpableDatabases(ctx context.Context, database string) ([]string, error) {
+	dbNames, err := dp.getDatabases(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get databases: %s", err)
+		return nil, fmt.Errorf("failed to get databases: %w", err)
 	}
 	if database != "" {
 		exist := false
@@ -108,71 +164,76 @@ func (dp *Dumper) GetDumpableDatabases(database string) ([]string, error) {
 }
 
 // Dump dumps the schema of a MySQL instance.
-func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly, dumpAll bool) error {
+func (dp *Dumper) Dump(ctx context.Context, dbName string, out io.Writer, schemaOnly, dumpAll bool) error {
 	// mysqldump -u root --databases dbName --no-data --routines --events --triggers --compact
 
 	// Database header.
 	header := fmt.Sprintf(databaseHeaderFmt, dbName)
-	if _, err := out.WriteString(header); err != nil {
+	if _, err := io.WriteString(out, header); err != nil {
 		return err
 	}
 
 	// Table and view statement.
-	tables, err := dp.getTables(dbName)
+	dp.tableRowCount = make(map[string]int64)
+	tables, err := dp.getTables(ctx, dbName)
 	if err != nil {
-		return fmt.Errorf("failed to get tables of database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get tables of database %q: %w", dbName, err)
 	}
-	for _, tbl := range tables {
-		if _, err := out.WriteString(fmt.Sprintf("%s\n", tbl.statement)); err != nil {
+	for i, tbl := range tables {
+		if _, err := io.WriteString(out, fmt.Sprintf("%s\n", tbl.statement)); err != nil {
 			return err
 		}
 		if !schemaOnly && tbl.tableType == "BASE TABLE" {
-			stmts, err := dp.getTableData(dbName, tbl.name, dumpAll)
+			stmts, err := dp.getTableData(ctx, dbName, tbl.name, dumpAll)
 			if err != nil {
 				return err
 			}
+			dp.tableRowCount[tbl.name] = int64(len(stmts))
 			for _, stmt := range stmts {
-				if _, err := out.WriteString(stmt); err != nil {
+				if _, err := io.WriteString(out, stmt); err != nil {
 					return err
 				}
 			}
 			if len(stmts) > 0 {
-				if _, err := out.WriteString("\n"); err != nil {
+				if _, err := io.WriteString(out, "\n"); err != nil {
 					return err
 				}
 			}
 		}
+		if dp.tableProgress != nil {
+			dp.tableProgress(tbl.name, i+1, len(tables))
+		}
 	}
 
 	// Procedure and function (routine) statements.
-	routines, err := dp.getRoutines(dbName)
+	routines, err := dp.getRoutines(ctx, dbName)
 	if err != nil {
-		return fmt.Errorf("failed to get routines of database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get routines of database %q: %w", dbName, err)
 	}
 	for _, rt := range routines {
-		if _, err := out.WriteString(fmt.Sprintf("%s\n", rt.statement)); err != nil {
+		if _, err := io.WriteString(out, fmt.Sprintf("%s\n", rt.statement)); err != nil {
 			return err
 		}
 	}
 
 	// Event statements.
-	events, err := dp.getEvents(dbName)
+	events, err := dp.getEvents(ctx, dbName)
 	if err != nil {
-		return fmt.Errorf("failed to get events of database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get events of database %q: %w", dbName, err)
 	}
 	for _, et := range events {
-		if _, err := out.WriteString(fmt.Sprintf("%s\n", et.statement)); err != nil {
+		if _, err := io.WriteString(out, fmt.Sprintf("%s\n", et.statement)); err != nil {
 			return err
 		}
 	}
 
 	// Trigger statements.
-	triggers, err := dp.getTriggers(dbName)
+	triggers, err := dp.getTriggers(ctx, dbName)
 	if err != nil {
-		return fmt.Errorf("failed to get triggers of database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get triggers of database %q: %w", dbName, err)
 	}
 	for _, tr := range triggers {
-		if _, err := out.WriteString(fmt.Sprintf("%s\n", tr.statement)); err != nil {
+		if _, err := io.WriteString(out, fmt.Sprintf("%s\n", tr.statement)); err != nil {
 			return err
 		}
 	}
@@ -180,10 +241,88 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly, dumpAll bool) er
 	return nil
 }
 
+// DumpConsistent dumps the database like Dump, except that the table data is dumped in a consistent snapshot
+// transaction, whose binlog coordinates are returned so that the changes after the dump can be replayed from the
+// binlog. It takes the global read lock briefly to start the snapshot, which requires the RELOAD privilege, and the
+// binlog must be enabled. Like mysqldump --single-transaction, only the data of the transactional tables such as
+// InnoDB is consistent, and the schema must not be changed during the dump.
+func (dp *Dumper) DumpConsistent(ctx context.Context, dbName string, out io.Writer, schemaOnly bool) (*Snapshot, error) {
+	conn, err := dp.conn.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	snapshot, err := startSnapshot(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	// The snapshot is read only, so it's always rolled back.
+	defer conn.ExecContext(context.Background(), "ROLLBACK;")
+
+	snapshotDumper := &Dumper{
+		conn:          dp.conn,
+		tableProgress: dp.tableProgress,
+		dataQueryer:   conn,
+	}
+	if err := snapshotDumper.Dump(ctx, dbName, out, schemaOnly, false /* dumpAll */); err != nil {
+		return nil, err
+	}
+	dp.tableRowCount = snapshotDumper.tableRowCount
+	return snapshot, nil
+}
+
+// startSnapshot starts the consistent snapshot transaction on conn under the global read lock, so that no write can
+// happen between the snapshot and reading its binlog coordinates.
+func startSnapshot(ctx context.Context, conn *sql.Conn) (*Snapshot, error) {
+	if _, err := conn.ExecContext(ctx, "FLUSH TABLES WITH READ LOCK;"); err != nil {
+		return nil, fmt.Errorf("failed to acquire the global read lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "UNLOCK TABLES;")
+
+	if _, err := conn.ExecContext(ctx, "SET SESSION TRANSACTION ISOLATION LEVEL REPEATABLE READ;"); err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, "START TRANSACTION WITH CONSISTENT SNAPSHOT;"); err != nil {
+		return nil, fmt.Errorf("failed to start the consistent snapshot: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SHOW MASTER STATUS;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("binlog is not enabled")
+	}
+	// The columns vary among the versions, but the first two are always File and Position.
+	snapshot := &Snapshot{}
+	values := []interface{}{&snapshot.BinlogFile, &snapshot.BinlogPosition}
+	for i := len(values); i < len(cols); i++ {
+		values = append(values, new(sql.RawBytes))
+	}
+	if err := rows.Scan(values...); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := conn.QueryRowContext(ctx, "SELECT UNIX_TIMESTAMP();").Scan(&snapshot.Ts); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
 // getDatabases gets all databases of an instance.
-func (dp *Dumper) getDatabases() ([]string, error) {
+func (dp *Dumper) getDatabases(ctx context.Context) ([]string, error) {
 	var dbNames []string
-	rows, err := dp.conn.DB.Query("SHOW DATABASES;")
+	rows, err := dp.conn.DB.QueryContext(ctx, "SHOW DATABASES;")
 	if err != nil {
 		return nil, err
 	}
@@ -226,10 +365,10 @@ type triggerSchema struct {
 }
 
 // getTables gets all tables of a database.
-func (dp *Dumper) getTables(dbName string) ([]tableSchema, error) {
+func (dp *Dumper) getTables(ctx context.Context, dbName string) ([]tableSchema, error) {
 	var tables []tableSchema
 	query := fmt.Sprintf("SHOW FULL TABLES FROM %s;", dbName)
-	rows, err := dp.conn.DB.Query(query)
+	rows, err := dp.conn.DB.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -240,9 +379,9 @@ func (dp *Dumper) getTables(dbName string) ([]tableSchema, error) {
 		if err := rows.Scan(&tbl.name, &tbl.tableType); err != nil {
 			return nil, err
 		}
-		stmt, err := dp.getTableStmt(dbName, tbl.name, tbl.tableType)
+		stmt, err := dp.getTableStmt(ctx, dbName, tbl.name, tbl.tableType)
 		if err != nil {
-			return nil, fmt.Errorf("getTableStmt(%q, %q, %q) got error: %s", dbName, tbl.name, tbl.tableType, err)
+			return nil, fmt.Errorf("getTableStmt(%q, %q, %q) got error: %w", dbName, tbl.name, tbl.tableType, err)
 		}
 		tbl.statement = stmt
 		tables = append(tables, tbl)
@@ -251,11 +390,11 @@ func (dp *Dumper) getTables(dbName string) ([]tableSchema, error) {
 }
 
 // getTableStmt gets the create statement of a table.
-func (dp *Dumper) getTableStmt(dbName, tblName, tblType string) (string, error) {
+func (dp *Dumper) getTableStmt(ctx context.Context, dbName, tblName, tblType string) (string, error) {
 	switch tblType {
 	case "BASE TABLE":
 		query := fmt.Sprintf("SHOW CREATE TABLE %s.%s;", dbName, tblName)
-		rows, err := dp.conn.DB.Query(query)
+		rows, err := dp.conn.DB.QueryContext(ctx, query)
 		if err != nil {
 			return "", err
 		}
@@ -272,7 +411,7 @@ func (dp *Dumper) getTableStmt(dbName, tblName, tblType string) (string, error)
 	case "VIEW":
 		// This differs from mysqldump as it includes.
 		query := fmt.Sprintf("SHOW CREATE VIEW %s.%s;", dbName, tblName)
-		rows, err := dp.conn.DB.Query(query)
+		rows, err := dp.conn.DB.QueryContext(ctx, query)
 		if err != nil {
 			return "", err
 		}
@@ -293,9 +432,9 @@ func (dp *Dumper) getTableStmt(dbName, tblName, tblType string) (string, error)
 }
 
 // getTableData gets the data of a table.
-func (dp *Dumper) getTableData(dbName, tblName string, dumpAll bool) ([]string, error) {
+func (dp *Dumper) getTableData(ctx context.Context, dbName, tblName string, dumpAll bool) ([]string, error) {
 	query := fmt.Sprintf("SELECT * FROM `%s`.`%s`;", dbName, tblName)
-	rows, err := dp.conn.DB.Query(query)
+	rows, err := dp.dataQueryer.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -347,11 +486,11 @@ func isNumeric(t string) bool {
 }
 
 // getRoutines gets all routines of a database.
-func (dp *Dumper) getRoutines(dbName string) ([]routineSchema, error) {
+func (dp *Dumper) getRoutines(ctx context.Context, dbName string) ([]routineSchema, error) {
 	var routines []routineSchema
 	for _, routineType := range []string{"FUNCTION", "PROCEDURE"} {
 		query := fmt.Sprintf("SHOW %s STATUS WHERE Db = ?;", routineType)
-		rows, err := dp.conn.DB.Query(query, dbName)
+		rows, err := dp.conn.DB.QueryContext(ctx, query, dbName)
 		if err != nil {
 			return nil, err
 		}
@@ -373,9 +512,9 @@ func (dp *Dumper) getRoutines(dbName string) ([]routineSchema, error) {
 			r.name = fmt.Sprintf("%s", *values[1].(*interface{}))
 			r.routineType = fmt.Sprintf("%s", *values[2].(*interface{}))
 
-			stmt, err := dp.getRoutineStmt(dbName, r.name, r.routineType)
+			stmt, err := dp.getRoutineStmt(ctx, dbName, r.name, r.routineType)
 			if err != nil {
-				return nil, fmt.Errorf("getRoutineStmt(%q, %q, %q) got error: %s", dbName, r.name, r.routineType, err)
+				return nil, fmt.Errorf("getRoutineStmt(%q, %q, %q) got error: %w", dbName, r.name, r.routineType, err)
 			}
 			r.statement = stmt
 			routines = append(routines, r)
@@ -385,9 +524,9 @@ func (dp *Dumper) getRoutines(dbName string) ([]routineSchema, error) {
 }
 
 // getRoutineStmt gets the create statement of a routine.
-func (dp *Dumper) getRoutineStmt(dbName, routineName, routineType string) (string, error) {
+func (dp *Dumper) getRoutineStmt(ctx context.Context, dbName, routineName, routineType string) (string, error) {
 	query := fmt.Sprintf("SHOW CREATE %s %s.%s;", routineType, dbName, routineName)
-	rows, err := dp.conn.DB.Query(query)
+	rows, err := dp.conn.DB.QueryContext(ctx, query)
 	if err != nil {
 		return "", err
 	}
@@ -417,9 +556,9 @@ func getReadableRoutineType(s string) string {
 }
 
 // getEvents gets all events of a database.
-func (dp *Dumper) getEvents(dbName string) ([]eventSchema, error) {
+func (dp *Dumper) getEvents(ctx context.Context, dbName string) ([]eventSchema, error) {
 	var events []eventSchema
-	rows, err := dp.conn.DB.Query(fmt.Sprintf("SHOW EVENTS FROM %s;", dbName))
+	rows, err := dp.conn.DB.QueryContext(ctx, fmt.Sprintf("SHOW EVENTS FROM %s;", dbName))
 	if err != nil {
 		return nil, err
 	}
@@ -439,9 +578,9 @@ func (dp *Dumper) getEvents(dbName string) ([]eventSchema, error) {
 			return nil, err
 		}
 		r.name = fmt.Sprintf("%s", *values[1].(*interface{}))
-		stmt, err := dp.getEventStmt(dbName, r.name)
+		stmt, err := dp.getEventStmt(ctx, dbName, r.name)
 		if err != nil {
-			return nil, fmt.Errorf("getEventStmt(%q, %q) got error: %s", dbName, r.name, err)
+			return nil, fmt.Errorf("getEventStmt(%q, %q) got error: %w", dbName, r.name, err)
 		}
 		r.statement = stmt
 		events = append(events, r)
@@ -450,9 +589,9 @@ func (dp *Dumper) getEvents(dbName string) ([]eventSchema, error) {
 }
 
 // getEventStmt gets the create statement of an event.
-func (dp *Dumper) getEventStmt(dbName, eventName string) (string, error) {
+func (dp *Dumper) getEventStmt(ctx context.Context, dbName, eventName string) (string, error) {
 	query := fmt.Sprintf("SHOW CREATE EVENT %s.%s;", dbName, eventName)
-	rows, err := dp.conn.DB.Query(query)
+	rows, err := dp.conn.DB.QueryContext(ctx, query)
 	if err != nil {
 		return "", err
 	}
@@ -469,9 +608,9 @@ func (dp *Dumper) getEventStmt(dbName, eventName string) (string, error) {
 }
 
 // getTriggers gets all triggers of a database.
-func (dp *Dumper) getTriggers(dbName string) ([]triggerSchema, error) {
+func (dp *Dumper) getTriggers(ctx context.Context, dbName string) ([]triggerSchema, error) {
 	var triggers []triggerSchema
-	rows, err := dp.conn.DB.Query(fmt.Sprintf("SHOW TRIGGERS FROM %s;", dbName))
+	rows, err := dp.conn.DB.QueryContext(ctx, fmt.Sprintf("SHOW TRIGGERS FROM %s;", dbName))
 	if err != nil {
 		return nil, err
 	}
@@ -491,9 +630,9 @@ func (dp *Dumper) getTriggers(dbName string) ([]triggerSchema, error) {
 			return nil, err
 		}
 		tr.name = fmt.Sprintf("%s", *values[0].(*interface{}))
-		stmt, err := dp.getTriggerStmt(dbName, tr.name)
+		stmt, err := dp.getTriggerStmt(ctx, dbName, tr.name)
 		if err != nil {
-			return nil, fmt.Errorf("getTriggerStmt(%q, %q) got error: %s", dbName, tr.name, err)
+			return nil, fmt.Errorf("getTriggerStmt(%q, %q) got error: %w", dbName, tr.name, err)
 		}
 		tr.statement = stmt
 		triggers = append(triggers, tr)
@@ -502,9 +641,9 @@ func (dp *Dumper) getTriggers(dbName string) ([]triggerSchema, error) {
 }
 
 // getTriggerStmt gets the create statement of a trigger.
-func (dp *Dumper) getTriggerStmt(dbName, triggerName string) (string, error) {
+func (dp *Dumper) getTriggerStmt(ctx context.Context, dbName, triggerName string) (string, error) {
 	query := fmt.Sprintf("SHOW CREATE TRIGGER %s.%s;", dbName, triggerName)
-	rows, err := dp.conn.DB.Query(query)
+	rows, err := dp.conn.DB.QueryContext(ctx, query)
 	if err != nil {
 		return "", err
 	}
@@ -4,7 +4,7 @@ package pgdump
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"io"
 	"regexp"
 	"strings"
 
@@ -171,6 +171,8 @@ var (
 // Dumper is a class for dumping schemas of a Postgres instance.
 type Dumper struct {
 	conn *connect.PostgresConnect
+	// tableRowCount is the number of rows of each table dumped by the last Dump.
+	tableRowCount map[string]int64
 }
 
 // New creates a new Postgres dumper.
@@ -180,11 +182,38 @@ func New(conn *connect.PostgresConnect) *Dumper {
 	}
 }
 
+// TableRowCount returns the number of rows of each table dumped by the last Dump, keyed by the schema qualified
+// table name. It's empty if the dump is schema only.
+func (dp *Dumper) TableRowCount() map[string]int64 {
+	return dp.tableRowCount
+}
+
+// CountTableRows returns the number of rows of each table in the database, keyed the same as TableRowCount.
+func (dp *Dumper) CountTableRows(dbName string) (map[string]int64, error) {
+	if err := dp.conn.SwitchDatabase(dbName); err != nil {
+		return nil, err
+	}
+	tables, err := dp.getPgTables()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tables from database %q: %w", dbName, err)
+	}
+	counts := make(map[string]int64)
+	for _, tbl := range tables {
+		var count int64
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s.%s;", tbl.schemaName, tbl.name)
+		if err := dp.conn.DB.QueryRow(query).Scan(&count); err != nil {
+			return nil, err
+		}
+		counts[fmt.Sprintf("%s.%s", tbl.schemaName, tbl.name)] = count
+	}
+	return counts, nil
+}
+
 // GetDumpableDatabases gets the databases to be exported.
 func (dp *Dumper) GetDumpableDatabases(database string) ([]string, error) {
 	dbNames, err := dp.getDatabases()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get databases: %s", err)
+		return nil, fmt.Errorf("failed to get databases: %w", err)
 	}
 
 	if database != "" {
@@ -211,7 +240,7 @@ func (dp *Dumper) GetDumpableDatabases(database string) ([]string, error) {
 }
 
 // Dump dumps the schema of a Postgres instance.
-func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
+func (dp *Dumper) Dump(dbName string, out io.Writer, schemaOnly bool) error {
 	// pg_dump -d dbName --schema-only
 	if err := dp.conn.SwitchDatabase(dbName); err != nil {
 		return err
@@ -219,7 +248,7 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 
 	// Database statement.
 	dbStmt := getDatabaseStmt(dbName)
-	if _, err := out.WriteString(dbStmt); err != nil {
+	if _, err := io.WriteString(out, dbStmt); err != nil {
 		return err
 	}
 
@@ -229,7 +258,7 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 		return err
 	}
 	for _, schema := range schemas {
-		if _, err := out.WriteString(schema.Statement()); err != nil {
+		if _, err := io.WriteString(out, schema.Statement()); err != nil {
 			return err
 		}
 	}
@@ -237,23 +266,24 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 	// Sequence statements.
 	seqs, err := dp.getSequences()
 	if err != nil {
-		return fmt.Errorf("failed to get sequences from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get sequences from database %q: %w", dbName, err)
 	}
 	for _, seq := range seqs {
-		if _, err := out.WriteString(seq.Statement()); err != nil {
+		if _, err := io.WriteString(out, seq.Statement()); err != nil {
 			return err
 		}
 	}
 
 	// Table statements.
+	dp.tableRowCount = make(map[string]int64)
 	tables, err := dp.getPgTables()
 	if err != nil {
-		return fmt.Errorf("failed to get tables from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get tables from database %q: %w", dbName, err)
 	}
 
 	constraints := make(map[string]bool)
 	for _, tbl := range tables {
-		if _, err := out.WriteString(tbl.Statement()); err != nil {
+		if _, err := io.WriteString(out, tbl.Statement()); err != nil {
 			return err
 		}
 		for _, constraint := range tbl.constraints {
@@ -265,13 +295,14 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 			if err != nil {
 				return err
 			}
+			dp.tableRowCount[fmt.Sprintf("%s.%s", tbl.schemaName, tbl.name)] = int64(len(stmts))
 			for _, stmt := range stmts {
-				if _, err := out.WriteString(stmt); err != nil {
+				if _, err := io.WriteString(out, stmt); err != nil {
 					return err
 				}
 			}
 			if len(stmts) > 0 {
-				if _, err := out.WriteString("\n"); err != nil {
+				if _, err := io.WriteString(out, "\n"); err != nil {
 					return err
 				}
 			}
@@ -281,10 +312,10 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 	// View statements.
 	views, err := dp.getViews()
 	if err != nil {
-		return fmt.Errorf("failed to get views from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get views from database %q: %w", dbName, err)
 	}
 	for _, view := range views {
-		if _, err := out.WriteString(view.Statement()); err != nil {
+		if _, err := io.WriteString(out, view.Statement()); err != nil {
 			return err
 		}
 	}
@@ -292,14 +323,14 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 	// Index statements.
 	indices, err := dp.getIndices()
 	if err != nil {
-		return fmt.Errorf("failed to get indices from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get indices from database %q: %w", dbName, err)
 	}
 	for _, idx := range indices {
 		key := fmt.Sprintf("%s.%s.%s", idx.schemaName, idx.tableName, idx.name)
 		if constraints[key] {
 			continue
 		}
-		if _, err := out.WriteString(idx.Statement()); err != nil {
+		if _, err := io.WriteString(out, idx.Statement()); err != nil {
 			return err
 		}
 	}
@@ -307,10 +338,10 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 	// Function statements.
 	fs, err := dp.getFunctions()
 	if err != nil {
-		return fmt.Errorf("failed to get functions from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get functions from database %q: %w", dbName, err)
 	}
 	for _, f := range fs {
-		if _, err := out.WriteString(f.Statement()); err != nil {
+		if _, err := io.WriteString(out, f.Statement()); err != nil {
 			return err
 		}
 	}
@@ -318,10 +349,10 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 	// Trigger statements.
 	triggers, err := dp.getTriggers()
 	if err != nil {
-		return fmt.Errorf("failed to get triggers from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get triggers from database %q: %w", dbName, err)
 	}
 	for _, tr := range triggers {
-		if _, err := out.WriteString(tr.Statement()); err != nil {
+		if _, err := io.WriteString(out, tr.Statement()); err != nil {
 			return err
 		}
 	}
@@ -329,10 +360,10 @@ func (dp *Dumper) Dump(dbName string, out *os.File, schemaOnly bool) error {
 	// Event statements.
 	events, err := dp.getEventTriggers()
 	if err != nil {
-		return fmt.Errorf("failed to get event triggers from database %q: %s", dbName, err)
+		return fmt.Errorf("failed to get event triggers from database %q: %w", dbName, err)
 	}
 	for _, evt := range events {
-		if _, err := out.WriteString(evt.Statement()); err != nil {
+		if _, err := io.WriteString(out, evt.Statement()); err != nil {
 			return err
 		}
 	}
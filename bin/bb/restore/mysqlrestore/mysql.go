@@ -3,6 +3,7 @@ package mysqlrestore
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"strings"
 
@@ -10,7 +11,7 @@ import (
 )
 
 // Restore restores the schema of a MySQL instance.
-func Restore(conn *connect.MysqlConnect, sc *bufio.Scanner) error {
+func Restore(ctx context.Context, conn *connect.MysqlConnect, sc *bufio.Scanner) error {
 	s := ""
 	delimiter := false
 	for sc.Scan() {
@@ -38,7 +39,7 @@ func Restore(conn *connect.MysqlConnect, sc *bufio.Scanner) error {
 			continue
 		}
 		if execute {
-			_, err := conn.DB.Exec(s)
+			_, err := conn.DB.ExecContext(ctx, s)
 			if err != nil {
 				return fmt.Errorf("execute query %q failed: %v", s, err)
 			}
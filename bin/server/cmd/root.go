@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"os"
@@ -22,6 +24,11 @@ import (
 const (
 	// Length for the secret used to sign the JWT auth token
 	SECRET_LENGTH = 32
+	// Length in bytes for the AES-256 key used to encrypt the backups
+	BACKUP_ENCRYPTION_KEY_LENGTH = 32
+	// The environment variable of the backup encryption key if --backup-encryption-key is not set, which keeps the
+	// key out of the process list.
+	BACKUP_ENCRYPTION_KEY_ENV = "BB_BACKUP_ENCRYPTION_KEY"
 
 	// http://patorjk.com/software/taag/#p=display&f=ANSI%20Shadow&t=Bytebase
 	GREETING_BANNER = `
@@ -65,6 +72,13 @@ var (
 	readonly bool
 	demo     bool
 	debug    bool
+	// taskConcurrency is the maximum number of tasks running at the same time.
+	taskConcurrency int
+	// taskInstanceConcurrency is the maximum number of tasks running on the same instance at the same time.
+	taskInstanceConcurrency int
+	// backupEncryptionKey is the hex encoded AES-256 key to encrypt the backups. It's kept out of the metadata
+	// database, so that the backups can't be decrypted with the metadata alone.
+	backupEncryptionKey string
 
 	logger *zap.Logger
 
@@ -123,6 +137,9 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&readonly, "readonly", false, "whether to run in read-only mode")
 	rootCmd.PersistentFlags().BoolVar(&demo, "demo", false, "whether to run using demo data")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "whether to enable debug level logging")
+	rootCmd.PersistentFlags().IntVar(&taskConcurrency, "task-concurrency", 10, "maximum number of tasks running at the same time")
+	rootCmd.PersistentFlags().IntVar(&taskInstanceConcurrency, "task-concurrency-per-instance", 1, "maximum number of tasks running on the same database instance at the same time")
+	rootCmd.PersistentFlags().StringVar(&backupEncryptionKey, "backup-encryption-key", "", fmt.Sprintf("hex encoded %d-byte AES-256 key used to encrypt the backups. Default is the %s environment variable. The encrypted backups can't be taken or restored without it, and changing it makes the existing encrypted backups unrestorable", BACKUP_ENCRYPTION_KEY_LENGTH, BACKUP_ENCRYPTION_KEY_ENV))
 }
 
 // -----------------------------------Command Line Config END--------------------------------------
@@ -163,6 +180,23 @@ func preStart() error {
 		return error
 	}
 
+	if taskConcurrency < 1 {
+		return fmt.Errorf("--task-concurrency %d must be at least 1", taskConcurrency)
+	}
+	if taskInstanceConcurrency < 1 {
+		return fmt.Errorf("--task-concurrency-per-instance %d must be at least 1", taskInstanceConcurrency)
+	}
+
+	if backupEncryptionKey == "" {
+		backupEncryptionKey = os.Getenv(BACKUP_ENCRYPTION_KEY_ENV)
+	}
+	if backupEncryptionKey != "" {
+		key, err := hex.DecodeString(backupEncryptionKey)
+		if err != nil || len(key) != BACKUP_ENCRYPTION_KEY_LENGTH {
+			return fmt.Errorf("--backup-encryption-key must be a hex encoded %d-byte key", BACKUP_ENCRYPTION_KEY_LENGTH)
+		}
+	}
+
 	// Convert to absolute path if relative path is supplied.
 	if !filepath.IsAbs(dataDir) {
 		absDir, err := filepath.Abs(filepath.Dir(os.Args[0]) + "/" + dataDir)
@@ -264,6 +298,48 @@ func initSetting(settingService api.SettingService) (*config, error) {
 	return result, nil
 }
 
+// moveBackupEncryptionKey moves the backup encryption key stored in the setting by the earlier versions over to
+// --backup-encryption-key, and returns the key to use. The stored key is kept as long as key is not passed, since the
+// existing encrypted backups can't be restored without it, and it's only cleared once the same key is passed.
+func moveBackupEncryptionKey(l *zap.Logger, settingService api.SettingService, key []byte) ([]byte, error) {
+	name := api.SettingBackupEncryptionKey
+	setting, err := settingService.FindSetting(context.Background(), &api.SettingFind{Name: &name})
+	if err != nil {
+		if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+			return key, nil
+		}
+		return nil, err
+	}
+	if setting.Value == "" {
+		return key, nil
+	}
+	storedKey, err := hex.DecodeString(setting.Value)
+	if err != nil || len(storedKey) != BACKUP_ENCRYPTION_KEY_LENGTH {
+		return nil, fmt.Errorf("invalid backup encryption key stored in setting %s", name)
+	}
+
+	if len(key) == 0 {
+		l.Warn(fmt.Sprintf("The backup encryption key is stored in setting %s, pass the same key by --backup-encryption-key or %s to move it out of the metadata database", name, BACKUP_ENCRYPTION_KEY_ENV))
+		return storedKey, nil
+	}
+	if !bytes.Equal(key, storedKey) {
+		return nil, fmt.Errorf("--backup-encryption-key differs from the key stored in setting %s, which the existing encrypted backups are encrypted with", name)
+	}
+	if readonly {
+		return key, nil
+	}
+	settingPatch := &api.SettingPatch{
+		UpdaterId: api.SYSTEM_BOT_ID,
+		Name:      name,
+		Value:     "",
+	}
+	if _, err := settingService.PatchSetting(context.Background(), settingPatch); err != nil {
+		return nil, err
+	}
+	l.Info(fmt.Sprintf("Cleared the backup encryption key stored in setting %s, which is passed by --backup-encryption-key now", name))
+	return key, nil
+}
+
 func (m *main) Run() error {
 	db := store.NewDB(m.l, m.profile.dsn, m.profile.seedDir, m.profile.forceResetSeed, readonly)
 	if err := db.Open(); err != nil {
@@ -278,7 +354,17 @@ func (m *main) Run() error {
 
 	m.db = db
 
-	s := server.NewServer(m.l, version, host, port, frontendHost, frontendPort, m.profile.mode, dataDir, m.profile.backupRunnerInterval, config.secret, readonly, demo, debug)
+	// The key has been validated in preStart, and it's empty if the encryption is not configured.
+	key, err := hex.DecodeString(backupEncryptionKey)
+	if err != nil {
+		return fmt.Errorf("invalid backup encryption key: %w", err)
+	}
+	key, err = moveBackupEncryptionKey(m.l, settingService, key)
+	if err != nil {
+		return fmt.Errorf("failed to move backup encryption key: %w", err)
+	}
+
+	s := server.NewServer(m.l, version, host, port, frontendHost, frontendPort, m.profile.mode, dataDir, m.profile.backupRunnerInterval, taskConcurrency, taskInstanceConcurrency, config.secret, key, readonly, demo, debug)
 	s.SettingService = settingService
 	s.PrincipalService = store.NewPrincipalService(m.l, db, s.CacheService)
 	s.MemberService = store.NewMemberService(m.l, db, s.CacheService)
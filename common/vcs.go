@@ -4,12 +4,20 @@ type VCSType string
 
 const (
 	GITLAB_SELF_HOST VCSType = "GITLAB_SELF_HOST"
+	// GITHUB is either github.com or a GitHub Enterprise Server instance, depending on the instance URL.
+	GITHUB VCSType = "GITHUB"
+	// GITEA is a self-hosted Gitea instance, which also works for Forgejo.
+	GITEA VCSType = "GITEA"
 )
 
 func (e VCSType) String() string {
 	switch e {
 	case GITLAB_SELF_HOST:
 		return "GITLAB_SELF_HOST"
+	case GITHUB:
+		return "GITHUB"
+	case GITEA:
+		return "GITEA"
 	}
 	return "UNKNOWN"
 }
@@ -0,0 +1,459 @@
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+	numberRegexp     = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	// PostgreSQL serial column default, e.g. nextval('t1_id_seq'::regclass).
+	nextvalRegexp = regexp.MustCompile(`^nextval\('((?:[^']|'')+)'::regclass\)$`)
+)
+
+// dbIndexDef is the index definition aggregated from the DBIndex list, which has one item per index column.
+type dbIndexDef struct {
+	name           string
+	unique         bool
+	primary        bool
+	constraint     bool
+	indexType      string
+	expressionList []string
+}
+
+func (def *dbIndexDef) equal(other *dbIndexDef) bool {
+	return def.unique == other.unique &&
+		def.primary == other.primary &&
+		def.constraint == other.constraint &&
+		strings.EqualFold(def.indexType, other.indexType) &&
+		strings.Join(def.expressionList, ",") == strings.Join(other.expressionList, ",")
+}
+
+// DiffSchema returns the ordered DDL statements that make the target schema match the source schema.
+// It covers tables, columns, indexes and comments, while views are skipped since DBTable doesn't record
+// the view definition, and the column order is ignored. Returns empty if both schemas are the same.
+//
+// The statements are ordered to satisfy the dependencies: indexes are dropped before dropping the columns
+// they refer to, and created after adding the columns they refer to. PostgreSQL sequences used by the serial
+// column defaults are created before the columns, and owned by the columns afterwards like serial does.
+func DiffSchema(dbType Type, source *DBSchema, target *DBSchema) ([]string, error) {
+	if dbType != Mysql && dbType != Postgres {
+		return nil, fmt.Errorf("schema diff is not supported for %s", dbType)
+	}
+	g := &ddlGenerator{dbType: dbType}
+
+	sourceTableMap := tableMap(source.TableList)
+	targetTableMap := tableMap(target.TableList)
+
+	var dropIndexList, dropTableList, createSequenceList, createTableList, alterColumnList, sequenceOwnerList, createIndexList, commentList []string
+	addSequence := func(table string, column DBColumn) {
+		if sequence, ok := g.sequence(column); ok {
+			createSequenceList = append(createSequenceList, fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s", sequence))
+			sequenceOwnerList = append(sequenceOwnerList, fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s", sequence, g.quoteTable(table), g.quoteIdentifier(column.Name)))
+		}
+	}
+
+	for _, name := range sortedTableNameList(targetTableMap) {
+		targetTable := targetTableMap[name]
+		sourceTable, ok := sourceTableMap[name]
+		if !ok {
+			dropTableList = append(dropTableList, fmt.Sprintf("DROP TABLE %s", g.quoteTable(name)))
+			continue
+		}
+
+		// Drop the changed indexes here and recreate them later.
+		sourceIndexMap := indexDefMap(sourceTable.IndexList)
+		targetIndexMap := indexDefMap(targetTable.IndexList)
+		for _, indexName := range sortedIndexNameList(targetIndexMap) {
+			targetIndex := targetIndexMap[indexName]
+			if sourceIndex, ok := sourceIndexMap[indexName]; !ok || !sourceIndex.equal(targetIndex) {
+				dropIndexList = append(dropIndexList, g.dropIndex(name, targetIndex))
+			}
+		}
+		for _, indexName := range sortedIndexNameList(sourceIndexMap) {
+			sourceIndex := sourceIndexMap[indexName]
+			if targetIndex, ok := targetIndexMap[indexName]; !ok || !sourceIndex.equal(targetIndex) {
+				createIndexList = append(createIndexList, g.createIndex(name, sourceIndex))
+			}
+		}
+
+		// Add and alter columns before dropping, so the table always has at least one column.
+		targetColumnMap := columnMap(targetTable.ColumnList)
+		sourceColumnMap := columnMap(sourceTable.ColumnList)
+		for _, column := range sortedColumnList(sourceTable.ColumnList) {
+			targetColumn, ok := targetColumnMap[column.Name]
+			if !ok {
+				addSequence(name, column)
+				alterColumnList = append(alterColumnList, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", g.quoteTable(name), g.columnDefinition(column)))
+				if g.dbType == Postgres && column.Comment != "" {
+					commentList = append(commentList, g.commentOnColumn(name, column))
+				}
+				continue
+			}
+			if !sameDefault(column, targetColumn) {
+				addSequence(name, column)
+			}
+			alterColumnList = append(alterColumnList, g.alterColumn(name, column, targetColumn)...)
+			if g.dbType == Postgres && column.Comment != targetColumn.Comment {
+				commentList = append(commentList, g.commentOnColumn(name, column))
+			}
+		}
+		for _, column := range sortedColumnList(targetTable.ColumnList) {
+			if _, ok := sourceColumnMap[column.Name]; !ok {
+				alterColumnList = append(alterColumnList, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", g.quoteTable(name), g.quoteIdentifier(column.Name)))
+			}
+		}
+
+		if sourceTable.Comment != targetTable.Comment {
+			commentList = append(commentList, g.commentOnTable(name, sourceTable.Comment))
+		}
+	}
+
+	for _, name := range sortedTableNameList(sourceTableMap) {
+		if _, ok := targetTableMap[name]; ok {
+			continue
+		}
+		sourceTable := sourceTableMap[name]
+		for _, column := range sortedColumnList(sourceTable.ColumnList) {
+			addSequence(name, column)
+		}
+		createTableList = append(createTableList, g.createTable(sourceTable))
+
+		indexMap := indexDefMap(sourceTable.IndexList)
+		for _, indexName := range sortedIndexNameList(indexMap) {
+			if index := indexMap[indexName]; !index.primary {
+				createIndexList = append(createIndexList, g.createIndex(name, index))
+			}
+		}
+		if g.dbType == Postgres {
+			if sourceTable.Comment != "" {
+				commentList = append(commentList, g.commentOnTable(name, sourceTable.Comment))
+			}
+			for _, column := range sortedColumnList(sourceTable.ColumnList) {
+				if column.Comment != "" {
+					commentList = append(commentList, g.commentOnColumn(name, column))
+				}
+			}
+		}
+	}
+
+	var statementList []string
+	for _, list := range [][]string{dropIndexList, dropTableList, createSequenceList, createTableList, alterColumnList, sequenceOwnerList, createIndexList, commentList} {
+		statementList = append(statementList, list...)
+	}
+	return statementList, nil
+}
+
+type ddlGenerator struct {
+	dbType Type
+}
+
+func (g *ddlGenerator) quoteIdentifier(identifier string) string {
+	if g.dbType == Mysql {
+		return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+	}
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// quoteTable quotes the table name. PostgreSQL table outside the public schema is named as "schema.table".
+func (g *ddlGenerator) quoteTable(name string) string {
+	if g.dbType == Postgres {
+		if i := strings.Index(name, "."); i >= 0 {
+			return g.quoteIdentifier(name[:i]) + "." + g.quoteIdentifier(name[i+1:])
+		}
+	}
+	return g.quoteIdentifier(name)
+}
+
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// defaultExpression returns the column default as SQL expression. PostgreSQL records the default as an expression
+// already, while MySQL records the literal value without quotes, and the expression without parentheses.
+func (g *ddlGenerator) defaultExpression(column DBColumn) string {
+	value := *column.Default
+	if g.dbType != Mysql {
+		return value
+	}
+	upper := strings.ToUpper(value)
+	if numberRegexp.MatchString(value) || upper == "NULL" || strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasPrefix(value, "(") {
+		return value
+	}
+	if strings.Contains(strings.ToUpper(column.Extra), "DEFAULT_GENERATED") {
+		return "(" + value + ")"
+	}
+	return quoteString(value)
+}
+
+// mysqlColumnAttribute returns the AUTO_INCREMENT and ON UPDATE attributes recorded in the column extra.
+func mysqlColumnAttribute(extra string) string {
+	var attribute string
+	fieldList := strings.Fields(extra)
+	for i, field := range fieldList {
+		switch {
+		case strings.EqualFold(field, "auto_increment"):
+			attribute += " AUTO_INCREMENT"
+		case strings.EqualFold(field, "on") && i+2 < len(fieldList) && strings.EqualFold(fieldList[i+1], "update"):
+			attribute += " ON UPDATE " + fieldList[i+2]
+		}
+	}
+	return attribute
+}
+
+// sequence returns the quoted name of the PostgreSQL sequence if the column default is from the sequence.
+func (g *ddlGenerator) sequence(column DBColumn) (string, bool) {
+	if g.dbType != Postgres || column.Default == nil {
+		return "", false
+	}
+	matches := nextvalRegexp.FindStringSubmatch(*column.Default)
+	if matches == nil {
+		return "", false
+	}
+	// The regclass literal is the sequence name quoted as needed, e.g. 'public."T1_id_seq"'.
+	return strings.ReplaceAll(matches[1], "''", "'"), true
+}
+
+func sameDefault(source DBColumn, target DBColumn) bool {
+	return (source.Default == nil && target.Default == nil) ||
+		(source.Default != nil && target.Default != nil && *source.Default == *target.Default)
+}
+
+func (g *ddlGenerator) columnDefinition(column DBColumn) string {
+	def := g.quoteIdentifier(column.Name) + " " + column.Type
+	if g.dbType == Mysql && column.Collation != "" {
+		def += " COLLATE " + column.Collation
+	}
+	if !column.Nullable {
+		def += " NOT NULL"
+	}
+	if column.Default != nil {
+		def += " DEFAULT " + g.defaultExpression(column)
+	}
+	if g.dbType == Mysql {
+		def += mysqlColumnAttribute(column.Extra)
+	}
+	if g.dbType == Mysql && column.Comment != "" {
+		def += " COMMENT " + quoteString(column.Comment)
+	}
+	return def
+}
+
+func (g *ddlGenerator) alterColumn(table string, source DBColumn, target DBColumn) []string {
+	if g.dbType == Mysql {
+		if source.Type == target.Type && source.Nullable == target.Nullable && sameDefault(source, target) &&
+			source.Collation == target.Collation && source.Comment == target.Comment &&
+			mysqlColumnAttribute(source.Extra) == mysqlColumnAttribute(target.Extra) {
+			return nil
+		}
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", g.quoteTable(table), g.columnDefinition(source))}
+	}
+
+	prefix := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s", g.quoteTable(table), g.quoteIdentifier(source.Name))
+	var list []string
+	if source.Type != target.Type {
+		list = append(list, fmt.Sprintf("%s TYPE %s", prefix, source.Type))
+	}
+	if source.Nullable != target.Nullable {
+		if source.Nullable {
+			list = append(list, prefix+" DROP NOT NULL")
+		} else {
+			list = append(list, prefix+" SET NOT NULL")
+		}
+	}
+	if !sameDefault(source, target) {
+		if source.Default == nil {
+			list = append(list, prefix+" DROP DEFAULT")
+		} else {
+			list = append(list, fmt.Sprintf("%s SET DEFAULT %s", prefix, *source.Default))
+		}
+	}
+	return list
+}
+
+func (g *ddlGenerator) createTable(table *DBTable) string {
+	var defList []string
+	for _, column := range sortedColumnList(table.ColumnList) {
+		defList = append(defList, "  "+g.columnDefinition(column))
+	}
+	indexMap := indexDefMap(table.IndexList)
+	for _, indexName := range sortedIndexNameList(indexMap) {
+		if index := indexMap[indexName]; index.primary {
+			def := "  "
+			if g.dbType == Postgres {
+				def += fmt.Sprintf("CONSTRAINT %s ", g.quoteIdentifier(index.name))
+			}
+			defList = append(defList, def+fmt.Sprintf("PRIMARY KEY (%s)", g.indexExpressionList(index)))
+		}
+	}
+
+	stmt := fmt.Sprintf("CREATE TABLE %s (\n%s\n)", g.quoteTable(table.Name), strings.Join(defList, ",\n"))
+	if g.dbType == Mysql {
+		if table.Engine != "" {
+			stmt += " ENGINE=" + table.Engine
+		}
+		if table.Collation != "" {
+			stmt += " COLLATE=" + table.Collation
+		}
+		if table.Comment != "" {
+			stmt += " COMMENT=" + quoteString(table.Comment)
+		}
+	}
+	return stmt
+}
+
+func (g *ddlGenerator) indexExpressionList(index *dbIndexDef) string {
+	var list []string
+	for _, expression := range index.expressionList {
+		switch {
+		case identifierRegexp.MatchString(expression):
+			list = append(list, g.quoteIdentifier(expression))
+		case g.dbType == Mysql:
+			// MySQL functional key part must be enclosed within parentheses.
+			list = append(list, "("+expression+")")
+		default:
+			list = append(list, expression)
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+func (g *ddlGenerator) createIndex(table string, index *dbIndexDef) string {
+	if index.primary {
+		if g.dbType == Postgres {
+			return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s PRIMARY KEY (%s)", g.quoteTable(table), g.quoteIdentifier(index.name), g.indexExpressionList(index))
+		}
+		return fmt.Sprintf("ALTER TABLE %s ADD PRIMARY KEY (%s)", g.quoteTable(table), g.indexExpressionList(index))
+	}
+
+	if g.dbType == Postgres && index.constraint {
+		return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s UNIQUE (%s)", g.quoteTable(table), g.quoteIdentifier(index.name), g.indexExpressionList(index))
+	}
+
+	unique := ""
+	if index.unique {
+		unique = "UNIQUE "
+	}
+	if g.dbType == Postgres {
+		using := ""
+		if index.indexType != "" {
+			using = " USING " + index.indexType
+		}
+		return fmt.Sprintf("CREATE %sINDEX %s ON %s%s (%s)", unique, g.quoteIdentifier(index.name), g.quoteTable(table), using, g.indexExpressionList(index))
+	}
+	using := ""
+	if index.indexType == "BTREE" || index.indexType == "HASH" {
+		using = " USING " + index.indexType
+	} else if index.indexType == "FULLTEXT" || index.indexType == "SPATIAL" {
+		unique = index.indexType + " "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s)%s", unique, g.quoteIdentifier(index.name), g.quoteTable(table), g.indexExpressionList(index), using)
+}
+
+func (g *ddlGenerator) dropIndex(table string, index *dbIndexDef) string {
+	if g.dbType == Postgres {
+		if index.primary || index.constraint {
+			return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s", g.quoteTable(table), g.quoteIdentifier(index.name))
+		}
+		// PostgreSQL index lives in the same schema as its table.
+		name := g.quoteIdentifier(index.name)
+		if i := strings.Index(table, "."); i >= 0 {
+			name = g.quoteIdentifier(table[:i]) + "." + name
+		}
+		return fmt.Sprintf("DROP INDEX %s", name)
+	}
+	if index.primary {
+		return fmt.Sprintf("ALTER TABLE %s DROP PRIMARY KEY", g.quoteTable(table))
+	}
+	return fmt.Sprintf("DROP INDEX %s ON %s", g.quoteIdentifier(index.name), g.quoteTable(table))
+}
+
+func (g *ddlGenerator) commentOnTable(table string, comment string) string {
+	if g.dbType == Mysql {
+		return fmt.Sprintf("ALTER TABLE %s COMMENT = %s", g.quoteTable(table), quoteString(comment))
+	}
+	if comment == "" {
+		return fmt.Sprintf("COMMENT ON TABLE %s IS NULL", g.quoteTable(table))
+	}
+	return fmt.Sprintf("COMMENT ON TABLE %s IS %s", g.quoteTable(table), quoteString(comment))
+}
+
+func (g *ddlGenerator) commentOnColumn(table string, column DBColumn) string {
+	if column.Comment == "" {
+		return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS NULL", g.quoteTable(table), g.quoteIdentifier(column.Name))
+	}
+	return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", g.quoteTable(table), g.quoteIdentifier(column.Name), quoteString(column.Comment))
+}
+
+// tableMap returns the table name to table map, views are excluded.
+func tableMap(tableList []DBTable) map[string]*DBTable {
+	m := make(map[string]*DBTable)
+	for i := range tableList {
+		if strings.EqualFold(tableList[i].Type, "VIEW") {
+			continue
+		}
+		m[tableList[i].Name] = &tableList[i]
+	}
+	return m
+}
+
+func sortedTableNameList(m map[string]*DBTable) []string {
+	var list []string
+	for name := range m {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func columnMap(columnList []DBColumn) map[string]DBColumn {
+	m := make(map[string]DBColumn)
+	for _, column := range columnList {
+		m[column.Name] = column
+	}
+	return m
+}
+
+func sortedColumnList(columnList []DBColumn) []DBColumn {
+	list := append([]DBColumn{}, columnList...)
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Position < list[j].Position
+	})
+	return list
+}
+
+func indexDefMap(indexList []DBIndex) map[string]*dbIndexDef {
+	sorted := append([]DBIndex{}, indexList...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	m := make(map[string]*dbIndexDef)
+	for _, index := range sorted {
+		def, ok := m[index.Name]
+		if !ok {
+			def = &dbIndexDef{
+				name:       index.Name,
+				unique:     index.Unique,
+				primary:    index.Primary,
+				constraint: index.Constraint,
+				indexType:  index.Type,
+			}
+			m[index.Name] = def
+		}
+		def.expressionList = append(def.expressionList, index.Expression)
+	}
+	return m
+}
+
+func sortedIndexNameList(m map[string]*dbIndexDef) []string {
+	var list []string
+	for name := range m {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSchema(t *testing.T) {
+	zero := "0"
+	target := &DBSchema{
+		Name: "db1",
+		TableList: []DBTable{
+			{
+				Name: "t1",
+				Type: "BASE TABLE",
+				ColumnList: []DBColumn{
+					{Name: "id", Position: 1, Type: "int"},
+					{Name: "name", Position: 2, Type: "varchar(64)", Nullable: true},
+					{Name: "legacy", Position: 3, Type: "int", Nullable: true},
+				},
+				IndexList: []DBIndex{
+					{Name: "PRIMARY", Expression: "id", Position: 1, Type: "BTREE", Unique: true, Primary: true},
+					{Name: "idx_name", Expression: "name", Position: 1, Type: "BTREE"},
+				},
+			},
+			{
+				Name: "t_old",
+				Type: "BASE TABLE",
+				ColumnList: []DBColumn{
+					{Name: "id", Position: 1, Type: "int"},
+				},
+			},
+			{
+				Name: "v1",
+				Type: "VIEW",
+			},
+		},
+	}
+	source := &DBSchema{
+		Name: "db1",
+		TableList: []DBTable{
+			{
+				Name: "t1",
+				Type: "BASE TABLE",
+				ColumnList: []DBColumn{
+					{Name: "id", Position: 1, Type: "int"},
+					{Name: "name", Position: 2, Type: "varchar(128)"},
+					{Name: "age", Position: 3, Type: "int", Default: &zero},
+				},
+				IndexList: []DBIndex{
+					{Name: "PRIMARY", Expression: "id", Position: 1, Type: "BTREE", Unique: true, Primary: true},
+					{Name: "idx_name", Expression: "name", Position: 1, Type: "BTREE", Unique: true},
+				},
+			},
+			{
+				Name:    "t_new",
+				Type:    "BASE TABLE",
+				Engine:  "InnoDB",
+				Comment: "new table",
+				ColumnList: []DBColumn{
+					{Name: "a", Position: 1, Type: "int"},
+					{Name: "b", Position: 2, Type: "int"},
+				},
+				IndexList: []DBIndex{
+					{Name: "PRIMARY", Expression: "a", Position: 1, Type: "BTREE", Unique: true, Primary: true},
+					{Name: "PRIMARY", Expression: "b", Position: 2, Type: "BTREE", Unique: true, Primary: true},
+					{Name: "idx_b", Expression: "b", Position: 1, Type: "BTREE"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		dbType Type
+		want   []string
+	}{
+		{
+			dbType: Mysql,
+			want: []string{
+				"DROP INDEX `idx_name` ON `t1`",
+				"DROP TABLE `t_old`",
+				"CREATE TABLE `t_new` (\n  `a` int NOT NULL,\n  `b` int NOT NULL,\n  PRIMARY KEY (`a`, `b`)\n) ENGINE=InnoDB COMMENT='new table'",
+				"ALTER TABLE `t1` MODIFY COLUMN `name` varchar(128) NOT NULL",
+				"ALTER TABLE `t1` ADD COLUMN `age` int NOT NULL DEFAULT 0",
+				"ALTER TABLE `t1` DROP COLUMN `legacy`",
+				"CREATE UNIQUE INDEX `idx_name` ON `t1` (`name`) USING BTREE",
+				"CREATE INDEX `idx_b` ON `t_new` (`b`) USING BTREE",
+			},
+		},
+		{
+			dbType: Postgres,
+			want: []string{
+				`DROP INDEX "idx_name"`,
+				`DROP TABLE "t_old"`,
+				"CREATE TABLE \"t_new\" (\n  \"a\" int NOT NULL,\n  \"b\" int NOT NULL,\n  CONSTRAINT \"PRIMARY\" PRIMARY KEY (\"a\", \"b\")\n)",
+				`ALTER TABLE "t1" ALTER COLUMN "name" TYPE varchar(128)`,
+				`ALTER TABLE "t1" ALTER COLUMN "name" SET NOT NULL`,
+				`ALTER TABLE "t1" ADD COLUMN "age" int NOT NULL DEFAULT 0`,
+				`ALTER TABLE "t1" DROP COLUMN "legacy"`,
+				`CREATE UNIQUE INDEX "idx_name" ON "t1" USING BTREE ("name")`,
+				`CREATE INDEX "idx_b" ON "t_new" USING BTREE ("b")`,
+				`COMMENT ON TABLE "t_new" IS 'new table'`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := DiffSchema(test.dbType, source, target)
+		if err != nil {
+			t.Fatalf("DiffSchema(%s) got error: %v", test.dbType, err)
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("DiffSchema(%s):\nwant %q\ngot  %q", test.dbType, test.want, got)
+		}
+	}
+
+	if got, err := DiffSchema(Mysql, source, source); err != nil || len(got) != 0 {
+		t.Errorf("expected no diff for the same schema, got %q, error %v", got, err)
+	}
+	if _, err := DiffSchema(Sqlite, source, target); err == nil {
+		t.Errorf("expected error for unsupported database type")
+	}
+}
+
+func TestDiffSchemaColumnAttribute(t *testing.T) {
+	seqDefault := "nextval('t1_id_seq'::regclass)"
+	quotedSeqDefault := `nextval('public."T2_id_seq"'::regclass)`
+	timestamp := "CURRENT_TIMESTAMP"
+	uuid := "uuid()"
+
+	tests := []struct {
+		name   string
+		dbType Type
+		source []DBTable
+		target []DBTable
+		want   []string
+	}{
+		{
+			name:   "MySQL AUTO_INCREMENT and ON UPDATE",
+			dbType: Mysql,
+			source: []DBTable{
+				{
+					Name: "t1",
+					ColumnList: []DBColumn{
+						{Name: "id", Position: 1, Type: "int", Extra: "auto_increment"},
+						{Name: "updated_ts", Position: 2, Type: "timestamp", Default: &timestamp, Extra: "DEFAULT_GENERATED on update CURRENT_TIMESTAMP"},
+						{Name: "uid", Position: 3, Type: "varchar(36)", Default: &uuid, Extra: "DEFAULT_GENERATED"},
+					},
+					IndexList: []DBIndex{
+						{Name: "PRIMARY", Expression: "id", Position: 1, Unique: true, Primary: true},
+					},
+				},
+			},
+			want: []string{
+				"CREATE TABLE `t1` (\n  `id` int NOT NULL AUTO_INCREMENT,\n  `updated_ts` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n  `uid` varchar(36) NOT NULL DEFAULT (uuid()),\n  PRIMARY KEY (`id`)\n)",
+			},
+		},
+		{
+			name:   "MySQL add AUTO_INCREMENT to existing column",
+			dbType: Mysql,
+			source: []DBTable{
+				{Name: "t1", ColumnList: []DBColumn{{Name: "id", Position: 1, Type: "int", Extra: "auto_increment"}}},
+			},
+			target: []DBTable{
+				{Name: "t1", ColumnList: []DBColumn{{Name: "id", Position: 1, Type: "int"}}},
+			},
+			want: []string{
+				"ALTER TABLE `t1` MODIFY COLUMN `id` int NOT NULL AUTO_INCREMENT",
+			},
+		},
+		{
+			name:   "PostgreSQL serial creates the sequence",
+			dbType: Postgres,
+			source: []DBTable{
+				{Name: "t1", ColumnList: []DBColumn{{Name: "id", Position: 1, Type: "integer", Default: &seqDefault}}},
+				{Name: "T2", ColumnList: []DBColumn{{Name: "id", Position: 1, Type: "integer"}, {Name: "seq", Position: 2, Type: "integer", Default: &quotedSeqDefault}}},
+			},
+			target: []DBTable{
+				{Name: "T2", ColumnList: []DBColumn{{Name: "id", Position: 1, Type: "integer"}}},
+			},
+			want: []string{
+				`CREATE SEQUENCE IF NOT EXISTS public."T2_id_seq"`,
+				`CREATE SEQUENCE IF NOT EXISTS t1_id_seq`,
+				"CREATE TABLE \"t1\" (\n  \"id\" integer NOT NULL DEFAULT nextval('t1_id_seq'::regclass)\n)",
+				`ALTER TABLE "T2" ADD COLUMN "seq" integer NOT NULL DEFAULT nextval('public."T2_id_seq"'::regclass)`,
+				`ALTER SEQUENCE public."T2_id_seq" OWNED BY "T2"."seq"`,
+				`ALTER SEQUENCE t1_id_seq OWNED BY "t1"."id"`,
+			},
+		},
+		{
+			name:   "PostgreSQL constraint-backed indexes",
+			dbType: Postgres,
+			source: []DBTable{
+				{
+					Name:       "t1",
+					ColumnList: []DBColumn{{Name: "a", Position: 1, Type: "int"}, {Name: "b", Position: 2, Type: "int"}},
+					IndexList: []DBIndex{
+						{Name: "t1_pkey", Expression: "a", Position: 1, Type: "btree", Unique: true, Primary: true, Constraint: true},
+						{Name: "t1_a_b_key", Expression: "a", Position: 1, Type: "btree", Unique: true, Constraint: true},
+						{Name: "t1_a_b_key", Expression: "b", Position: 2, Type: "btree", Unique: true, Constraint: true},
+					},
+				},
+			},
+			target: []DBTable{
+				{
+					Name:       "t1",
+					ColumnList: []DBColumn{{Name: "a", Position: 1, Type: "int"}, {Name: "b", Position: 2, Type: "int"}},
+					IndexList: []DBIndex{
+						{Name: "t1_pkey", Expression: "b", Position: 1, Type: "btree", Unique: true, Primary: true, Constraint: true},
+						{Name: "t1_a_b_key", Expression: "a", Position: 1, Type: "btree", Unique: true, Constraint: true},
+						{Name: "t1_b_key", Expression: "b", Position: 1, Type: "btree", Unique: true, Constraint: true},
+					},
+				},
+			},
+			want: []string{
+				`ALTER TABLE "t1" DROP CONSTRAINT "t1_a_b_key"`,
+				`ALTER TABLE "t1" DROP CONSTRAINT "t1_b_key"`,
+				`ALTER TABLE "t1" DROP CONSTRAINT "t1_pkey"`,
+				`ALTER TABLE "t1" ADD CONSTRAINT "t1_a_b_key" UNIQUE ("a", "b")`,
+				`ALTER TABLE "t1" ADD CONSTRAINT "t1_pkey" PRIMARY KEY ("a")`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := DiffSchema(test.dbType, &DBSchema{TableList: test.source}, &DBSchema{TableList: test.target})
+		if err != nil {
+			t.Fatalf("%s: DiffSchema got error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf("%s:\nwant %q\ngot  %q", test.name, test.want, got)
+		}
+	}
+}
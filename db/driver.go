@@ -14,13 +14,19 @@ import (
 type Type string
 
 const (
-	Mysql Type = "MYSQL"
+	Mysql    Type = "MYSQL"
+	Postgres Type = "POSTGRES"
+	Sqlite   Type = "SQLITE"
 )
 
 func (e Type) String() string {
 	switch e {
 	case Mysql:
 		return "MYSQL"
+	case Postgres:
+		return "POSTGRES"
+	case Sqlite:
+		return "SQLITE"
 	}
 	return "UNKNOWN"
 }
@@ -37,6 +43,11 @@ type DBIndex struct {
 	Position   int
 	Type       string
 	Unique     bool
+	// Primary is true if the index backs the primary key.
+	Primary bool
+	// Constraint is true if the index backs a PRIMARY KEY or UNIQUE constraint, which must be dropped by dropping the
+	// constraint. PostgreSQL only, MySQL doesn't distinguish the UNIQUE constraint from the unique index.
+	Constraint bool
 	Visible    bool
 	Comment    string
 }
@@ -50,6 +61,9 @@ type DBColumn struct {
 	CharacterSet string
 	Collation    string
 	Comment      string
+	// Extra is the MySQL column attribute not covered by the other fields, e.g. "auto_increment" and
+	// "DEFAULT_GENERATED on update CURRENT_TIMESTAMP".
+	Extra string
 }
 
 type DBTable struct {
@@ -84,10 +98,16 @@ var (
 
 type DriverConfig struct {
 	Logger *zap.Logger
+	// SqliteDir is the root directory of the SQLite instances, whose hosts are the directories relative to it.
+	SqliteDir string
 }
 
 type DriverFunc func(DriverConfig) Driver
 
+// migrationSchemaVersion is the version of the bytebase migration schema, recorded as the "bb.schema.version" setting.
+// Version 2 adds the schema snapshot to migration_history.
+const migrationSchemaVersion = 2
+
 type MigrationEngine string
 
 const (
@@ -211,6 +231,20 @@ type MigrationHistory struct {
 	ExecutionDuration int
 	IssueId           string
 	Payload           string
+	// The schema snapshot after the migration, see SchemaSnapshot. Empty if the migration was applied before
+	// recording snapshot was supported.
+	Schema string
+}
+
+// MigrationDryRunResult is the result of validating a migration without applying it.
+type MigrationDryRunResult struct {
+	// The sequence the migration would be recorded with.
+	Sequence int
+	// The statements would be executed, empty for the VCS baselining.
+	StatementList []string
+	// Validated is true if the driver has validated the statement on a scratch copy of the schema, e.g. an in-memory
+	// SQLite database. Otherwise, the caller should validate it on a scratch database.
+	Validated bool
 }
 
 type MigrationHistoryFind struct {
@@ -225,6 +259,8 @@ type ConnectionConfig struct {
 	Username string
 	Password string
 	Database string
+	// SSLMode is the sslmode of the PostgreSQL connection, "disable" if empty.
+	SSLMode string
 }
 
 // Context not used for establishing the db connection, but is useful for logging.
@@ -248,8 +284,13 @@ type Driver interface {
 	SetupMigrationIfNeeded(ctx context.Context) error
 	// Execute migration will apply the statement and record the migration history on success.
 	ExecuteMigration(ctx context.Context, m *MigrationInfo, statement string) error
+	// Dry run migration will run the same checks as ExecuteMigration against the migration history. It never executes
+	// the statement on the database, see MigrationDryRunResult.Validated.
+	DryRunMigration(ctx context.Context, m *MigrationInfo, statement string) (*MigrationDryRunResult, error)
 	// Find the migration history list and return most recent item first.
 	FindMigrationHistoryList(ctx context.Context, find *MigrationHistoryFind) ([]*MigrationHistory, error)
+	// Update the schema snapshot of the migration history identified by the namespace, engine and version of m.
+	UpdateMigrationHistorySchema(ctx context.Context, m *MigrationInfo, schema string) error
 }
 
 // Register makes a database driver available by the provided type.
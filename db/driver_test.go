@@ -23,6 +23,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "",
+				Engine:      "VCS",
 				Type:        "SQL",
 				Description: "Create db1 migration",
 				Creator:     "",
@@ -37,6 +38,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "",
+				Engine:      "VCS",
 				Type:        "SQL",
 				Description: "Create db1 migration",
 				Creator:     "",
@@ -51,6 +53,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "dev",
+				Engine:      "VCS",
 				Type:        "SQL",
 				Description: "Create db1 migration",
 				Creator:     "",
@@ -65,6 +68,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "dev",
+				Engine:      "VCS",
 				Type:        "SQL",
 				Description: "Create db1 migration",
 				Creator:     "",
@@ -79,6 +83,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "",
+				Engine:      "VCS",
 				Type:        "SQL",
 				Description: "Create t1",
 				Creator:     "",
@@ -93,6 +98,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "",
+				Engine:      "VCS",
 				Type:        "BASELINE",
 				Description: "Create db1 baseline",
 				Creator:     "",
@@ -107,6 +113,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db1",
 				Database:    "db1",
 				Environment: "",
+				Engine:      "VCS",
 				Type:        "BASELINE",
 				Description: "Create t1",
 				Creator:     "",
@@ -121,6 +128,7 @@ func TestParseMigrationInfo(t *testing.T) {
 				Namespace:   "db_shop1",
 				Database:    "db_shop1",
 				Environment: "",
+				Engine:      "VCS",
 				Type:        "BASELINE",
 				Description: "Create t1",
 				Creator:     "",
@@ -147,9 +155,9 @@ func TestParseMigrationInfo(t *testing.T) {
 		mi, err := ParseMigrationInfo(tc.fullPath, tc.baseDir)
 		if err != nil {
 			if tc.wantErr == "" {
-				t.Errorf("fullPath=%s, baseDir=%s: expected no error, got %w", tc.fullPath, tc.baseDir, err)
+				t.Errorf("fullPath=%s, baseDir=%s: expected no error, got %v", tc.fullPath, tc.baseDir, err)
 			} else if !strings.Contains(err.Error(), tc.wantErr) {
-				t.Errorf("fullPath=%s, baseDir=%s: expected error %s, got %w", tc.fullPath, tc.baseDir, tc.wantErr, err)
+				t.Errorf("fullPath=%s, baseDir=%s: expected error %s, got %v", tc.fullPath, tc.baseDir, tc.wantErr, err)
 			}
 		} else {
 			if !reflect.DeepEqual(tc.want, *mi) {
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	_ "embed"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -218,6 +219,8 @@ func (driver *MySQLDriver) SyncSchema(ctx context.Context) ([]*DBUser, []*DBSche
 		} else if expression.Valid {
 			index.Expression = expression.String
 		}
+		// MySQL always names the primary key index PRIMARY.
+		index.Primary = index.Name == "PRIMARY"
 
 		key := fmt.Sprintf("%s/%s", dbName, tableName)
 		indexList, ok := indexMap[key]
@@ -242,7 +245,8 @@ func (driver *MySQLDriver) SyncSchema(ctx context.Context) ([]*DBUser, []*DBSche
 				COLUMN_TYPE,
 				IFNULL(CHARACTER_SET_NAME, ''),
 				IFNULL(COLLATION_NAME, ''),
-				COLUMN_COMMENT
+				COLUMN_COMMENT,
+				EXTRA
 			FROM information_schema.COLUMNS
 			WHERE ` + columnWhere
 	columnRows, err := driver.db.QueryContext(ctx, query)
@@ -270,6 +274,7 @@ func (driver *MySQLDriver) SyncSchema(ctx context.Context) ([]*DBUser, []*DBSche
 			&column.CharacterSet,
 			&column.Collation,
 			&column.Comment,
+			&column.Extra,
 		); err != nil {
 			return nil, nil, err
 		}
@@ -277,6 +282,7 @@ func (driver *MySQLDriver) SyncSchema(ctx context.Context) ([]*DBUser, []*DBSche
 		if defaultStr.Valid {
 			column.Default = &defaultStr.String
 		}
+		column.Nullable = nullable == "YES"
 
 		key := fmt.Sprintf("%s/%s", dbName, tableName)
 		tableList, ok := columnMap[key]
@@ -393,11 +399,54 @@ func (driver *MySQLDriver) Execute(ctx context.Context, statement string) error
 	}
 	defer tx.Rollback()
 
+	stop, err := driver.killQueryOnCancel(ctx, tx)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
 	_, err = tx.ExecContext(ctx, statement)
 	return err
 }
 
+// killQueryOnCancel kills the query running on the connection of tx once ctx is canceled, until stop is called.
+// On cancellation, the MySQL client only closes its side of the connection, and the server keeps running the query.
+func (driver *MySQLDriver) killQueryOnCancel(ctx context.Context, tx *sql.Tx) (stop func(), err error) {
+	var connectionID int64
+	if err := tx.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&connectionID); err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Kill from another connection since the connection of tx is busy running the query.
+			if _, err := driver.db.ExecContext(context.Background(), fmt.Sprintf("KILL QUERY %d", connectionID)); err != nil {
+				driver.l.Warn("Failed to kill query after cancellation",
+					zap.String("environment", driver.connectionCtx.EnvironmentName),
+					zap.String("database", driver.connectionCtx.InstanceName),
+					zap.Int64("connection_id", connectionID),
+					zap.Error(err),
+				)
+			}
+		case <-done:
+		}
+	}()
+	return func() { close(done) }, nil
+}
+
 func (driver *MySQLDriver) NeedsSetupMigration(ctx context.Context) (bool, error) {
+	version, err := driver.findMigrationSchemaVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return version < migrationSchemaVersion, nil
+}
+
+// findMigrationSchemaVersion returns the version of the migration schema, or 0 if the migration schema doesn't exist.
+func (driver *MySQLDriver) findMigrationSchemaVersion(ctx context.Context) (int, error) {
 	const query = `
 		SELECT 
 		    1
@@ -406,15 +455,23 @@ func (driver *MySQLDriver) NeedsSetupMigration(ctx context.Context) (bool, error
 		`
 	rows, err := driver.db.QueryContext(ctx, query)
 	if err != nil {
-		return false, formatErrorWithQuery(err, query)
+		return 0, formatErrorWithQuery(err, query)
 	}
-	defer rows.Close()
+	exist := rows.Next()
+	rows.Close()
 
-	if rows.Next() {
-		return false, nil
+	if !exist {
+		return 0, nil
 	}
 
-	return true, nil
+	const versionQuery = `
+		SELECT value FROM bytebase.setting WHERE name = 'bb.schema.version'
+		`
+	var value string
+	if err := driver.db.QueryRowContext(ctx, versionQuery).Scan(&value); err != nil {
+		return 0, formatErrorWithQuery(err, versionQuery)
+	}
+	return strconv.Atoi(value)
 }
 
 func (driver *MySQLDriver) SetupMigrationIfNeeded(ctx context.Context) error {
@@ -424,6 +481,14 @@ func (driver *MySQLDriver) SetupMigrationIfNeeded(ctx context.Context) error {
 	}
 
 	if setup {
+		version, err := driver.findMigrationSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version > 0 {
+			return driver.upgradeMigrationSchema(ctx, version)
+		}
+
 		driver.l.Info("Bytebase migration schema not found, creating schema...",
 			zap.String("environment", driver.connectionCtx.EnvironmentName),
 			zap.String("database", driver.connectionCtx.InstanceName),
@@ -445,57 +510,72 @@ func (driver *MySQLDriver) SetupMigrationIfNeeded(ctx context.Context) error {
 	return nil
 }
 
-func (driver *MySQLDriver) ExecuteMigration(ctx context.Context, m *MigrationInfo, statement string) error {
-	tx, err := driver.db.BeginTx(ctx, nil)
+// upgradeMigrationSchema upgrades the migration schema created by the older version.
+func (driver *MySQLDriver) upgradeMigrationSchema(ctx context.Context, version int) error {
+	driver.l.Info("Bytebase migration schema is outdated, upgrading schema...",
+		zap.String("environment", driver.connectionCtx.EnvironmentName),
+		zap.String("database", driver.connectionCtx.InstanceName),
+		zap.Int("version", version),
+	)
+
+	// MySQL DDL causes implicit commit, so the column may have been added by an interrupted upgrade. We only add it if
+	// it's missing to make the upgrade idempotent. ADD COLUMN fills the existing rows with the implicit default ''.
+	const columnQuery = `
+		SELECT
+		    1
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = 'bytebase' AND TABLE_NAME = 'migration_history' AND COLUMN_NAME = 'schema'
+		`
+	rows, err := driver.db.QueryContext(ctx, columnQuery)
 	if err != nil {
-		return err
+		return formatErrorWithQuery(err, columnQuery)
 	}
-	defer tx.Rollback()
-
-	startedTs := time.Now().Unix()
+	exist := rows.Next()
+	rows.Close()
 
-	// Phase 1 - Precheck before executing migration
-	// Check if the same migration version has alraedy been applied
-	duplicate, err := checkDuplicateVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
-	if err != nil {
-		return err
+	if !exist {
+		const alterQuery = "ALTER TABLE bytebase.migration_history ADD COLUMN `schema` MEDIUMTEXT NOT NULL"
+		if _, err := driver.db.ExecContext(ctx, alterQuery); err != nil {
+			return formatErrorWithQuery(err, alterQuery)
+		}
 	}
-	if duplicate {
-		return fmt.Errorf("database '%s' has already applied version %s", m.Database, m.Version)
+	const query = `
+		UPDATE bytebase.setting SET value = '2', updated_ts = UNIX_TIMESTAMP() WHERE name = 'bb.schema.version'
+		`
+	if _, err := driver.db.ExecContext(ctx, query); err != nil {
+		return formatErrorWithQuery(err, query)
 	}
 
-	// Check if there is any higher version already been applied
-	version, err := checkOutofOrderVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	driver.l.Info("Successfully upgraded migration schema.",
+		zap.String("environment", driver.connectionCtx.EnvironmentName),
+		zap.String("database", driver.connectionCtx.InstanceName),
+	)
+	return nil
+}
+
+func (driver *MySQLDriver) ExecuteMigration(ctx context.Context, m *MigrationInfo, statement string) error {
+	tx, err := driver.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	if version != nil {
-		return fmt.Errorf("database '%s' has already applied version %s which is higher than %s", m.Database, *version, m.Version)
-	}
-
-	// If the migration engine is VCS and type is not baseline, then we can only proceed if there is existing baseline
-	// This check is also wrapped in transaction to avoid edge case where two baselinings are running concurrently.
-	if m.Engine == VCS && m.Type != Baseline {
-		hasBaseline, err := findBaseline(ctx, tx, m.Namespace)
-		if err != nil {
-			return err
-		}
+	defer tx.Rollback()
 
-		if !hasBaseline {
-			return fmt.Errorf("%s has not created migration baseline yet", m.Database)
-		}
-	}
+	startedTs := time.Now().Unix()
 
-	// VCS based SQL migration requires existing baselining
-	requireBaseline := m.Engine == VCS && m.Type == Sql
-	sequence, err := findNextSequence(ctx, tx, m.Namespace, requireBaseline)
+	// Phase 1 - Precheck before executing migration
+	sequence, err := precheckMigration(ctx, tx, m)
 	if err != nil {
 		return err
 	}
 
 	// Phase 2 - Executing migration unless it's VCS baselining
 	if m.Engine != VCS || m.Type != Baseline {
-		_, err = tx.ExecContext(ctx, statement)
+		stop, err := driver.killQueryOnCancel(ctx, tx)
+		if err != nil {
+			return err
+		}
+		err = executeStatement(ctx, Mysql, tx, statement)
+		stop()
 		if err != nil {
 			return formatError(err)
 		}
@@ -517,9 +597,10 @@ func (driver *MySQLDriver) ExecuteMigration(ctx context.Context, m *MigrationInf
 			statement,
 			execution_duration,
 			issue_id,
-			payload
+			payload,
+			` + "`schema`" + `
 		)
-		VALUES (?, unix_timestamp(), ?, unix_timestamp(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		VALUES (?, unix_timestamp(), ?, unix_timestamp(), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '')
 	`
 	_, err = tx.ExecContext(ctx, query,
 		m.Creator,
@@ -545,6 +626,68 @@ func (driver *MySQLDriver) ExecuteMigration(ctx context.Context, m *MigrationInf
 	return nil
 }
 
+func (driver *MySQLDriver) DryRunMigration(ctx context.Context, m *MigrationInfo, statement string) (*MigrationDryRunResult, error) {
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sequence, err := precheckMigration(ctx, tx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationDryRunResult{
+		Sequence:      sequence,
+		StatementList: []string{},
+	}
+	if m.Engine == VCS && m.Type == Baseline {
+		return result, nil
+	}
+
+	result.StatementList = SplitMultiSQL(Mysql, statement)
+	return result, nil
+}
+
+// precheckMigration checks whether the migration can be applied and returns the sequence to record.
+func precheckMigration(ctx context.Context, tx *sql.Tx, m *MigrationInfo) (int, error) {
+	// Check if the same migration version has alraedy been applied
+	duplicate, err := checkDuplicateVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	if err != nil {
+		return -1, err
+	}
+	if duplicate {
+		return -1, fmt.Errorf("database '%s' has already applied version %s", m.Database, m.Version)
+	}
+
+	// Check if there is any higher version already been applied
+	version, err := checkOutofOrderVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	if err != nil {
+		return -1, err
+	}
+	if version != nil {
+		return -1, fmt.Errorf("database '%s' has already applied version %s which is higher than %s", m.Database, *version, m.Version)
+	}
+
+	// If the migration engine is VCS and type is not baseline, then we can only proceed if there is existing baseline
+	// This check is also wrapped in transaction to avoid edge case where two baselinings are running concurrently.
+	if m.Engine == VCS && m.Type != Baseline {
+		hasBaseline, err := findBaseline(ctx, tx, m.Namespace)
+		if err != nil {
+			return -1, err
+		}
+
+		if !hasBaseline {
+			return -1, fmt.Errorf("%s has not created migration baseline yet", m.Database)
+		}
+	}
+
+	// VCS based SQL migration requires existing baselining
+	requireBaseline := m.Engine == VCS && m.Type == Sql
+	return findNextSequence(ctx, tx, m.Namespace, requireBaseline)
+}
+
 func (driver *MySQLDriver) FindMigrationHistoryList(ctx context.Context, find *MigrationHistoryFind) ([]*MigrationHistory, error) {
 	tx, err := driver.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -573,7 +716,8 @@ func (driver *MySQLDriver) FindMigrationHistoryList(ctx context.Context, find *M
 		    statement,
 		    execution_duration,
 			issue_id,
-			payload
+			payload,
+			` + "`schema`" + `
 		FROM bytebase.migration_history
 		WHERE ` + strings.Join(where, " AND ") + `
 		ORDER BY created_ts DESC`
@@ -607,6 +751,7 @@ func (driver *MySQLDriver) FindMigrationHistoryList(ctx context.Context, find *M
 			&history.ExecutionDuration,
 			&history.IssueId,
 			&history.Payload,
+			&history.Schema,
 		); err != nil {
 			return nil, err
 		}
@@ -620,6 +765,18 @@ func (driver *MySQLDriver) FindMigrationHistoryList(ctx context.Context, find *M
 	return list, nil
 }
 
+func (driver *MySQLDriver) UpdateMigrationHistorySchema(ctx context.Context, m *MigrationInfo, schema string) error {
+	const query = `
+		UPDATE bytebase.migration_history
+		SET ` + "`schema`" + ` = ?, updated_ts = unix_timestamp()
+		WHERE namespace = ? AND ` + "`engine`" + ` = ? AND version = ?
+	`
+	if _, err := driver.db.ExecContext(ctx, query, schema, m.Namespace, m.Engine, m.Version); err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+	return nil
+}
+
 func findBaseline(ctx context.Context, tx *sql.Tx, namespace string) (bool, error) {
 	query := `
 		SELECT 1 FROM bytebase.migration_history WHERE namespace = ? AND ` + "`type` = 'BASELINE'" + `
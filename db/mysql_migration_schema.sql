@@ -15,7 +15,7 @@ CREATE TABLE bytebase.setting (
 
 CREATE UNIQUE INDEX bytebase_idx_unique_setting_name ON bytebase.setting (name(256));
 
--- Insert schema version 1
+-- Insert schema version 2
 INSERT INTO
     bytebase.setting (
         created_by,
@@ -33,7 +33,7 @@ VALUES
         'bytebase',
         UNIX_TIMESTAMP(),
         'bb.schema.version',
-        '1',
+        '2',
         'Schema version'
     );
 
@@ -60,7 +60,9 @@ CREATE TABLE bytebase.migration_history (
     statement TEXT NOT NULL,
     execution_duration INTEGER NOT NULL,
     issue_id TEXT NOT NULL,
-    payload TEXT NOT NULL
+    payload TEXT NOT NULL,
+    -- Recorded the schema snapshot after the migration, used to detect the schema drift
+    `schema` MEDIUMTEXT NOT NULL
 );
 
 CREATE UNIQUE INDEX bytebase_idx_unique_migration_history_namespace_sequence ON bytebase.migration_history (namespace(256), sequence);
@@ -0,0 +1,954 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+//go:embed postgres_migration_schema.sql
+var pgMigrationSchema string
+
+const (
+	// The database used to store the bytebase migration schema. Unlike MySQL, PostgreSQL doesn't allow
+	// cross database query, so we need to connect to this database separately.
+	bytebaseDatabase = "bytebase"
+	// The database to connect if the connection config doesn't specify one.
+	pgDefaultDatabase = "postgres"
+)
+
+var (
+	_ Driver = (*PostgresDriver)(nil)
+
+	pgExcludedSchemaList = []string{
+		"'pg_catalog'",
+		"'information_schema'",
+	}
+)
+
+func init() {
+	register(Postgres, newPostgresDriver)
+}
+
+type PostgresDriver struct {
+	l             *zap.Logger
+	connectionCtx ConnectionContext
+	config        ConnectionConfig
+
+	db *sql.DB
+}
+
+func newPostgresDriver(config DriverConfig) Driver {
+	return &PostgresDriver{
+		l: config.Logger,
+	}
+}
+
+func (driver *PostgresDriver) open(config ConnectionConfig, ctx ConnectionContext) (Driver, error) {
+	if config.Database == "" {
+		config.Database = pgDefaultDatabase
+	}
+
+	driver.l.Debug("Opening PostgreSQL driver",
+		zap.String("host", config.Host),
+		zap.String("port", config.Port),
+		zap.String("database", config.Database),
+		zap.String("environment", ctx.EnvironmentName),
+		zap.String("instance", ctx.InstanceName),
+	)
+	db, err := sql.Open("postgres", pgDSN(config, config.Database))
+	if err != nil {
+		return nil, err
+	}
+	driver.db = db
+	driver.config = config
+	driver.connectionCtx = ctx
+
+	return driver, nil
+}
+
+// pgDSN returns the key/value connection string to connect the database on the instance described by config.
+func pgDSN(config ConnectionConfig, database string) string {
+	port := config.Port
+	if port == "" {
+		port = "5432"
+	}
+	sslMode := config.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	params := []string{
+		fmt.Sprintf("host=%s", pgQuoteParam(config.Host)),
+		fmt.Sprintf("port=%s", pgQuoteParam(port)),
+		fmt.Sprintf("user=%s", pgQuoteParam(config.Username)),
+		fmt.Sprintf("dbname=%s", pgQuoteParam(database)),
+		fmt.Sprintf("sslmode=%s", pgQuoteParam(sslMode)),
+	}
+	if config.Password != "" {
+		params = append(params, fmt.Sprintf("password=%s", pgQuoteParam(config.Password)))
+	}
+	return strings.Join(params, " ")
+}
+
+// pgQuoteParam quotes the connection parameter value so that it can contain spaces and quotes.
+func pgQuoteParam(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// switchDatabase opens a new connection to the database on the same instance.
+// Remember to close the returned db to avoid connection leak.
+func (driver *PostgresDriver) switchDatabase(ctx context.Context, database string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", pgDSN(driver.config, database))
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (driver *PostgresDriver) Close(ctx context.Context) error {
+	return driver.db.Close()
+}
+
+func (driver *PostgresDriver) Ping(ctx context.Context) error {
+	return driver.db.PingContext(ctx)
+}
+
+func (driver *PostgresDriver) SyncSchema(ctx context.Context) ([]*DBUser, []*DBSchema, error) {
+	// Query user info
+	query := `
+		SELECT
+			rolname,
+			rolsuper,
+			rolinherit,
+			rolcreaterole,
+			rolcreatedb,
+			rolcanlogin,
+			rolreplication
+		FROM pg_catalog.pg_roles
+		WHERE rolname NOT LIKE 'pg_%'
+	`
+	userRows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, nil, formatErrorWithQuery(err, query)
+	}
+	defer userRows.Close()
+
+	userList := make([]*DBUser, 0)
+	for userRows.Next() {
+		var name string
+		var super, inherit, createRole, createDB, canLogin, replication bool
+		if err := userRows.Scan(
+			&name,
+			&super,
+			&inherit,
+			&createRole,
+			&createDB,
+			&canLogin,
+			&replication,
+		); err != nil {
+			return nil, nil, err
+		}
+
+		attributeList := []string{}
+		if super {
+			attributeList = append(attributeList, "Superuser")
+		}
+		if !inherit {
+			attributeList = append(attributeList, "No inheritance")
+		}
+		if createRole {
+			attributeList = append(attributeList, "Create role")
+		}
+		if createDB {
+			attributeList = append(attributeList, "Create DB")
+		}
+		if !canLogin {
+			attributeList = append(attributeList, "Cannot login")
+		}
+		if replication {
+			attributeList = append(attributeList, "Replication")
+		}
+
+		userList = append(userList, &DBUser{
+			Name:  name,
+			Grant: strings.Join(attributeList, ", "),
+		})
+	}
+	if err := userRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// Query db info
+	query = `
+		SELECT
+			datname,
+			pg_encoding_to_char(encoding),
+			datcollate
+		FROM pg_catalog.pg_database
+		WHERE datistemplate = false AND datname <> $1
+	`
+	rows, err := driver.db.QueryContext(ctx, query, bytebaseDatabase)
+	if err != nil {
+		return nil, nil, formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	schemaList := make([]*DBSchema, 0)
+	for rows.Next() {
+		var schema DBSchema
+		if err := rows.Scan(
+			&schema.Name,
+			&schema.CharacterSet,
+			&schema.Collation,
+		); err != nil {
+			return nil, nil, err
+		}
+		schemaList = append(schemaList, &schema)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// PostgreSQL catalogs are per database, so we need to connect each database to fetch its tables.
+	for _, schema := range schemaList {
+		tableList, err := driver.syncTableList(ctx, schema.Name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sync database %q: %w", schema.Name, err)
+		}
+		schema.TableList = tableList
+	}
+
+	return userList, schemaList, nil
+}
+
+// syncTableList fetches the tables along with their columns and indexes for database.
+// Tables outside the "public" schema are named as {{schema}}.{{table}}.
+func (driver *PostgresDriver) syncTableList(ctx context.Context, database string) ([]DBTable, error) {
+	db, err := driver.switchDatabase(ctx, database)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// Query index info
+	indexWhere := pgSchemaWhere("n.nspname")
+	query := `
+		SELECT
+			n.nspname,
+			t.relname,
+			i.relname,
+			pg_catalog.pg_get_indexdef(x.indexrelid, k.pos, true),
+			k.pos,
+			am.amname,
+			x.indisunique,
+			x.indisprimary,
+			EXISTS (
+				SELECT 1 FROM pg_catalog.pg_constraint c
+				WHERE c.conrelid = x.indrelid AND c.conindid = x.indexrelid AND c.contype IN ('p', 'u')
+			),
+			COALESCE(pg_catalog.obj_description(i.oid, 'pg_class'), '')
+		FROM pg_catalog.pg_index x
+		JOIN pg_catalog.pg_class t ON t.oid = x.indrelid
+		JOIN pg_catalog.pg_class i ON i.oid = x.indexrelid
+		JOIN pg_catalog.pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_catalog.pg_am am ON am.oid = i.relam
+		CROSS JOIN LATERAL generate_series(1, x.indnatts) AS k(pos)
+		WHERE ` + indexWhere
+	indexRows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer indexRows.Close()
+
+	// schemaName/tableName -> indexList map
+	indexMap := make(map[string][]DBIndex)
+	for indexRows.Next() {
+		var schemaName string
+		var tableName string
+		var index DBIndex
+		if err := indexRows.Scan(
+			&schemaName,
+			&tableName,
+			&index.Name,
+			&index.Expression,
+			&index.Position,
+			&index.Type,
+			&index.Unique,
+			&index.Primary,
+			&index.Constraint,
+			&index.Comment,
+		); err != nil {
+			return nil, err
+		}
+		// PostgreSQL doesn't support invisible index.
+		index.Visible = true
+
+		key := fmt.Sprintf("%s/%s", schemaName, tableName)
+		indexMap[key] = append(indexMap[key], index)
+	}
+	if err := indexRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Query column info
+	columnWhere := pgSchemaWhere("table_schema")
+	query = `
+		SELECT
+			table_schema,
+			table_name,
+			column_name,
+			ordinal_position,
+			column_default,
+			is_nullable,
+			pg_catalog.format_type(a.atttypid, a.atttypmod),
+			COALESCE(character_set_name, ''),
+			COALESCE(collation_name, ''),
+			COALESCE(pg_catalog.col_description(format('%I.%I', table_schema, table_name)::regclass, ordinal_position::int), '')
+		FROM information_schema.columns
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = format('%I.%I', table_schema, table_name)::regclass AND a.attname = column_name
+		WHERE ` + columnWhere
+	columnRows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer columnRows.Close()
+
+	// schemaName/tableName -> columnList map
+	columnMap := make(map[string][]DBColumn)
+	for columnRows.Next() {
+		var schemaName string
+		var tableName string
+		var nullable string
+		var defaultStr sql.NullString
+		var column DBColumn
+		if err := columnRows.Scan(
+			&schemaName,
+			&tableName,
+			&column.Name,
+			&column.Position,
+			&defaultStr,
+			&nullable,
+			&column.Type,
+			&column.CharacterSet,
+			&column.Collation,
+			&column.Comment,
+		); err != nil {
+			return nil, err
+		}
+
+		if defaultStr.Valid {
+			column.Default = &defaultStr.String
+		}
+		column.Nullable = nullable == "YES"
+
+		key := fmt.Sprintf("%s/%s", schemaName, tableName)
+		columnMap[key] = append(columnMap[key], column)
+	}
+	if err := columnRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Query table info
+	tableWhere := pgSchemaWhere("tbl.table_schema")
+	query = `
+		SELECT
+			tbl.table_schema,
+			tbl.table_name,
+			tbl.table_type,
+			GREATEST(c.reltuples, 0)::BIGINT,
+			pg_catalog.pg_table_size(c.oid),
+			pg_catalog.pg_indexes_size(c.oid),
+			COALESCE(pg_catalog.obj_description(c.oid, 'pg_class'), '')
+		FROM information_schema.tables tbl
+		JOIN pg_catalog.pg_namespace n ON n.nspname = tbl.table_schema
+		JOIN pg_catalog.pg_class c ON c.relnamespace = n.oid AND c.relname = tbl.table_name
+		WHERE ` + tableWhere
+	tableRows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer tableRows.Close()
+
+	tableList := make([]DBTable, 0)
+	for tableRows.Next() {
+		var schemaName string
+		var table DBTable
+		if err := tableRows.Scan(
+			&schemaName,
+			&table.Name,
+			&table.Type,
+			&table.RowCount,
+			&table.DataSize,
+			&table.IndexSize,
+			&table.Comment,
+		); err != nil {
+			return nil, err
+		}
+
+		key := fmt.Sprintf("%s/%s", schemaName, table.Name)
+		table.ColumnList = columnMap[key]
+		table.IndexList = indexMap[key]
+		if schemaName != "public" {
+			table.Name = fmt.Sprintf("%s.%s", schemaName, table.Name)
+		}
+
+		tableList = append(tableList, table)
+	}
+	if err := tableRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tableList, nil
+}
+
+// pgSchemaWhere returns the condition to exclude the system schemas on column.
+func pgSchemaWhere(column string) string {
+	return fmt.Sprintf("%s NOT IN (%s) AND %s NOT LIKE 'pg_toast%%' AND %s NOT LIKE 'pg_temp%%'", column, strings.Join(pgExcludedSchemaList, ", "), column, column)
+}
+
+func (driver *PostgresDriver) Execute(ctx context.Context, statement string) error {
+	// CREATE DATABASE can't be executed inside a transaction block.
+	if isPgNonTransactionalStatement(statement) {
+		_, err := driver.db.ExecContext(ctx, statement)
+		return err
+	}
+
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isPgNonTransactionalStatement(statement string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(statement))
+	return strings.HasPrefix(upper, "CREATE DATABASE") || strings.HasPrefix(upper, "DROP DATABASE")
+}
+
+func (driver *PostgresDriver) NeedsSetupMigration(ctx context.Context) (bool, error) {
+	version, err := driver.findMigrationSchemaVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return version < migrationSchemaVersion, nil
+}
+
+// findMigrationSchemaVersion returns the version of the migration schema, or 0 if the migration schema doesn't exist.
+func (driver *PostgresDriver) findMigrationSchemaVersion(ctx context.Context) (int, error) {
+	const query = `
+		SELECT
+		    1
+		FROM pg_catalog.pg_database
+		WHERE datname = $1
+		`
+	rows, err := driver.db.QueryContext(ctx, query, bytebaseDatabase)
+	if err != nil {
+		return 0, formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, nil
+	}
+
+	db, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	const tableQuery = `
+		SELECT
+		    1
+		FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name = 'migration_history'
+		`
+	tableRows, err := db.QueryContext(ctx, tableQuery)
+	if err != nil {
+		return 0, formatErrorWithQuery(err, tableQuery)
+	}
+	exist := tableRows.Next()
+	tableRows.Close()
+
+	if !exist {
+		return 0, nil
+	}
+
+	const versionQuery = `
+		SELECT value FROM setting WHERE name = 'bb.schema.version'
+		`
+	var value string
+	if err := db.QueryRowContext(ctx, versionQuery).Scan(&value); err != nil {
+		return 0, formatErrorWithQuery(err, versionQuery)
+	}
+	return strconv.Atoi(value)
+}
+
+func (driver *PostgresDriver) SetupMigrationIfNeeded(ctx context.Context) error {
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return err
+	}
+
+	if setup {
+		version, err := driver.findMigrationSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version > 0 {
+			return driver.upgradeMigrationSchema(ctx, version)
+		}
+
+		driver.l.Info("Bytebase migration schema not found, creating schema...",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+
+		if err := driver.setupMigration(ctx); err != nil {
+			driver.l.Error("Failed to initialize migration schema.",
+				zap.Error(err),
+				zap.String("environment", driver.connectionCtx.EnvironmentName),
+				zap.String("database", driver.connectionCtx.InstanceName),
+			)
+			return err
+		}
+		driver.l.Info("Successfully created migration schema.",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+	}
+
+	return nil
+}
+
+func (driver *PostgresDriver) setupMigration(ctx context.Context) error {
+	const query = `
+		SELECT
+		    1
+		FROM pg_catalog.pg_database
+		WHERE datname = $1
+		`
+	rows, err := driver.db.QueryContext(ctx, query, bytebaseDatabase)
+	if err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+	exist := rows.Next()
+	rows.Close()
+
+	if !exist {
+		stmt := fmt.Sprintf("CREATE DATABASE %s", bytebaseDatabase)
+		if _, err := driver.db.ExecContext(ctx, stmt); err != nil {
+			return formatErrorWithQuery(err, stmt)
+		}
+	}
+
+	db, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, pgMigrationSchema); err != nil {
+		return formatErrorWithQuery(err, pgMigrationSchema)
+	}
+
+	return tx.Commit()
+}
+
+// upgradeMigrationSchema upgrades the migration schema created by the older version.
+func (driver *PostgresDriver) upgradeMigrationSchema(ctx context.Context, version int) error {
+	driver.l.Info("Bytebase migration schema is outdated, upgrading schema...",
+		zap.String("environment", driver.connectionCtx.EnvironmentName),
+		zap.String("database", driver.connectionCtx.InstanceName),
+		zap.Int("version", version),
+	)
+
+	db, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	const query = `
+		ALTER TABLE migration_history ADD COLUMN IF NOT EXISTS schema TEXT NOT NULL DEFAULT '';
+		UPDATE setting SET value = '2', updated_ts = EXTRACT(epoch FROM NOW())::BIGINT WHERE name = 'bb.schema.version';
+		`
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	driver.l.Info("Successfully upgraded migration schema.",
+		zap.String("environment", driver.connectionCtx.EnvironmentName),
+		zap.String("database", driver.connectionCtx.InstanceName),
+	)
+	return nil
+}
+
+func (driver *PostgresDriver) ExecuteMigration(ctx context.Context, m *MigrationInfo, statement string) error {
+	// The migration history lives in the bytebase database while the statement applies to the
+	// database the driver connects to. PostgreSQL can't span a transaction across databases,
+	// so we keep both transactions open and commit the migration first, then the history.
+	bytebaseDB, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return err
+	}
+	defer bytebaseDB.Close()
+
+	historyTx, err := bytebaseDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer historyTx.Rollback()
+
+	startedTs := time.Now().Unix()
+
+	// Phase 1 - Precheck before executing migration
+	sequence, err := pgPrecheckMigration(ctx, historyTx, m)
+	if err != nil {
+		return err
+	}
+
+	// Phase 2 - Executing migration unless it's VCS baselining
+	var migrationTx *sql.Tx
+	if m.Engine != VCS || m.Type != Baseline {
+		migrationTx, err = driver.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer migrationTx.Rollback()
+
+		if err := executeStatement(ctx, Postgres, migrationTx, statement); err != nil {
+			return formatError(err)
+		}
+	}
+
+	// Phase 3 - Record migration
+	const query = `
+		INSERT INTO migration_history (
+			created_by,
+			created_ts,
+			updated_by,
+			updated_ts,
+			namespace,
+			sequence,
+			engine,
+			type,
+			version,
+			description,
+			statement,
+			execution_duration,
+			issue_id,
+			payload,
+			schema
+		)
+		VALUES ($1, EXTRACT(epoch FROM NOW())::BIGINT, $2, EXTRACT(epoch FROM NOW())::BIGINT, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, '')
+	`
+	if _, err := historyTx.ExecContext(ctx, query,
+		m.Creator,
+		m.Creator,
+		m.Namespace,
+		sequence,
+		m.Engine,
+		m.Type,
+		m.Version,
+		m.Description,
+		statement,
+		time.Now().Unix()-startedTs,
+		m.IssueId,
+		m.Payload,
+	); err != nil {
+		return formatErrorWithQuery(formatError(err), query)
+	}
+
+	if migrationTx != nil {
+		if err := migrationTx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return historyTx.Commit()
+}
+
+func (driver *PostgresDriver) DryRunMigration(ctx context.Context, m *MigrationInfo, statement string) (*MigrationDryRunResult, error) {
+	bytebaseDB, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer bytebaseDB.Close()
+
+	historyTx, err := bytebaseDB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer historyTx.Rollback()
+
+	sequence, err := pgPrecheckMigration(ctx, historyTx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationDryRunResult{
+		Sequence:      sequence,
+		StatementList: []string{},
+	}
+	if m.Engine == VCS && m.Type == Baseline {
+		return result, nil
+	}
+
+	result.StatementList = SplitMultiSQL(Postgres, statement)
+	return result, nil
+}
+
+// pgPrecheckMigration checks whether the migration can be applied and returns the sequence to record.
+func pgPrecheckMigration(ctx context.Context, tx *sql.Tx, m *MigrationInfo) (int, error) {
+	// Check if the same migration version has alraedy been applied
+	duplicate, err := pgCheckDuplicateVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	if err != nil {
+		return -1, err
+	}
+	if duplicate {
+		return -1, fmt.Errorf("database '%s' has already applied version %s", m.Database, m.Version)
+	}
+
+	// Check if there is any higher version already been applied
+	version, err := pgCheckOutofOrderVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	if err != nil {
+		return -1, err
+	}
+	if version != nil {
+		return -1, fmt.Errorf("database '%s' has already applied version %s which is higher than %s", m.Database, *version, m.Version)
+	}
+
+	// If the migration engine is VCS and type is not baseline, then we can only proceed if there is existing baseline
+	if m.Engine == VCS && m.Type != Baseline {
+		hasBaseline, err := pgFindBaseline(ctx, tx, m.Namespace)
+		if err != nil {
+			return -1, err
+		}
+
+		if !hasBaseline {
+			return -1, fmt.Errorf("%s has not created migration baseline yet", m.Database)
+		}
+	}
+
+	// VCS based SQL migration requires existing baselining
+	requireBaseline := m.Engine == VCS && m.Type == Sql
+	return pgFindNextSequence(ctx, tx, m.Namespace, requireBaseline)
+}
+
+func (driver *PostgresDriver) FindMigrationHistoryList(ctx context.Context, find *MigrationHistoryFind) ([]*MigrationHistory, error) {
+	db, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.Database; v != nil {
+		where, args = append(where, fmt.Sprintf("namespace = $%d", len(args)+1)), append(args, *v)
+	}
+
+	var query = `
+		SELECT
+		    id,
+			created_by,
+		    created_ts,
+		    updated_by,
+		    updated_ts,
+			namespace,
+			sequence,
+			engine,
+			type,
+			version,
+			description,
+		    statement,
+		    execution_duration,
+			issue_id,
+			payload,
+			schema
+		FROM migration_history
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY created_ts DESC`
+	if v := find.Limit; v != nil {
+		query += fmt.Sprintf(" LIMIT %d", *v)
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*MigrationHistory, 0)
+	for rows.Next() {
+		var history MigrationHistory
+		if err := rows.Scan(
+			&history.ID,
+			&history.Creator,
+			&history.CreatedTs,
+			&history.Updater,
+			&history.UpdatedTs,
+			&history.Namespace,
+			&history.Sequence,
+			&history.Engine,
+			&history.Type,
+			&history.Version,
+			&history.Description,
+			&history.Statement,
+			&history.ExecutionDuration,
+			&history.IssueId,
+			&history.Payload,
+			&history.Schema,
+		); err != nil {
+			return nil, err
+		}
+
+		list = append(list, &history)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (driver *PostgresDriver) UpdateMigrationHistorySchema(ctx context.Context, m *MigrationInfo, schema string) error {
+	db, err := driver.switchDatabase(ctx, bytebaseDatabase)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	const query = `
+		UPDATE migration_history
+		SET schema = $1, updated_ts = EXTRACT(epoch FROM NOW())::BIGINT
+		WHERE namespace = $2 AND engine = $3 AND version = $4
+	`
+	if _, err := db.ExecContext(ctx, query, schema, m.Namespace, m.Engine, m.Version); err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+	return nil
+}
+
+func pgFindBaseline(ctx context.Context, tx *sql.Tx, namespace string) (bool, error) {
+	query := `
+		SELECT 1 FROM migration_history WHERE namespace = $1 AND type = 'BASELINE'
+	`
+	row, err := tx.QueryContext(ctx, query, namespace)
+	if err != nil {
+		return false, formatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	if !row.Next() {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func pgCheckDuplicateVersion(ctx context.Context, tx *sql.Tx, namespace string, engine MigrationEngine, version string) (bool, error) {
+	query := `
+		SELECT 1 FROM migration_history WHERE namespace = $1 AND engine = $2 AND version = $3
+	`
+	row, err := tx.QueryContext(ctx, query, namespace, engine.String(), version)
+	if err != nil {
+		return false, formatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		return true, nil
+	}
+	return false, nil
+}
+
+func pgCheckOutofOrderVersion(ctx context.Context, tx *sql.Tx, namespace string, engine MigrationEngine, version string) (*string, error) {
+	// Use the "C" collation to compare byte by byte, which is the same as the MySQL STRCMP.
+	query := `
+		SELECT MIN(version COLLATE "C") FROM migration_history WHERE namespace = $1 AND engine = $2 AND $3 < version COLLATE "C"
+	`
+	row, err := tx.QueryContext(ctx, query, namespace, engine.String(), version)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	var minVersion sql.NullString
+	row.Next()
+	if err := row.Scan(&minVersion); err != nil {
+		return nil, err
+	}
+
+	if minVersion.Valid {
+		return &minVersion.String, nil
+	}
+
+	return nil, nil
+}
+
+func pgFindNextSequence(ctx context.Context, tx *sql.Tx, namespace string, requireBaseline bool) (int, error) {
+	query := `
+		SELECT MAX(sequence) + 1 FROM migration_history WHERE namespace = $1
+	`
+	row, err := tx.QueryContext(ctx, query, namespace)
+	if err != nil {
+		return -1, formatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	var sequence sql.NullInt32
+	row.Next()
+	if err := row.Scan(&sequence); err != nil {
+		return -1, err
+	}
+
+	if !sequence.Valid {
+		// Returns 1 if we haven't applied any migration for this namespace and doesn't require baselining
+		if !requireBaseline {
+			return 1, nil
+		}
+
+		// This should not happen normally since we already check the baselining exist beforehand. Just in case.
+		return -1, fmt.Errorf("unable to generate next migration_sequence, no migration hisotry found for '%s', do you forget to baselining?", namespace)
+	}
+
+	return int(sequence.Int32), nil
+}
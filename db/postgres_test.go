@@ -0,0 +1,24 @@
+package db
+
+import "testing"
+
+func TestPgDSN(t *testing.T) {
+	tests := []struct {
+		config ConnectionConfig
+		want   string
+	}{
+		{
+			config: ConnectionConfig{Host: "localhost", Username: "postgres"},
+			want:   "host='localhost' port='5432' user='postgres' dbname='db' sslmode='disable'",
+		},
+		{
+			config: ConnectionConfig{Host: "db.example.com", Port: "6432", Username: "bb", Password: "it's secret", SSLMode: "require"},
+			want:   `host='db.example.com' port='6432' user='bb' dbname='db' sslmode='require' password='it\'s secret'`,
+		},
+	}
+	for _, tc := range tests {
+		if got := pgDSN(tc.config, "db"); got != tc.want {
+			t.Errorf("config=%+v: expected %q, got %q", tc.config, tc.want, got)
+		}
+	}
+}
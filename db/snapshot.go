@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaSnapshot renders the schema structure of a database as text, one object per line. It only includes the
+// properties changed by DDL, so the snapshot stays the same as long as nobody alters the schema. The lines are
+// sorted, and comparing two snapshots line by line tells what has changed.
+func SchemaSnapshot(schema *DBSchema) string {
+	var lineList []string
+	lineList = append(lineList, fmt.Sprintf("DATABASE %s CHARACTER SET %s COLLATE %s", schema.Name, schema.CharacterSet, schema.Collation))
+	for _, table := range schema.TableList {
+		lineList = append(lineList, fmt.Sprintf("TABLE %s TYPE %q ENGINE %q COLLATE %q COMMENT %q", table.Name, table.Type, table.Engine, table.Collation, table.Comment))
+		for _, column := range table.ColumnList {
+			defaultValue := "NULL"
+			if column.Default != nil {
+				defaultValue = fmt.Sprintf("%q", *column.Default)
+			}
+			nullable := "NOT NULL"
+			if column.Nullable {
+				nullable = "NULL"
+			}
+			lineList = append(lineList, fmt.Sprintf("COLUMN %s.%s POSITION %d TYPE %q %s DEFAULT %s CHARACTER SET %q COLLATE %q COMMENT %q",
+				table.Name, column.Name, column.Position, column.Type, nullable, defaultValue, column.CharacterSet, column.Collation, column.Comment))
+		}
+		for _, index := range table.IndexList {
+			lineList = append(lineList, fmt.Sprintf("INDEX %s.%s POSITION %d EXPRESSION %q TYPE %q UNIQUE %t VISIBLE %t COMMENT %q",
+				table.Name, index.Name, index.Position, index.Expression, index.Type, index.Unique, index.Visible, index.Comment))
+		}
+	}
+	sort.Strings(lineList)
+	return strings.Join(lineList, "\n")
+}
+
+// DiffSchemaSnapshot compares two snapshots generated by SchemaSnapshot. It returns the lines only in the old
+// snapshot prefixed with "- ", followed by the lines only in the new snapshot prefixed with "+ ".
+// Returns empty if both snapshots are the same.
+func DiffSchemaSnapshot(oldSnapshot string, newSnapshot string) []string {
+	oldLineMap := make(map[string]bool)
+	for _, line := range strings.Split(oldSnapshot, "\n") {
+		oldLineMap[line] = true
+	}
+	newLineMap := make(map[string]bool)
+	for _, line := range strings.Split(newSnapshot, "\n") {
+		newLineMap[line] = true
+	}
+
+	var diffList []string
+	for _, line := range strings.Split(oldSnapshot, "\n") {
+		if !newLineMap[line] {
+			diffList = append(diffList, "- "+line)
+		}
+	}
+	for _, line := range strings.Split(newSnapshot, "\n") {
+		if !oldLineMap[line] {
+			diffList = append(diffList, "+ "+line)
+		}
+	}
+	return diffList
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSchemaSnapshot(t *testing.T) {
+	defaultValue := "0"
+	schema := &DBSchema{
+		Name:         "db1",
+		CharacterSet: "utf8mb4",
+		Collation:    "utf8mb4_general_ci",
+		TableList: []DBTable{
+			{
+				Name:     "t1",
+				Type:     "BASE TABLE",
+				RowCount: 10,
+				ColumnList: []DBColumn{
+					{Name: "id", Position: 1, Type: "int"},
+					{Name: "age", Position: 2, Type: "int", Nullable: true, Default: &defaultValue},
+				},
+				IndexList: []DBIndex{
+					{Name: "PRIMARY", Expression: "id", Position: 1, Type: "BTREE", Unique: true, Visible: true},
+				},
+			},
+		},
+	}
+	snapshot := SchemaSnapshot(schema)
+
+	// Row count is not a part of the schema.
+	schema.TableList[0].RowCount = 20
+	if got := SchemaSnapshot(schema); got != snapshot {
+		t.Errorf("expected snapshot unchanged, got %q", got)
+	}
+	if diff := DiffSchemaSnapshot(snapshot, snapshot); len(diff) != 0 {
+		t.Errorf("expected no diff, got %q", diff)
+	}
+
+	schema.TableList[0].ColumnList[1].Nullable = false
+	want := []string{
+		`- COLUMN t1.age POSITION 2 TYPE "int" NULL DEFAULT "0" CHARACTER SET "" COLLATE "" COMMENT ""`,
+		`+ COLUMN t1.age POSITION 2 TYPE "int" NOT NULL DEFAULT "0" CHARACTER SET "" COLLATE "" COMMENT ""`,
+	}
+	if diff := DiffSchemaSnapshot(snapshot, SchemaSnapshot(schema)); !reflect.DeepEqual(want, diff) {
+		t.Errorf("expected diff %q, got %q", want, diff)
+	}
+}
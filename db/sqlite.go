@@ -0,0 +1,804 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+//go:embed sqlite_migration_schema.sql
+var sqliteMigrationSchema string
+
+const (
+	// Each SQLite database is stored as a {{database}}.db file under the instance directory.
+	sqliteDatabaseExt = ".db"
+)
+
+var (
+	_ Driver = (*SqliteDriver)(nil)
+)
+
+func init() {
+	register(Sqlite, newSqliteDriver)
+}
+
+// SqliteDriver manages the SQLite database files under a directory. The directory is specified
+// as the instance host relative to the SQLite root directory, and the migration history is stored in
+// the bytebase.db file under the same directory, which is attached to every connection as the "bytebase" schema.
+type SqliteDriver struct {
+	l             *zap.Logger
+	connectionCtx ConnectionContext
+	rootDir       string
+	dir           string
+
+	db *sql.DB
+}
+
+func newSqliteDriver(config DriverConfig) Driver {
+	return &SqliteDriver{
+		l:       config.Logger,
+		rootDir: config.SqliteDir,
+	}
+}
+
+func (driver *SqliteDriver) open(config ConnectionConfig, ctx ConnectionContext) (Driver, error) {
+	dir, err := resolveSqliteInstanceDir(driver.rootDir, config.Host)
+	if err != nil {
+		return nil, err
+	}
+	dsn := ":memory:"
+	if config.Database != "" {
+		if !isValidSqliteDatabaseName(config.Database) {
+			return nil, fmt.Errorf("invalid SQLite database name %q", config.Database)
+		}
+		// Use "rw" mode so that we won't accidentally create the database file.
+		dsn = fmt.Sprintf("file:%s?mode=rw", sqliteDatabasePath(dir, config.Database))
+	}
+
+	driver.l.Debug("Opening SQLite driver",
+		zap.String("dsn", dsn),
+		zap.String("environment", ctx.EnvironmentName),
+		zap.String("database", ctx.InstanceName),
+	)
+	// The root directory is created on demand, while the other instance directories are created by the admin.
+	if err := os.MkdirAll(driver.rootDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create SQLite root directory: %w", err)
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access SQLite instance directory %q: %w", config.Host, err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("SQLite instance path %q is not a directory", config.Host)
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// ATTACH only applies to the current connection, so we restrict the pool to a single connection.
+	db.SetMaxOpenConns(1)
+	// ATTACH creates the bytebase.db if it doesn't exist yet.
+	query := "ATTACH DATABASE ? AS bytebase"
+	if _, err := db.Exec(query, sqliteDatabasePath(dir, bytebaseDatabase)); err != nil {
+		db.Close()
+		return nil, formatErrorWithQuery(err, query)
+	}
+	driver.db = db
+	driver.dir = dir
+	driver.connectionCtx = ctx
+
+	return driver, nil
+}
+
+// resolveSqliteInstanceDir returns the instance directory of host under rootDir. The host must be a relative path
+// staying inside rootDir, so that the instance can't access the other files of the server, e.g. the Bytebase
+// metadata database.
+func resolveSqliteInstanceDir(rootDir string, host string) (string, error) {
+	if rootDir == "" {
+		return "", fmt.Errorf("SQLite root directory is not configured")
+	}
+	rel := filepath.Clean(host)
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("SQLite instance directory %q must be a relative path inside the SQLite root directory", host)
+	}
+	return filepath.Join(rootDir, rel), nil
+}
+
+// isValidSqliteDatabaseName returns true if the database file stays inside the instance directory.
+func isValidSqliteDatabaseName(database string) bool {
+	return database != "." && database != ".." && !strings.ContainsAny(database, `/\`)
+}
+
+func sqliteDatabasePath(dir string, database string) string {
+	return filepath.Join(dir, database+sqliteDatabaseExt)
+}
+
+func (driver *SqliteDriver) Close(ctx context.Context) error {
+	return driver.db.Close()
+}
+
+func (driver *SqliteDriver) Ping(ctx context.Context) error {
+	return driver.db.PingContext(ctx)
+}
+
+func (driver *SqliteDriver) SyncSchema(ctx context.Context) ([]*DBUser, []*DBSchema, error) {
+	fileList, err := ioutil.ReadDir(driver.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	schemaList := make([]*DBSchema, 0)
+	for _, file := range fileList {
+		if file.IsDir() || filepath.Ext(file.Name()) != sqliteDatabaseExt {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), sqliteDatabaseExt)
+		// Skip our internal "bytebase" database
+		if name == bytebaseDatabase {
+			continue
+		}
+
+		schema, err := driver.syncDatabase(ctx, name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sync database %q: %w", name, err)
+		}
+		schemaList = append(schemaList, schema)
+	}
+
+	// SQLite doesn't have users.
+	return []*DBUser{}, schemaList, nil
+}
+
+func (driver *SqliteDriver) syncDatabase(ctx context.Context, database string) (*DBSchema, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=ro", sqliteDatabasePath(driver.dir, database)))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	schema := &DBSchema{
+		Name:      database,
+		Collation: "BINARY",
+	}
+
+	query := "PRAGMA encoding"
+	if err := db.QueryRowContext(ctx, query).Scan(&schema.CharacterSet); err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+
+	// Query table info
+	query = `
+		SELECT
+			name,
+			type
+		FROM sqlite_master
+		WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%'
+		ORDER BY name`
+	tableRows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer tableRows.Close()
+
+	tableList := make([]DBTable, 0)
+	for tableRows.Next() {
+		var table DBTable
+		var tableType string
+		if err := tableRows.Scan(
+			&table.Name,
+			&tableType,
+		); err != nil {
+			return nil, err
+		}
+		// Align with the information_schema TABLE_TYPE.
+		if tableType == "view" {
+			table.Type = "VIEW"
+		} else {
+			table.Type = "BASE TABLE"
+		}
+		tableList = append(tableList, table)
+	}
+	if err := tableRows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range tableList {
+		table := &tableList[i]
+		if table.Type == "BASE TABLE" {
+			query := fmt.Sprintf("SELECT COUNT(*) FROM %s", sqliteQuoteIdentifier(table.Name))
+			if err := db.QueryRowContext(ctx, query).Scan(&table.RowCount); err != nil {
+				return nil, formatErrorWithQuery(err, query)
+			}
+		}
+
+		columnList, err := sqliteColumnList(ctx, db, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		table.ColumnList = columnList
+
+		indexList, err := sqliteIndexList(ctx, db, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		table.IndexList = indexList
+	}
+	schema.TableList = tableList
+
+	return schema, nil
+}
+
+func sqliteColumnList(ctx context.Context, db *sql.DB, table string) ([]DBColumn, error) {
+	query := fmt.Sprintf("PRAGMA table_info(%s)", sqliteQuoteIdentifier(table))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	columnList := make([]DBColumn, 0)
+	for rows.Next() {
+		var column DBColumn
+		var cid int
+		var notNull bool
+		var defaultStr sql.NullString
+		var pk int
+		if err := rows.Scan(
+			&cid,
+			&column.Name,
+			&column.Type,
+			&notNull,
+			&defaultStr,
+			&pk,
+		); err != nil {
+			return nil, err
+		}
+
+		column.Position = cid + 1
+		column.Nullable = !notNull
+		if defaultStr.Valid {
+			column.Default = &defaultStr.String
+		}
+		columnList = append(columnList, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return columnList, nil
+}
+
+func sqliteIndexList(ctx context.Context, db *sql.DB, table string) ([]DBIndex, error) {
+	query := fmt.Sprintf("PRAGMA index_list(%s)", sqliteQuoteIdentifier(table))
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	type indexMeta struct {
+		name   string
+		unique bool
+	}
+	metaList := []indexMeta{}
+	for rows.Next() {
+		var seq int
+		var meta indexMeta
+		var origin string
+		var partial bool
+		if err := rows.Scan(
+			&seq,
+			&meta.name,
+			&meta.unique,
+			&origin,
+			&partial,
+		); err != nil {
+			return nil, err
+		}
+		metaList = append(metaList, meta)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	indexList := make([]DBIndex, 0)
+	for _, meta := range metaList {
+		query := fmt.Sprintf("PRAGMA index_info(%s)", sqliteQuoteIdentifier(meta.name))
+		columnRows, err := db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, formatErrorWithQuery(err, query)
+		}
+
+		for columnRows.Next() {
+			var seqno int
+			var cid int
+			var columnName sql.NullString
+			if err := columnRows.Scan(
+				&seqno,
+				&cid,
+				&columnName,
+			); err != nil {
+				columnRows.Close()
+				return nil, err
+			}
+
+			index := DBIndex{
+				Name:     meta.name,
+				Position: seqno + 1,
+				// SQLite always uses B-tree for index.
+				Type:    "BTREE",
+				Unique:  meta.unique,
+				Visible: true,
+			}
+			// Column name is NULL if the index refers to an expression.
+			if columnName.Valid {
+				index.Expression = columnName.String
+			}
+			indexList = append(indexList, index)
+		}
+		if err := columnRows.Err(); err != nil {
+			columnRows.Close()
+			return nil, err
+		}
+		columnRows.Close()
+	}
+
+	return indexList, nil
+}
+
+func sqliteQuoteIdentifier(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func (driver *SqliteDriver) Execute(ctx context.Context, statement string) error {
+	// SQLite doesn't support CREATE DATABASE, we create the database file instead.
+	if database, ok := parseSqliteCreateDatabase(statement); ok {
+		return driver.createDatabase(ctx, database)
+	}
+
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statement); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// parseSqliteCreateDatabase extracts the database name from "CREATE DATABASE {{name}} [...]".
+func parseSqliteCreateDatabase(statement string) (string, bool) {
+	fields := strings.Fields(strings.TrimSpace(statement))
+	if len(fields) < 3 || !strings.EqualFold(fields[0], "CREATE") || !strings.EqualFold(fields[1], "DATABASE") {
+		return "", false
+	}
+	name := strings.TrimSuffix(fields[2], ";")
+	name = strings.Trim(name, "`\"'")
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func (driver *SqliteDriver) createDatabase(ctx context.Context, database string) error {
+	if database == bytebaseDatabase || !isValidSqliteDatabaseName(database) {
+		return fmt.Errorf("invalid SQLite database name %q", database)
+	}
+
+	path := sqliteDatabasePath(driver.dir, database)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("database %q already exists", database)
+	}
+
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?mode=rwc", path))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// SQLite creates the file lazily, so we force it by writing the schema header.
+	_, err = db.ExecContext(ctx, "PRAGMA user_version = 0")
+	return err
+}
+
+func (driver *SqliteDriver) NeedsSetupMigration(ctx context.Context) (bool, error) {
+	version, err := driver.findMigrationSchemaVersion(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return version < migrationSchemaVersion, nil
+}
+
+// findMigrationSchemaVersion returns the version of the migration schema, or 0 if the migration schema doesn't exist.
+func (driver *SqliteDriver) findMigrationSchemaVersion(ctx context.Context) (int, error) {
+	const query = `
+		SELECT
+		    1
+		FROM bytebase.sqlite_master
+		WHERE type = 'table' AND name = 'migration_history'
+		`
+	rows, err := driver.db.QueryContext(ctx, query)
+	if err != nil {
+		return 0, formatErrorWithQuery(err, query)
+	}
+	exist := rows.Next()
+	rows.Close()
+
+	if !exist {
+		return 0, nil
+	}
+
+	const versionQuery = `
+		SELECT value FROM bytebase.setting WHERE name = 'bb.schema.version'
+		`
+	var value string
+	if err := driver.db.QueryRowContext(ctx, versionQuery).Scan(&value); err != nil {
+		return 0, formatErrorWithQuery(err, versionQuery)
+	}
+	return strconv.Atoi(value)
+}
+
+func (driver *SqliteDriver) SetupMigrationIfNeeded(ctx context.Context) error {
+	setup, err := driver.NeedsSetupMigration(ctx)
+	if err != nil {
+		return err
+	}
+
+	if setup {
+		version, err := driver.findMigrationSchemaVersion(ctx)
+		if err != nil {
+			return err
+		}
+		if version > 0 {
+			return driver.upgradeMigrationSchema(ctx, version)
+		}
+
+		driver.l.Info("Bytebase migration schema not found, creating schema...",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+		if err := driver.Execute(ctx, sqliteMigrationSchema); err != nil {
+			driver.l.Error("Failed to initialize migration schema.",
+				zap.Error(err),
+				zap.String("environment", driver.connectionCtx.EnvironmentName),
+				zap.String("database", driver.connectionCtx.InstanceName),
+			)
+			return formatErrorWithQuery(err, sqliteMigrationSchema)
+		}
+		driver.l.Info("Successfully created migration schema.",
+			zap.String("environment", driver.connectionCtx.EnvironmentName),
+			zap.String("database", driver.connectionCtx.InstanceName),
+		)
+	}
+
+	return nil
+}
+
+// upgradeMigrationSchema upgrades the migration schema created by the older version.
+func (driver *SqliteDriver) upgradeMigrationSchema(ctx context.Context, version int) error {
+	driver.l.Info("Bytebase migration schema is outdated, upgrading schema...",
+		zap.String("environment", driver.connectionCtx.EnvironmentName),
+		zap.String("database", driver.connectionCtx.InstanceName),
+		zap.Int("version", version),
+	)
+
+	const query = `
+		ALTER TABLE bytebase.migration_history ADD COLUMN ` + "`schema`" + ` TEXT NOT NULL DEFAULT '';
+		UPDATE bytebase.setting SET value = '2', updated_ts = strftime('%s', 'now') WHERE name = 'bb.schema.version';
+		`
+	if err := driver.Execute(ctx, query); err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+
+	driver.l.Info("Successfully upgraded migration schema.",
+		zap.String("environment", driver.connectionCtx.EnvironmentName),
+		zap.String("database", driver.connectionCtx.InstanceName),
+	)
+	return nil
+}
+
+func (driver *SqliteDriver) ExecuteMigration(ctx context.Context, m *MigrationInfo, statement string) error {
+	// The bytebase database is attached to the same connection, so the migration and its history
+	// are committed atomically.
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	startedTs := time.Now().Unix()
+
+	// Phase 1 - Precheck before executing migration
+	sequence, err := sqlitePrecheckMigration(ctx, tx, m)
+	if err != nil {
+		return err
+	}
+
+	// Phase 2 - Executing migration unless it's VCS baselining
+	if m.Engine != VCS || m.Type != Baseline {
+		if err := executeStatement(ctx, Sqlite, tx, statement); err != nil {
+			return formatError(err)
+		}
+	}
+
+	// Phase 3 - Record migration
+	const query = `
+		INSERT INTO bytebase.migration_history (
+			created_by,
+			created_ts,
+			updated_by,
+			updated_ts,
+			namespace,
+			sequence,
+			` + "`engine`," + `
+			` + "`type`," + `
+			version,
+			description,
+			statement,
+			execution_duration,
+			issue_id,
+			payload,
+			` + "`schema`" + `
+		)
+		VALUES (?, strftime('%s', 'now'), ?, strftime('%s', 'now'), ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, '')
+	`
+	_, err = tx.ExecContext(ctx, query,
+		m.Creator,
+		m.Creator,
+		m.Namespace,
+		sequence,
+		m.Engine,
+		m.Type,
+		m.Version,
+		m.Description,
+		statement,
+		time.Now().Unix()-startedTs,
+		m.IssueId,
+		m.Payload,
+	)
+
+	if err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+
+	return tx.Commit()
+}
+
+func (driver *SqliteDriver) DryRunMigration(ctx context.Context, m *MigrationInfo, statement string) (*MigrationDryRunResult, error) {
+	tx, err := driver.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sequence, err := sqlitePrecheckMigration(ctx, tx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MigrationDryRunResult{
+		Sequence:      sequence,
+		StatementList: []string{},
+	}
+	if m.Engine == VCS && m.Type == Baseline {
+		return result, nil
+	}
+
+	// SQLite is embedded, so we validate the statement on an in-memory database with the same schema instead of
+	// the database itself.
+	if err := validateOnSqliteScratchDatabase(ctx, tx, statement); err != nil {
+		return nil, err
+	}
+	result.StatementList = SplitMultiSQL(Sqlite, statement)
+	result.Validated = true
+
+	return result, nil
+}
+
+// validateOnSqliteScratchDatabase copies the schema of the main database of tx to an in-memory database, and
+// executes the statement there the same way as ExecuteMigration.
+func validateOnSqliteScratchDatabase(ctx context.Context, tx *sql.Tx, statement string) error {
+	// The objects are listed in the order of creation, so that the tables are created before their indexes and
+	// triggers. The internal tables such as sqlite_sequence are created by SQLite itself.
+	query := "SELECT sql FROM main.sqlite_master WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY rowid"
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+	var schemaList []string
+	for rows.Next() {
+		var stmt string
+		if err := rows.Scan(&stmt); err != nil {
+			return err
+		}
+		schemaList = append(schemaList, stmt)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	scratch, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		return err
+	}
+	defer scratch.Close()
+	// Each connection has its own in-memory database.
+	scratch.SetMaxOpenConns(1)
+	for _, stmt := range schemaList {
+		if _, err := scratch.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("failed to copy schema to scratch database: %w", formatErrorWithQuery(err, stmt))
+		}
+	}
+
+	scratchTx, err := scratch.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer scratchTx.Rollback()
+	return executeStatement(ctx, Sqlite, scratchTx, statement)
+}
+
+// sqlitePrecheckMigration checks whether the migration can be applied and returns the sequence to record.
+func sqlitePrecheckMigration(ctx context.Context, tx *sql.Tx, m *MigrationInfo) (int, error) {
+	// Check if the same migration version has alraedy been applied
+	duplicate, err := checkDuplicateVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	if err != nil {
+		return -1, err
+	}
+	if duplicate {
+		return -1, fmt.Errorf("database '%s' has already applied version %s", m.Database, m.Version)
+	}
+
+	// Check if there is any higher version already been applied
+	version, err := sqliteCheckOutofOrderVersion(ctx, tx, m.Namespace, m.Engine, m.Version)
+	if err != nil {
+		return -1, err
+	}
+	if version != nil {
+		return -1, fmt.Errorf("database '%s' has already applied version %s which is higher than %s", m.Database, *version, m.Version)
+	}
+
+	// If the migration engine is VCS and type is not baseline, then we can only proceed if there is existing baseline
+	if m.Engine == VCS && m.Type != Baseline {
+		hasBaseline, err := findBaseline(ctx, tx, m.Namespace)
+		if err != nil {
+			return -1, err
+		}
+
+		if !hasBaseline {
+			return -1, fmt.Errorf("%s has not created migration baseline yet", m.Database)
+		}
+	}
+
+	// VCS based SQL migration requires existing baselining
+	requireBaseline := m.Engine == VCS && m.Type == Sql
+	return findNextSequence(ctx, tx, m.Namespace, requireBaseline)
+}
+
+func (driver *SqliteDriver) FindMigrationHistoryList(ctx context.Context, find *MigrationHistoryFind) ([]*MigrationHistory, error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.Database; v != nil {
+		where, args = append(where, "namespace = ?"), append(args, *v)
+	}
+
+	var query = `
+		SELECT
+		    id,
+			created_by,
+		    created_ts,
+		    updated_by,
+		    updated_ts,
+			namespace,
+			sequence,
+			` + "`engine`," + `
+			` + "`type`," + `
+			version,
+			description,
+		    statement,
+		    execution_duration,
+			issue_id,
+			payload,
+			` + "`schema`" + `
+		FROM bytebase.migration_history
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY created_ts DESC`
+	if v := find.Limit; v != nil {
+		query += fmt.Sprintf(" LIMIT %d", *v)
+	}
+
+	rows, err := driver.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*MigrationHistory, 0)
+	for rows.Next() {
+		var history MigrationHistory
+		if err := rows.Scan(
+			&history.ID,
+			&history.Creator,
+			&history.CreatedTs,
+			&history.Updater,
+			&history.UpdatedTs,
+			&history.Namespace,
+			&history.Sequence,
+			&history.Engine,
+			&history.Type,
+			&history.Version,
+			&history.Description,
+			&history.Statement,
+			&history.ExecutionDuration,
+			&history.IssueId,
+			&history.Payload,
+			&history.Schema,
+		); err != nil {
+			return nil, err
+		}
+
+		list = append(list, &history)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (driver *SqliteDriver) UpdateMigrationHistorySchema(ctx context.Context, m *MigrationInfo, schema string) error {
+	const query = `
+		UPDATE bytebase.migration_history
+		SET ` + "`schema`" + ` = ?, updated_ts = strftime('%s', 'now')
+		WHERE namespace = ? AND ` + "`engine`" + ` = ? AND version = ?
+	`
+	if _, err := driver.db.ExecContext(ctx, query, schema, m.Namespace, m.Engine, m.Version); err != nil {
+		return formatErrorWithQuery(err, query)
+	}
+	return nil
+}
+
+func sqliteCheckOutofOrderVersion(ctx context.Context, tx *sql.Tx, namespace string, engine MigrationEngine, version string) (*string, error) {
+	// SQLite compares TEXT using the BINARY collation by default, which is the same as the MySQL STRCMP.
+	query := `
+		SELECT MIN(version) FROM bytebase.migration_history WHERE namespace = ? AND ` + "`engine` = ? AND ? < version" + `
+	`
+	args := []interface{}{namespace, engine.String(), version}
+	row, err := tx.QueryContext(ctx, query,
+		args...,
+	)
+
+	if err != nil {
+		return nil, formatErrorWithQuery(err, query)
+	}
+	defer row.Close()
+
+	var minVersion sql.NullString
+	row.Next()
+	if err := row.Scan(&minVersion); err != nil {
+		return nil, err
+	}
+
+	if minVersion.Valid {
+		return &minVersion.String, nil
+	}
+
+	return nil, nil
+}
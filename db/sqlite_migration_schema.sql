@@ -0,0 +1,70 @@
+-- This is the bytebase schema to track migration info for SQLite
+-- The schema lives in the bytebase.db file under the instance directory, which is attached as "bytebase".
+CREATE TABLE bytebase.setting (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_by TEXT NOT NULL,
+    created_ts BIGINT NOT NULL,
+    updated_by TEXT NOT NULL,
+    updated_ts BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    value TEXT NOT NULL,
+    description TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX bytebase.bytebase_idx_unique_setting_name ON setting (name);
+
+-- Insert schema version 2
+INSERT INTO
+    bytebase.setting (
+        created_by,
+        created_ts,
+        updated_by,
+        updated_ts,
+        name,
+        value,
+        description
+    )
+VALUES
+    (
+        'bytebase',
+        strftime('%s', 'now'),
+        'bytebase',
+        strftime('%s', 'now'),
+        'bb.schema.version',
+        '2',
+        'Schema version'
+    );
+
+-- Create migration_history table
+CREATE TABLE bytebase.migration_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_by TEXT NOT NULL,
+    created_ts BIGINT NOT NULL,
+    updated_by TEXT NOT NULL,
+    updated_ts BIGINT NOT NULL,
+    -- Allows granular tracking of migration history (e.g If an application manages schemas for a multi-tenant service and each tenant has its own schema, that application can use namespace to record the tenant name to track the per-tenant schema migration)
+    -- Since bytebase also manages different application databases from an instance, it leverages this field to track each database migration history.
+    namespace TEXT NOT NULL,
+    -- Used to detect out of order migration together with 'namespace' and 'version' column.
+    sequence INTEGER NOT NULL CHECK (sequence >= 0),
+    -- We call it engine because maybe we could load history from other migration tool.
+    `engine` TEXT NOT NULL CHECK (`engine` IN ('UI', 'VCS')),
+    `type` TEXT NOT NULL CHECK (`type` IN ('BASELINE', 'SQL')),
+    version TEXT NOT NULL,
+    description TEXT NOT NULL,
+    -- Recorded the migration statement
+    statement TEXT NOT NULL,
+    execution_duration INTEGER NOT NULL,
+    issue_id TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    -- Recorded the schema snapshot after the migration, used to detect the schema drift
+    `schema` TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX bytebase.bytebase_idx_unique_migration_history_namespace_sequence ON migration_history (namespace, sequence);
+
+CREATE UNIQUE INDEX bytebase.bytebase_idx_unique_migration_history_namespace_engine_version ON migration_history (namespace, `engine`, version);
+
+CREATE INDEX bytebase.bytebase_idx_migration_history_namespace_engine_type ON migration_history (namespace, `engine`, `type`);
+
+CREATE INDEX bytebase.bytebase_idx_migration_history_namespace_created ON migration_history (namespace, created_ts);
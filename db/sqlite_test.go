@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func openSqliteTestDriver(t *testing.T, dir string, database string) Driver {
+	driver, err := Open(
+		Sqlite,
+		DriverConfig{Logger: zap.NewNop(), SqliteDir: dir},
+		ConnectionConfig{
+			Database: database,
+		},
+		ConnectionContext{},
+	)
+	if err != nil {
+		t.Fatalf("failed to open SQLite driver: %v", err)
+	}
+	return driver
+}
+
+func TestResolveSqliteInstanceDir(t *testing.T) {
+	tests := []struct {
+		host    string
+		want    string
+		wantErr bool
+	}{
+		{"", "/data/sqlite", false},
+		{"app", "/data/sqlite/app", false},
+		{"app/../other", "/data/sqlite/other", false},
+		{"/etc", "", true},
+		{"..", "", true},
+		{"../bytebase_prod.db", "", true},
+		{"app/../../data", "", true},
+	}
+	for _, test := range tests {
+		got, err := resolveSqliteInstanceDir("/data/sqlite", test.host)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("resolveSqliteInstanceDir(%q) = %q, want error", test.host, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("resolveSqliteInstanceDir(%q) = %q, %v, want %q", test.host, got, err, test.want)
+		}
+	}
+	if _, err := resolveSqliteInstanceDir("", "app"); err == nil {
+		t.Errorf("resolveSqliteInstanceDir() without root directory got no error")
+	}
+}
+
+func TestSqliteUpgradeMigrationSchema(t *testing.T) {
+	ctx := context.Background()
+	driver := openSqliteTestDriver(t, t.TempDir(), "")
+	defer driver.Close(ctx)
+
+	// Create the version 1 schema without the schema snapshot.
+	v1Schema := strings.Replace(sqliteMigrationSchema, ",\n    -- Recorded the schema snapshot after the migration, used to detect the schema drift\n    `schema` TEXT NOT NULL", "", 1)
+	v1Schema = strings.Replace(v1Schema, "'2',", "'1',", 1)
+	if v1Schema == sqliteMigrationSchema || strings.Contains(v1Schema, "`schema`") {
+		t.Fatalf("failed to derive the version 1 migration schema")
+	}
+	if err := driver.Execute(ctx, v1Schema); err != nil {
+		t.Fatalf("failed to create version 1 migration schema: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		setup, err := driver.NeedsSetupMigration(ctx)
+		if err != nil {
+			t.Fatalf("failed to check migration setup: %v", err)
+		}
+		if setup != (i == 0) {
+			t.Errorf("attempt %d: expected migration setup needed to be %v", i, i == 0)
+		}
+		if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+			t.Fatalf("attempt %d: failed to upgrade migration: %v", i, err)
+		}
+	}
+	if err := driver.UpdateMigrationHistorySchema(ctx, &MigrationInfo{Namespace: "db1", Engine: UI, Version: "0001"}, ""); err != nil {
+		t.Errorf("expected the schema column after upgrade: %v", err)
+	}
+}
+
+func TestSqliteMigration(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	instanceDriver := openSqliteTestDriver(t, dir, "")
+	defer instanceDriver.Close(ctx)
+
+	if err := instanceDriver.Execute(ctx, "CREATE DATABASE `db1` CHARACTER SET utf8mb4;"); err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if err := instanceDriver.Execute(ctx, "CREATE DATABASE db1"); err == nil {
+		t.Errorf("expected error when creating existing database")
+	}
+
+	setup, err := instanceDriver.NeedsSetupMigration(ctx)
+	if err != nil {
+		t.Fatalf("failed to check migration setup: %v", err)
+	}
+	if !setup {
+		t.Errorf("expected migration setup needed")
+	}
+	if err := instanceDriver.SetupMigrationIfNeeded(ctx); err != nil {
+		t.Fatalf("failed to setup migration: %v", err)
+	}
+	setup, err = instanceDriver.NeedsSetupMigration(ctx)
+	if err != nil {
+		t.Fatalf("failed to check migration setup: %v", err)
+	}
+	if setup {
+		t.Errorf("expected migration setup not needed")
+	}
+
+	driver := openSqliteTestDriver(t, dir, "db1")
+	defer driver.Close(ctx)
+
+	m := &MigrationInfo{
+		Version:     "0002",
+		Namespace:   "db1",
+		Database:    "db1",
+		Engine:      UI,
+		Type:        Sql,
+		Description: "Create t1",
+		Creator:     "bytebase",
+	}
+	statement := "CREATE TABLE t1 (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT ''); CREATE UNIQUE INDEX idx_t1_name ON t1 (name);"
+	result, err := driver.DryRunMigration(ctx, m, statement)
+	if err != nil {
+		t.Fatalf("failed to dry run migration: %v", err)
+	}
+	if result.Sequence != 1 || len(result.StatementList) != 2 {
+		t.Errorf("unexpected dry run result %+v", result)
+	}
+	if _, err := driver.DryRunMigration(ctx, m, "CREATE TABLE t1 (id INTEGER); CREATE TABLE t1 (id INTEGER);"); err == nil {
+		t.Errorf("expected dry run error for invalid statement")
+	}
+
+	var logList []*StatementLog
+	loggerCtx := WithStatementLogger(ctx, func(log *StatementLog) {
+		logList = append(logList, log)
+	})
+	if err := driver.ExecuteMigration(loggerCtx, m, statement); err != nil {
+		t.Fatalf("failed to execute migration: %v", err)
+	}
+	// Each statement is logged before and after its execution.
+	wantStatementList := SplitMultiSQL(Sqlite, statement)
+	if len(logList) != 2*len(wantStatementList) {
+		t.Fatalf("expected %d statement logs, got %+v", 2*len(wantStatementList), logList)
+	}
+	for i, want := range wantStatementList {
+		start, done := logList[2*i], logList[2*i+1]
+		if start.Done || start.Statement != want || !done.Done || done.Statement != want || done.Err != nil {
+			t.Errorf("unexpected statement logs %+v and %+v for %q", start, done, want)
+		}
+	}
+
+	if err := driver.ExecuteMigration(ctx, m, statement); err == nil || !strings.Contains(err.Error(), "has already applied version 0002") {
+		t.Errorf("expected duplicate version error, got %v", err)
+	}
+	if _, err := driver.DryRunMigration(ctx, m, statement); err == nil || !strings.Contains(err.Error(), "has already applied version 0002") {
+		t.Errorf("expected dry run duplicate version error, got %v", err)
+	}
+
+	outOfOrder := *m
+	outOfOrder.Version = "0001"
+	if err := driver.ExecuteMigration(ctx, &outOfOrder, "CREATE TABLE t0 (id INTEGER)"); err == nil || !strings.Contains(err.Error(), "which is higher than 0001") {
+		t.Errorf("expected out of order version error, got %v", err)
+	}
+
+	// The dry run validates on a scratch copy of the schema having t1, and the statements may depend on each other.
+	next := *m
+	next.Version = "0003"
+	dryRunStatement := "CREATE TABLE t2 (id INTEGER); CREATE INDEX idx_t2_id ON t2 (id); ALTER TABLE t1 ADD COLUMN age INTEGER;"
+	result, err = driver.DryRunMigration(ctx, &next, dryRunStatement)
+	if err != nil {
+		t.Fatalf("failed to dry run migration: %v", err)
+	}
+	if !result.Validated || result.Sequence != 2 || len(result.StatementList) != 3 {
+		t.Errorf("unexpected dry run result %+v", result)
+	}
+	// Nothing is changed by the dry run, so it passes again.
+	if _, err := driver.DryRunMigration(ctx, &next, dryRunStatement); err != nil {
+		t.Errorf("failed to dry run migration again: %v", err)
+	}
+
+	database := "db1"
+	historyList, err := driver.FindMigrationHistoryList(ctx, &MigrationHistoryFind{Database: &database})
+	if err != nil {
+		t.Fatalf("failed to find migration history: %v", err)
+	}
+	if len(historyList) != 1 || historyList[0].Version != "0002" || historyList[0].Sequence != 1 || historyList[0].Schema != "" {
+		t.Errorf("unexpected migration history %+v", historyList)
+	}
+
+	if err := driver.UpdateMigrationHistorySchema(ctx, m, "TABLE t1"); err != nil {
+		t.Fatalf("failed to update migration history schema: %v", err)
+	}
+	historyList, err = driver.FindMigrationHistoryList(ctx, &MigrationHistoryFind{Database: &database})
+	if err != nil {
+		t.Fatalf("failed to find migration history: %v", err)
+	}
+	if len(historyList) != 1 || historyList[0].Schema != "TABLE t1" {
+		t.Errorf("unexpected migration history schema %+v", historyList)
+	}
+
+	userList, schemaList, err := instanceDriver.SyncSchema(ctx)
+	if err != nil {
+		t.Fatalf("failed to sync schema: %v", err)
+	}
+	if len(userList) != 0 {
+		t.Errorf("expected no user, got %d", len(userList))
+	}
+	if len(schemaList) != 1 || schemaList[0].Name != "db1" {
+		t.Fatalf("unexpected schema list %+v", schemaList)
+	}
+	tableList := schemaList[0].TableList
+	if len(tableList) != 1 || tableList[0].Name != "t1" {
+		t.Fatalf("unexpected table list %+v", tableList)
+	}
+	if len(tableList[0].ColumnList) != 2 || tableList[0].ColumnList[1].Name != "name" || tableList[0].ColumnList[1].Nullable {
+		t.Errorf("unexpected column list %+v", tableList[0].ColumnList)
+	}
+	if len(tableList[0].IndexList) != 1 || tableList[0].IndexList[0].Name != "idx_t1_name" || !tableList[0].IndexList[0].Unique {
+		t.Errorf("unexpected index list %+v", tableList[0].IndexList)
+	}
+}
@@ -0,0 +1,259 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// StatementLog is the execution log of a migration statement.
+type StatementLog struct {
+	Statement string
+	// Done is false for the log reported right before executing the statement, and true for the one reported after
+	// the statement finishes with the result below.
+	Done         bool
+	Duration     time.Duration
+	RowsAffected int64
+	Err          error
+}
+
+// StatementLogger is called before and after executing the statement of a migration.
+type StatementLogger func(log *StatementLog)
+
+type statementLoggerKey struct{}
+
+// WithStatementLogger returns a context that makes ExecuteMigration report the statement to logger before executing
+// it, and report the result after it finishes.
+func WithStatementLogger(ctx context.Context, logger StatementLogger) context.Context {
+	return context.WithValue(ctx, statementLoggerKey{}, logger)
+}
+
+// executeStatement splits the possibly multi-statement statement of dbType by SplitMultiSQL, and executes each of
+// them in tx in order. If ctx carries a StatementLogger, each statement is reported to the logger before and after
+// its execution, so a long-running statement shows up while it's still running.
+func executeStatement(ctx context.Context, dbType Type, tx *sql.Tx, statement string) error {
+	logger, ok := ctx.Value(statementLoggerKey{}).(StatementLogger)
+	for _, stmt := range SplitMultiSQL(dbType, statement) {
+		if ok {
+			logger(&StatementLog{Statement: stmt})
+		}
+
+		startedAt := time.Now()
+		result, err := tx.ExecContext(ctx, stmt)
+		if ok {
+			log := &StatementLog{
+				Statement: stmt,
+				Done:      true,
+				Duration:  time.Since(startedAt),
+				Err:       err,
+			}
+			if err == nil {
+				// Not all drivers support rows affected, e.g. for DDL, so we just ignore the error.
+				log.RowsAffected, _ = result.RowsAffected()
+			}
+			logger(log)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	// MySQL stored programs whose body may be a BEGIN ... END block containing ";".
+	mysqlStoredProgramRegexp = regexp.MustCompile(`(?is)^\s*CREATE\b.*?\b(TRIGGER|PROCEDURE|FUNCTION|EVENT)\b`)
+	// The END of these blocks isn't counted since their beginning isn't counted either.
+	mysqlEndBlockSet = map[string]bool{"IF": true, "LOOP": true, "WHILE": true, "REPEAT": true}
+)
+
+// SplitMultiSQL splits the statement of dbType into a list of single statements by the ";" delimiter.
+// It understands quoted strings, quoted identifiers, comments and PostgreSQL dollar-quoted strings,
+// so a ";" inside them won't split the statement. For MySQL, it also understands the "#" comment, the
+// backslash escape in strings, the DELIMITER command of the mysql client and the BEGIN ... END block of
+// the stored programs. Empty statements are omitted and the trailing delimiter is removed.
+func SplitMultiSQL(dbType Type, statement string) []string {
+	var list []string
+	var current strings.Builder
+	delimiter := ";"
+	// The depth of the BEGIN ... END and CASE ... END blocks in the MySQL stored program.
+	depth := 0
+
+	appendCurrent := func() {
+		if s := strings.TrimSpace(current.String()); s != "" && !isCommentOnly(s) {
+			list = append(list, s)
+		}
+		current.Reset()
+		depth = 0
+	}
+
+	for i := 0; i < len(statement); {
+		c := statement[i]
+		switch {
+		case dbType == Mysql && isCommentOnly(current.String()) && hasPrefixFold(statement[i:], "DELIMITER "):
+			// DELIMITER is a mysql client command taking the rest of the line, it's not sent to the server.
+			appendCurrent()
+			end := strings.IndexByte(statement[i:], '\n')
+			if end < 0 {
+				end = len(statement) - i
+			}
+			if d := strings.TrimSpace(statement[i+len("DELIMITER ") : i+end]); d != "" {
+				delimiter = d
+			}
+			i += end
+		case c == '\'' || c == '"' || c == '`':
+			end := indexQuoteEnd(statement, i+1, c, hasBackslashEscape(dbType, statement, i))
+			current.WriteString(statement[i:end])
+			i = end
+		case c == '-' && strings.HasPrefix(statement[i:], "--"), dbType == Mysql && c == '#':
+			end := strings.IndexByte(statement[i:], '\n')
+			if end < 0 {
+				end = len(statement) - i
+			}
+			current.WriteString(statement[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				end = len(statement)
+			} else {
+				end = i + 2 + end + 2
+			}
+			current.WriteString(statement[i:end])
+			i = end
+		case c == '$' && dbType != Mysql && (i == 0 || !isIdentifierChar(statement[i-1])):
+			tag, ok := dollarQuoteTag(statement[i:])
+			if !ok {
+				current.WriteByte(c)
+				i++
+				continue
+			}
+			end := strings.Index(statement[i+len(tag):], tag)
+			if end < 0 {
+				end = len(statement)
+			} else {
+				end = i + len(tag) + end + len(tag)
+			}
+			current.WriteString(statement[i:end])
+			i = end
+		case strings.HasPrefix(statement[i:], delimiter) && (delimiter != ";" || depth == 0):
+			appendCurrent()
+			i += len(delimiter)
+		case dbType == Mysql && isKeywordChar(c) && (i == 0 || !isIdentifierChar(statement[i-1])):
+			end := i + len(nextWord(statement[i:]))
+			switch word := strings.ToUpper(statement[i:end]); word {
+			case "BEGIN", "CASE":
+				if depth > 0 || (word == "BEGIN" && mysqlStoredProgramRegexp.MatchString(current.String())) {
+					depth++
+				}
+			case "END":
+				// Consume the block type following END together, so that "END CASE" isn't taken as another CASE.
+				rest := statement[end:]
+				next := nextWord(rest)
+				if blockType := strings.ToUpper(next); blockType == "CASE" || mysqlEndBlockSet[blockType] {
+					end += len(rest) - len(strings.TrimLeft(rest, " \t\r\n")) + len(next)
+					if blockType == "CASE" && depth > 0 {
+						depth--
+					}
+				} else if depth > 0 {
+					depth--
+				}
+			}
+			current.WriteString(statement[i:end])
+			i = end
+		default:
+			current.WriteByte(c)
+			i++
+		}
+	}
+	appendCurrent()
+
+	return list
+}
+
+// hasBackslashEscape returns true if the backslash escapes the next character in the quoted string starting at
+// statement[start]. MySQL strings support backslash escapes by default, while PostgreSQL only supports them in the
+// escape string constants, e.g. E'it\'s', and SQLite doesn't support them at all.
+func hasBackslashEscape(dbType Type, statement string, start int) bool {
+	quote := statement[start]
+	switch dbType {
+	case Mysql:
+		return quote != '`'
+	case Postgres:
+		return quote == '\'' && start > 0 && (statement[start-1] == 'E' || statement[start-1] == 'e') &&
+			(start == 1 || !isIdentifierChar(statement[start-2]))
+	}
+	return false
+}
+
+// indexQuoteEnd returns the index right after the closing quote, a doubled quote or a backslash escaped
+// quote if backslashEscape is true doesn't close the quoted string.
+func indexQuoteEnd(s string, start int, quote byte, backslashEscape bool) int {
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if backslashEscape {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// dollarQuoteTag returns the PostgreSQL dollar quote tag such as "$$" or "$body$" at the beginning of s.
+func dollarQuoteTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1], true
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return "", false
+		}
+	}
+	return "", false
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+func isKeywordChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// nextWord returns the keyword at the beginning of s after skipping the leading whitespaces.
+func nextWord(s string) string {
+	s = strings.TrimLeft(s, " \t\r\n")
+	end := 0
+	for end < len(s) && (isKeywordChar(s[end]) || end > 0 && s[end] >= '0' && s[end] <= '9') {
+		end++
+	}
+	return s[:end]
+}
+
+func hasPrefixFold(s string, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
+
+func isCommentOnly(s string) bool {
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "/*") && strings.HasSuffix(line, "*/") {
+			continue
+		}
+		return false
+	}
+	return true
+}
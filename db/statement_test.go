@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestSplitMultiSQL(t *testing.T) {
+	type test struct {
+		dbType    Type
+		statement string
+		want      []string
+	}
+
+	tests := []test{
+		{
+			dbType:    Mysql,
+			statement: "CREATE TABLE t1 (id INT)",
+			want:      []string{"CREATE TABLE t1 (id INT)"},
+		},
+		{
+			dbType:    Mysql,
+			statement: "CREATE TABLE t1 (id INT);\nCREATE TABLE t2 (id INT);\n",
+			want:      []string{"CREATE TABLE t1 (id INT)", "CREATE TABLE t2 (id INT)"},
+		},
+		{
+			dbType:    Mysql,
+			statement: "INSERT INTO t1 VALUES ('a;b', \"c;d\", 'e''f;', 'g\\';');;",
+			want:      []string{"INSERT INTO t1 VALUES ('a;b', \"c;d\", 'e''f;', 'g\\';')"},
+		},
+		{
+			dbType:    Mysql,
+			statement: "-- create t1;\nCREATE TABLE `t;1` (id INT); /* done; */",
+			want:      []string{"-- create t1;\nCREATE TABLE `t;1` (id INT)"},
+		},
+		{
+			dbType:    Postgres,
+			statement: "CREATE FUNCTION f() RETURNS INT AS $body$ SELECT 1; $body$ LANGUAGE SQL; SELECT $1;",
+			want:      []string{"CREATE FUNCTION f() RETURNS INT AS $body$ SELECT 1; $body$ LANGUAGE SQL", "SELECT $1"},
+		},
+		{
+			dbType:    Mysql,
+			statement: "  ;\n-- comment only\n",
+			want:      nil,
+		},
+		// MySQL "#" comment, while "#" is an operator in PostgreSQL.
+		{
+			dbType:    Mysql,
+			statement: "# create t1;\nCREATE TABLE t1 (id INT); # done;",
+			want:      []string{"# create t1;\nCREATE TABLE t1 (id INT)"},
+		},
+		{
+			dbType:    Postgres,
+			statement: "SELECT data #> '{a,b}' FROM t1; SELECT data #>> '{a}', 1 # 2 FROM t1;",
+			want:      []string{"SELECT data #> '{a,b}' FROM t1", "SELECT data #>> '{a}', 1 # 2 FROM t1"},
+		},
+		// Backslash escapes.
+		{
+			dbType:    Mysql,
+			statement: "SELECT 'a\\\\'; SELECT \"b\\\";\";",
+			want:      []string{"SELECT 'a\\\\'", "SELECT \"b\\\";\""},
+		},
+		{
+			dbType:    Postgres,
+			statement: "SELECT 'C:\\'; SELECT E'it\\'s;'; SELECT e'\\\\';",
+			want:      []string{"SELECT 'C:\\'", "SELECT E'it\\'s;'", "SELECT e'\\\\'"},
+		},
+		{
+			dbType:    Sqlite,
+			statement: "SELECT 'C:\\'; SELECT 1",
+			want:      []string{"SELECT 'C:\\'", "SELECT 1"},
+		},
+		{
+			dbType:    Postgres,
+			statement: "SELECT name$1 FROM t1; SELECT 1",
+			want:      []string{"SELECT name$1 FROM t1", "SELECT 1"},
+		},
+		// MySQL stored programs.
+		{
+			dbType: Mysql,
+			statement: "CREATE TRIGGER t1_bi BEFORE INSERT ON t1 FOR EACH ROW\nBEGIN\n  IF NEW.id < 0 THEN\n    SET NEW.id = 0;\n  END IF;\n" +
+				"  CASE NEW.name WHEN '' THEN SET NEW.name = 'a'; ELSE BEGIN END; END CASE;\nEND;\nINSERT INTO t1 VALUES (1);",
+			want: []string{
+				"CREATE TRIGGER t1_bi BEFORE INSERT ON t1 FOR EACH ROW\nBEGIN\n  IF NEW.id < 0 THEN\n    SET NEW.id = 0;\n  END IF;\n" +
+					"  CASE NEW.name WHEN '' THEN SET NEW.name = 'a'; ELSE BEGIN END; END CASE;\nEND",
+				"INSERT INTO t1 VALUES (1)",
+			},
+		},
+		{
+			dbType:    Mysql,
+			statement: "DELIMITER $$\nCREATE PROCEDURE p1()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND$$\nDELIMITER ;\nCALL p1();",
+			want:      []string{"CREATE PROCEDURE p1()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND", "CALL p1()"},
+		},
+		{
+			dbType:    Mysql,
+			statement: "START TRANSACTION; BEGIN; UPDATE t1 SET id = 1; COMMIT;",
+			want:      []string{"START TRANSACTION", "BEGIN", "UPDATE t1 SET id = 1", "COMMIT"},
+		},
+	}
+
+	for _, tc := range tests {
+		got := SplitMultiSQL(tc.dbType, tc.statement)
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("dbType=%s statement=%q: expected %q, got %q", tc.dbType, tc.statement, tc.want, got)
+		}
+	}
+}
+
+func TestExecuteStatement(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	// Each connection has its own in-memory database.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("CREATE TABLE t1 (id INTEGER PRIMARY KEY, name TEXT UNIQUE)"); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	type test struct {
+		statement string
+		// The rows affected of each statement, or -1 if the statement fails.
+		wantRowsAffected []int64
+	}
+	tests := []test{
+		{
+			statement:        "INSERT INTO t1 (name) VALUES ('a;'), ('b');\n-- comment;\nUPDATE t1 SET name = name || '!';",
+			wantRowsAffected: []int64{2, 2},
+		},
+		// The statements after the failed one aren't executed.
+		{
+			statement:        "INSERT INTO t1 (name) VALUES ('c'); INSERT INTO t1 (name) VALUES ('c'); DELETE FROM t1",
+			wantRowsAffected: []int64{1, -1},
+		},
+	}
+	for _, tc := range tests {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			t.Fatalf("failed to begin transaction: %v", err)
+		}
+		var logList []*StatementLog
+		loggerCtx := WithStatementLogger(ctx, func(log *StatementLog) {
+			logList = append(logList, log)
+		})
+		err = executeStatement(loggerCtx, Sqlite, tx, tc.statement)
+		tx.Rollback()
+
+		wantStatementList := SplitMultiSQL(Sqlite, tc.statement)[:len(tc.wantRowsAffected)]
+		if len(logList) != 2*len(wantStatementList) {
+			t.Errorf("statement=%q: expected %d logs, got %+v", tc.statement, 2*len(wantStatementList), logList)
+			continue
+		}
+		for i, want := range wantStatementList {
+			start, done := logList[2*i], logList[2*i+1]
+			if start.Done || start.Statement != want || !done.Done || done.Statement != want {
+				t.Errorf("statement=%q: unexpected logs %+v and %+v for %q", tc.statement, start, done, want)
+			}
+			if tc.wantRowsAffected[i] < 0 {
+				if done.Err == nil || err == nil {
+					t.Errorf("statement=%q: expected error for %q", tc.statement, want)
+				}
+			} else if done.Err != nil || done.RowsAffected != tc.wantRowsAffected[i] {
+				t.Errorf("statement=%q: expected %d rows affected for %q, got %+v", tc.statement, tc.wantRowsAffected[i], want, done)
+			}
+		}
+	}
+}
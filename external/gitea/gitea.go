@@ -0,0 +1,357 @@
+package gitea
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	ApiPath = "api/v1"
+	// SignatureHeader is the header of the hex encoded HMAC-SHA256 signature of the webhook payload. Forgejo sends it
+	// as well for compatibility.
+	SignatureHeader = "X-Gitea-Signature"
+	// EventHeader is the header of the webhook event type.
+	EventHeader = "X-Gitea-Event"
+
+	// Gitea caps the page size to its MAX_RESPONSE_ITEMS setting, which is 50 by default.
+	repositoryPageSize = 50
+	tagPageSize        = 50
+)
+
+type GiteaWebhookType string
+
+const (
+	WebhookPush GiteaWebhookType = "push"
+)
+
+func (e GiteaWebhookType) String() string {
+	switch e {
+	case WebhookPush:
+		return "push"
+	}
+	return "UNKNOWN"
+}
+
+type WebhookInfo struct {
+	ID int `json:"id"`
+}
+
+type WebhookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Secret      string `json:"secret"`
+}
+
+type WebhookPost struct {
+	// Type must be "gitea".
+	Type   string        `json:"type"`
+	Active bool          `json:"active"`
+	Events []string      `json:"events"`
+	Config WebhookConfig `json:"config"`
+	// BranchFilter is the glob pattern of the branches to deliver the push event for, all branches if empty.
+	BranchFilter string `json:"branch_filter"`
+}
+
+type WebhookPatch struct {
+	Config       WebhookConfig `json:"config"`
+	BranchFilter string        `json:"branch_filter"`
+}
+
+type WebhookRepository struct {
+	ID       int    `json:"id"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type WebhookCommitAuthor struct {
+	Name string `json:"name"`
+}
+
+type WebhookCommit struct {
+	ID        string              `json:"id"`
+	Message   string              `json:"message"`
+	Timestamp string              `json:"timestamp"`
+	URL       string              `json:"url"`
+	Author    WebhookCommitAuthor `json:"author"`
+	AddedList []string            `json:"added"`
+}
+
+type WebhookPusher struct {
+	Login string `json:"login"`
+}
+
+type WebhookPushEvent struct {
+	Ref        string            `json:"ref"`
+	Repository WebhookRepository `json:"repository"`
+	Pusher     WebhookPusher     `json:"pusher"`
+	CommitList []WebhookCommit   `json:"commits"`
+	// HeadCommit is the commit the ref points to after the push, which is not in CommitList when pushing a tag of
+	// an existing commit.
+	HeadCommit *WebhookCommit `json:"head_commit"`
+}
+
+// Repository is a repository accessible to the user.
+type Repository struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type TagCommit struct {
+	SHA string `json:"sha"`
+}
+
+// Tag is a tag of the repository.
+type Tag struct {
+	Name   string    `json:"name"`
+	Commit TagCommit `json:"commit"`
+}
+
+// CommitFile is a file changed by a commit.
+type CommitFile struct {
+	Filename string `json:"filename"`
+	// Status is one of added, removed and modified.
+	Status string `json:"status"`
+}
+
+// Commit is a commit with the files it changes.
+type Commit struct {
+	SHA      string       `json:"sha"`
+	FileList []CommitFile `json:"files"`
+}
+
+// Compare is the result of comparing two commits.
+type Compare struct {
+	// CommitList is the commits from the base to the head, the newest first like git log.
+	CommitList []Commit `json:"commits"`
+}
+
+// OAuthToken is the response of exchanging the OAuth code for the token.
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresTs is computed from ExpiresIn when the token is exchanged.
+	ExpiresTs int64 `json:"-"`
+}
+
+// ValidateSignature returns true if signature is the X-Gitea-Signature header of the payload signed with secret.
+func ValidateSignature(secret string, payload []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ExchangeToken exchanges the code returned to redirectURL by the OAuth authorization for the access token. Gitea's
+// token endpoint doesn't allow cross-origin requests, so the browser can't do it.
+func ExchangeToken(instanceURL string, clientID string, clientSecret string, code string, redirectURL string) (*OAuthToken, error) {
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURL)
+	return requestToken(instanceURL, values)
+}
+
+// RefreshToken refreshes the expired access token.
+func RefreshToken(instanceURL string, clientID string, clientSecret string, refreshToken string) (*OAuthToken, error) {
+	values := url.Values{}
+	values.Set("grant_type", "refresh_token")
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("refresh_token", refreshToken)
+	return requestToken(instanceURL, values)
+}
+
+func requestToken(instanceURL string, values url.Values) (*OAuthToken, error) {
+	url := fmt.Sprintf("%s/login/oauth/access_token", instanceURL)
+	req, err := http.NewRequest("POST",
+		url, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct POST %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed POST %v, status code: %d, status: %s", url, resp.StatusCode, resp.Status)
+	}
+	token := &OAuthToken{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OAuth token response (%w)", err)
+	}
+	if token.ExpiresIn > 0 {
+		token.ExpiresTs = time.Now().Unix() + token.ExpiresIn
+	}
+	return token, nil
+}
+
+// FetchRepositoryList returns all the repositories the user of token can access.
+func FetchRepositoryList(instanceURL string, token string) ([]Repository, error) {
+	var list []Repository
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("user/repos?limit=%d&page=%d", repositoryPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Repository
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("failed to fetch repository list, status code: %d, status: %s", resp.StatusCode, resp.Status)
+			}
+			return json.NewDecoder(resp.Body).Decode(&pageList)
+		}()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pageList...)
+		if len(pageList) < repositoryPageSize {
+			return list, nil
+		}
+	}
+}
+
+// FetchTagList returns all the tags of the repository fullName.
+func FetchTagList(instanceURL string, fullName string, token string) ([]Tag, error) {
+	var list []Tag
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/tags?limit=%d&page=%d", fullName, tagPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Tag
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("failed to fetch tag list, status code: %d, status: %s", resp.StatusCode, resp.Status)
+			}
+			return json.NewDecoder(resp.Body).Decode(&pageList)
+		}()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pageList...)
+		if len(pageList) < tagPageSize {
+			return list, nil
+		}
+	}
+}
+
+// FetchCompareCommitList returns the commits from the commit base to the commit head of the repository fullName,
+// the newest first.
+func FetchCompareCommitList(instanceURL string, fullName string, base string, head string, token string) ([]Commit, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/compare/%s...%s", fullName, url.PathEscape(base), url.PathEscape(head)), token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to compare %s...%s, status code: %d, status: %s", base, head, resp.StatusCode, resp.Status)
+	}
+	compare := &Compare{}
+	if err := json.NewDecoder(resp.Body).Decode(compare); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal compare (%w)", err)
+	}
+	return compare.CommitList, nil
+}
+
+// FetchCommit returns the commit of the repository fullName.
+func FetchCommit(instanceURL string, fullName string, commitID string, token string) (*Commit, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/git/commits/%s", fullName, url.PathEscape(commitID)), token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch commit %s, status code: %d, status: %s", commitID, resp.StatusCode, resp.Status)
+	}
+	commit := &Commit{}
+	if err := json.NewDecoder(resp.Body).Decode(commit); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal commit (%w)", err)
+	}
+	return commit, nil
+}
+
+// ReadFileContent returns the raw content of the file at ref in the repository fullName.
+func ReadFileContent(instanceURL string, fullName string, filePath string, ref string, token string) ([]byte, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/raw/%s?ref=%s", fullName, escapePath(filePath), url.QueryEscape(ref)), token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to read file %s, status code: %d, status: %s", filePath, resp.StatusCode, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// escapePath escapes each segment of the file path.
+func escapePath(filePath string) string {
+	segmentList := strings.Split(filePath, "/")
+	for i, segment := range segmentList {
+		segmentList[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segmentList, "/")
+}
+
+func POST(instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	return request("POST", instanceURL, resourcePath, token, body)
+}
+
+func GET(instanceURL string, resourcePath string, token string) (*http.Response, error) {
+	return request("GET", instanceURL, resourcePath, token, nil)
+}
+
+func PATCH(instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	return request("PATCH", instanceURL, resourcePath, token, body)
+}
+
+func DELETE(instanceURL string, resourcePath string, token string) (*http.Response, error) {
+	return request("DELETE", instanceURL, resourcePath, token, nil)
+}
+
+func request(method string, instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s/%s", instanceURL, ApiPath, resourcePath)
+	req, err := http.NewRequest(method,
+		url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s %v (%w)", method, url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Add("Authorization", "token "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed %s %v (%w)", method, url, err)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,155 @@
+package gitea
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestValidateSignature(t *testing.T) {
+	payload := []byte(`{"ref":"refs/heads/main"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(payload)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		secret    string
+		payload   []byte
+		signature string
+		want      bool
+	}{
+		{"secret", payload, signature, true},
+		{"another", payload, signature, false},
+		{"secret", []byte(`{"ref":"refs/heads/dev"}`), signature, false},
+		{"secret", payload, "sha256=" + signature, false},
+		{"secret", payload, "zz", false},
+		{"secret", payload, "", false},
+	}
+	for i, test := range tests {
+		if got := ValidateSignature(test.secret, test.payload, test.signature); got != test.want {
+			t.Errorf("test %d: ValidateSignature() = %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestAPI(t *testing.T) {
+	hookList := []WebhookPost{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login/oauth/access_token" {
+			if err := r.ParseForm(); err != nil || r.Form.Get("grant_type") != "authorization_code" || r.Form.Get("client_id") != "client" || r.Form.Get("client_secret") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.Form.Get("code") != "code" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"invalid_grant","error_description":"client is not authorized"}`)
+				return
+			}
+			fmt.Fprint(w, `{"access_token":"token","token_type":"bearer","expires_in":3600,"refresh_token":"refresh"}`)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "token token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/user/repos":
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			// 2 full pages and 1 partial page.
+			count := repositoryPageSize
+			if page == 3 {
+				count = 1
+			}
+			list := []Repository{}
+			for i := 0; i < count && page <= 3; i++ {
+				id := (page-1)*repositoryPageSize + i
+				list = append(list, Repository{ID: id, Name: fmt.Sprintf("repo%d", id), FullName: fmt.Sprintf("org/repo%d", id)})
+			}
+			json.NewEncoder(w).Encode(list)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/repos/org/repo/raw/db/prod/v1__init schema.sql":
+			if r.URL.Query().Get("ref") != "abc" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, "CREATE TABLE t (id INT);")
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/repos/org/repo/hooks":
+			body, _ := ioutil.ReadAll(r.Body)
+			hook := WebhookPost{}
+			if err := json.Unmarshal(body, &hook); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			hookList = append(hookList, hook)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"id":%d}`, len(hookList))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	token, err := ExchangeToken(server.URL, "client", "secret", "code", "http://localhost/oauth/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.AccessToken != "token" || token.RefreshToken != "refresh" || token.ExpiresTs == 0 {
+		t.Errorf("unexpected token %+v", token)
+	}
+	if _, err := ExchangeToken(server.URL, "client", "secret", "expired", "http://localhost/oauth/callback"); err == nil {
+		t.Errorf("ExchangeToken() with bad code got no error")
+	}
+
+	repositoryList, err := FetchRepositoryList(server.URL, token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repositoryList) != 2*repositoryPageSize+1 {
+		t.Errorf("got %d repositories, want %d", len(repositoryList), 2*repositoryPageSize+1)
+	}
+	if _, err := FetchRepositoryList(server.URL, "invalid"); err == nil {
+		t.Errorf("FetchRepositoryList() with invalid token got no error")
+	}
+
+	content, err := ReadFileContent(server.URL, "org/repo", "db/prod/v1__init schema.sql", "abc", token.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "CREATE TABLE t (id INT);" {
+		t.Errorf("content = %q", content)
+	}
+	if _, err := ReadFileContent(server.URL, "org/repo", "db/prod/v2__missing.sql", "abc", token.AccessToken); err == nil {
+		t.Errorf("ReadFileContent() of missing file got no error")
+	}
+
+	body, err := json.Marshal(WebhookPost{
+		Type:         "gitea",
+		Active:       true,
+		Events:       []string{string(WebhookPush)},
+		Config:       WebhookConfig{URL: "http://localhost/hook/gitea/1", ContentType: "json", Secret: "secret"},
+		BranchFilter: "main",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := POST(server.URL, "repos/org/repo/hooks", token.AccessToken, bytes.NewBuffer(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	webhookInfo := &WebhookInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+		t.Fatal(err)
+	}
+	if webhookInfo.ID != 1 || len(hookList) != 1 || hookList[0].Config.Secret != "secret" || hookList[0].BranchFilter != "main" {
+		t.Errorf("unexpected webhook %d, %+v", webhookInfo.ID, hookList)
+	}
+}
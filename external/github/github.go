@@ -0,0 +1,365 @@
+package github
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// ApiPath is the path of the REST API on a GitHub Enterprise Server instance.
+	ApiPath = "api/v3"
+	// SignatureHeader is the header of the HMAC-SHA256 signature of the webhook payload.
+	SignatureHeader = "X-Hub-Signature-256"
+	// EventHeader is the header of the webhook event type.
+	EventHeader = "X-GitHub-Event"
+
+	repositoryPageSize = 100
+	tagPageSize        = 100
+)
+
+type GitHubWebhookType string
+
+const (
+	WebhookPush GitHubWebhookType = "push"
+	// WebhookPing is sent when the webhook is created.
+	WebhookPing GitHubWebhookType = "ping"
+)
+
+func (e GitHubWebhookType) String() string {
+	switch e {
+	case WebhookPush:
+		return "push"
+	case WebhookPing:
+		return "ping"
+	}
+	return "UNKNOWN"
+}
+
+// ApiURL returns the REST API URL of the GitHub instance, which is api.github.com for github.com and
+// {{instanceURL}}/api/v3 for GitHub Enterprise Server.
+func ApiURL(instanceURL string) string {
+	if u, err := url.Parse(instanceURL); err == nil && (u.Host == "github.com" || u.Host == "www.github.com") {
+		return "https://api.github.com"
+	}
+	return fmt.Sprintf("%s/%s", instanceURL, ApiPath)
+}
+
+type WebhookInfo struct {
+	ID int `json:"id"`
+}
+
+type WebhookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Secret      string `json:"secret"`
+	// InsecureSSL is "1" to skip verifying the certificate of URL, and "0" to verify it.
+	InsecureSSL string `json:"insecure_ssl"`
+}
+
+type WebhookPost struct {
+	// Name must be "web".
+	Name   string        `json:"name"`
+	Active bool          `json:"active"`
+	Events []string      `json:"events"`
+	Config WebhookConfig `json:"config"`
+}
+
+type WebhookRepository struct {
+	ID       int    `json:"id"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type WebhookCommitAuthor struct {
+	Name string `json:"name"`
+}
+
+type WebhookCommit struct {
+	ID        string              `json:"id"`
+	Message   string              `json:"message"`
+	Timestamp string              `json:"timestamp"`
+	URL       string              `json:"url"`
+	Author    WebhookCommitAuthor `json:"author"`
+	AddedList []string            `json:"added"`
+}
+
+type WebhookPusher struct {
+	Name string `json:"name"`
+}
+
+type WebhookPushEvent struct {
+	Ref        string            `json:"ref"`
+	Repository WebhookRepository `json:"repository"`
+	Pusher     WebhookPusher     `json:"pusher"`
+	CommitList []WebhookCommit   `json:"commits"`
+	// HeadCommit is the commit the ref points to after the push, which is not in CommitList when pushing a tag of
+	// an existing commit.
+	HeadCommit *WebhookCommit `json:"head_commit"`
+}
+
+// Repository is a repository accessible to the user.
+type Repository struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+}
+
+type TagCommit struct {
+	SHA string `json:"sha"`
+}
+
+// Tag is a tag of the repository.
+type Tag struct {
+	Name   string    `json:"name"`
+	Commit TagCommit `json:"commit"`
+}
+
+// CommitFile is a file changed by a commit or between two commits.
+type CommitFile struct {
+	Filename string `json:"filename"`
+	// Status is one of added, removed, modified, renamed, copied, changed and unchanged.
+	Status string `json:"status"`
+}
+
+// CommitFileList is the files changed in the response of getting a commit or comparing two commits.
+type CommitFileList struct {
+	FileList []CommitFile `json:"files"`
+}
+
+// OAuthToken is the response of exchanging the OAuth code for the token. The token of a GitHub OAuth App doesn't
+// expire, so there is no refresh token.
+type OAuthToken struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	Scope            string `json:"scope"`
+	ExpiresIn        int64  `json:"expires_in"`
+	RefreshToken     string `json:"refresh_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	// ExpiresTs is computed from ExpiresIn when the token is exchanged.
+	ExpiresTs int64 `json:"-"`
+}
+
+// ValidateSignature returns true if signature is the X-Hub-Signature-256 header of the payload signed with secret.
+func ValidateSignature(secret string, payload []byte, signature string) bool {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ExchangeToken exchanges the code returned to redirectURL by the OAuth authorization for the access token.
+func ExchangeToken(instanceURL string, clientID string, clientSecret string, code string, redirectURL string) (*OAuthToken, error) {
+	values := url.Values{}
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURL)
+	return requestToken(instanceURL, values)
+}
+
+// RefreshToken refreshes the expired access token, which only applies to the GitHub App with the token expiration
+// enabled.
+func RefreshToken(instanceURL string, clientID string, clientSecret string, refreshToken string) (*OAuthToken, error) {
+	values := url.Values{}
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("grant_type", "refresh_token")
+	values.Set("refresh_token", refreshToken)
+	return requestToken(instanceURL, values)
+}
+
+func requestToken(instanceURL string, values url.Values) (*OAuthToken, error) {
+	url := fmt.Sprintf("%s/login/oauth/access_token", instanceURL)
+	req, err := http.NewRequest("POST",
+		url, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct POST %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed POST %v, status code: %d, status: %s", url, resp.StatusCode, resp.Status)
+	}
+	token := &OAuthToken{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OAuth token response (%w)", err)
+	}
+	// GitHub responds the error with 200.
+	if token.Error != "" {
+		return nil, fmt.Errorf("failed to exchange OAuth token: %s, %s", token.Error, token.ErrorDescription)
+	}
+	if token.ExpiresIn > 0 {
+		token.ExpiresTs = time.Now().Unix() + token.ExpiresIn
+	}
+	return token, nil
+}
+
+// FetchRepositoryList returns all the repositories the user of token can access.
+func FetchRepositoryList(instanceURL string, token string) ([]Repository, error) {
+	var list []Repository
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("user/repos?per_page=%d&page=%d", repositoryPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Repository
+		err = decodeResponse(resp, &pageList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch repository list (%w)", err)
+		}
+		list = append(list, pageList...)
+		if len(pageList) < repositoryPageSize {
+			return list, nil
+		}
+	}
+}
+
+// FetchTagList returns all the tags of the repository fullName.
+func FetchTagList(instanceURL string, fullName string, token string) ([]Tag, error) {
+	var list []Tag
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/tags?per_page=%d&page=%d", fullName, tagPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Tag
+		err = decodeResponse(resp, &pageList)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tag list (%w)", err)
+		}
+		list = append(list, pageList...)
+		if len(pageList) < tagPageSize {
+			return list, nil
+		}
+	}
+}
+
+// FetchCompareFileList returns the files changed from the commit base to the commit head of the repository fullName.
+// GitHub returns at most 300 files.
+func FetchCompareFileList(instanceURL string, fullName string, base string, head string, token string) ([]CommitFile, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/compare/%s...%s", fullName, url.PathEscape(base), url.PathEscape(head)), token)
+	if err != nil {
+		return nil, err
+	}
+	fileList := &CommitFileList{}
+	if err := decodeResponse(resp, fileList); err != nil {
+		return nil, fmt.Errorf("failed to compare %s...%s (%w)", base, head, err)
+	}
+	return fileList.FileList, nil
+}
+
+// FetchCommitFileList returns the files changed by the commit of the repository fullName. GitHub returns at most 300
+// files.
+func FetchCommitFileList(instanceURL string, fullName string, commitID string, token string) ([]CommitFile, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("repos/%s/commits/%s", fullName, url.PathEscape(commitID)), token)
+	if err != nil {
+		return nil, err
+	}
+	fileList := &CommitFileList{}
+	if err := decodeResponse(resp, fileList); err != nil {
+		return nil, fmt.Errorf("failed to fetch commit %s (%w)", commitID, err)
+	}
+	return fileList.FileList, nil
+}
+
+// ReadFileContent returns the raw content of the file at ref in the repository fullName.
+func ReadFileContent(instanceURL string, fullName string, filePath string, ref string, token string) ([]byte, error) {
+	url := fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s", ApiURL(instanceURL), fullName, escapePath(filePath), url.QueryEscape(ref))
+	req, err := http.NewRequest("GET",
+		url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct GET %v (%w)", url, err)
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	req.Header.Add("Authorization", "token "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed GET %v (%w)", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed GET %v, status code: %d, status: %s", url, resp.StatusCode, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// escapePath escapes each segment of the file path.
+func escapePath(filePath string) string {
+	segmentList := strings.Split(filePath, "/")
+	for i, segment := range segmentList {
+		segmentList[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segmentList, "/")
+}
+
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func POST(instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	return request("POST", instanceURL, resourcePath, token, body)
+}
+
+func GET(instanceURL string, resourcePath string, token string) (*http.Response, error) {
+	return request("GET", instanceURL, resourcePath, token, nil)
+}
+
+func PATCH(instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	return request("PATCH", instanceURL, resourcePath, token, body)
+}
+
+func DELETE(instanceURL string, resourcePath string, token string) (*http.Response, error) {
+	return request("DELETE", instanceURL, resourcePath, token, nil)
+}
+
+func request(method string, instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
+	url := fmt.Sprintf("%s/%s", ApiURL(instanceURL), resourcePath)
+	req, err := http.NewRequest(method,
+		url, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct %s %v (%w)", method, url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Add("Authorization", "token "+token)
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed %s %v (%w)", method, url, err)
+	}
+
+	return resp, nil
+}
@@ -1,30 +1,51 @@
 package gitlab
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
 )
 
 const (
 	ApiPath             = "api/v4"
 	SECRET_TOKEN_LENGTH = 16
+
+	projectPageSize = 100
+	tagPageSize     = 100
+	diffPageSize    = 100
 )
 
 type GitLabWebhookType string
 
 const (
-	WebhookPush GitLabWebhookType = "push"
+	WebhookPush         GitLabWebhookType = "push"
+	WebhookTagPush      GitLabWebhookType = "tag_push"
+	WebhookMergeRequest GitLabWebhookType = "merge_request"
 )
 
 func (e GitLabWebhookType) String() string {
 	switch e {
 	case WebhookPush:
 		return "push"
+	case WebhookTagPush:
+		return "tag_push"
+	case WebhookMergeRequest:
+		return "merge_request"
 	}
 	return "UNKNOWN"
 }
 
+// Commit status states.
+const (
+	CommitStatusPending = "pending"
+	CommitStatusSuccess = "success"
+	CommitStatusFailed  = "failed"
+)
+
 type WebhookInfo struct {
 	ID int `json:"id"`
 }
@@ -34,20 +55,27 @@ type WebhookPost struct {
 	SecretToken string `json:"token"`
 	// This is set to true
 	PushEvents bool `json:"push_events"`
+	// This is set to true if the repository creates the issues on pushing the release tags.
+	TagPushEvents bool `json:"tag_push_events"`
+	// This is set to true to review the SQL statically with the SQL review rules before the MR is merged.
 	// For now, there is no native dry run DDL support in mysql/postgres. One may wonder if we could wrap the DDL
 	// in a transaction and just not commit at the end, unfortunately there are side effects which are hard to control.
 	// See https://www.postgresql.org/message-id/CAMsr%2BYGiYQ7PYvYR2Voio37YdCpp79j5S%2BcmgVJMOLM2LnRQcA%40mail.gmail.com
-	// So we can't possibly display useful info when reviewing a MR, thus we don't enable this event.
 	// Saying that, delivering a souding dry run solution would be great and hopefully we can achieve that one day.
-	// MergeRequestsEvents  bool   `json:"merge_requests_events"`
+	MergeRequestsEvents    bool   `json:"merge_requests_events"`
 	PushEventsBranchFilter string `json:"push_events_branch_filter"`
-	// TODO(tianzhou): This is set to false, be lax to not enable_ssl_verification
+	// This is set to true to verify the certificate of URL.
 	EnableSSLVerification bool `json:"enable_ssl_verification"`
 }
 
 type WebhookPut struct {
-	URL                    string `json:"url"`
+	URL           string `json:"url"`
+	TagPushEvents bool   `json:"tag_push_events"`
+	// This is set to true to enable the merge request events for the webhooks created before they're reviewed.
+	MergeRequestsEvents    bool   `json:"merge_requests_events"`
 	PushEventsBranchFilter string `json:"push_events_branch_filter"`
+	// This is set to true to enable the SSL verification for the webhooks created without it.
+	EnableSSLVerification bool `json:"enable_ssl_verification"`
 }
 
 type WebhookProject struct {
@@ -73,9 +101,286 @@ type WebhookCommit struct {
 type WebhookPushEvent struct {
 	ObjectKind GitLabWebhookType `json:"object_kind"`
 	Ref        string            `json:"ref"`
-	AuthorName string            `json:"user_name"`
-	Project    WebhookProject    `json:"project"`
-	CommitList []WebhookCommit   `json:"commits"`
+	// CheckoutSHA is the commit the ref points to after the push, which is not in CommitList when pushing a tag of
+	// an existing commit.
+	CheckoutSHA string `json:"checkout_sha"`
+	// Message is the message of the annotated tag for the tag push event.
+	Message    string          `json:"message"`
+	AuthorName string          `json:"user_name"`
+	Project    WebhookProject  `json:"project"`
+	CommitList []WebhookCommit `json:"commits"`
+}
+
+type WebhookMergeRequestLastCommit struct {
+	ID string `json:"id"`
+}
+
+type WebhookMergeRequestAttributes struct {
+	IID             int    `json:"iid"`
+	Title           string `json:"title"`
+	URL             string `json:"url"`
+	SourceBranch    string `json:"source_branch"`
+	TargetBranch    string `json:"target_branch"`
+	SourceProjectID int    `json:"source_project_id"`
+	// Action is one of open, close, reopen, update, approved, unapproved, approval, unapproval and merge.
+	Action string `json:"action"`
+	// OldRev is set if the update pushes new commits.
+	OldRev     string                        `json:"oldrev"`
+	LastCommit WebhookMergeRequestLastCommit `json:"last_commit"`
+}
+
+type WebhookUser struct {
+	Name string `json:"name"`
+}
+
+type WebhookMergeRequestEvent struct {
+	ObjectKind       GitLabWebhookType             `json:"object_kind"`
+	User             WebhookUser                   `json:"user"`
+	Project          WebhookProject                `json:"project"`
+	ObjectAttributes WebhookMergeRequestAttributes `json:"object_attributes"`
+}
+
+// MergeRequestChange is a file changed by the merge request.
+type MergeRequestChange struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+type MergeRequestChangeList struct {
+	ChangeList []MergeRequestChange `json:"changes"`
+}
+
+// Diff is a file changed by a commit or between two commits.
+type Diff struct {
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// Compare is the result of comparing two commits.
+type Compare struct {
+	DiffList []Diff `json:"diffs"`
+}
+
+type TagCommit struct {
+	ID string `json:"id"`
+}
+
+// Tag is a tag of the project.
+type Tag struct {
+	Name   string    `json:"name"`
+	Commit TagCommit `json:"commit"`
+}
+
+type MergeRequestNotePost struct {
+	Body string `json:"body"`
+}
+
+type CommitStatusPost struct {
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
+// Project is a project the user is a member of.
+type Project struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	FullPath string `json:"path_with_namespace"`
+	WebURL   string `json:"web_url"`
+}
+
+// FetchProjectList returns all the projects the user of token is a member of.
+func FetchProjectList(instanceURL string, token string) ([]Project, error) {
+	var list []Project
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("projects?membership=true&simple=true&per_page=%d&page=%d", projectPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Project
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("failed to fetch project list, status code: %d, status: %s", resp.StatusCode, resp.Status)
+			}
+			return json.NewDecoder(resp.Body).Decode(&pageList)
+		}()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pageList...)
+		if len(pageList) < projectPageSize {
+			return list, nil
+		}
+	}
+}
+
+// FetchMergeRequestChangeList returns the files changed by the merge request iid of the project.
+func FetchMergeRequestChangeList(instanceURL string, projectID string, iid string, token string) ([]MergeRequestChange, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("projects/%s/merge_requests/%s/changes", projectID, iid), token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch merge request changes, status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	changeList := &MergeRequestChangeList{}
+	if err := json.NewDecoder(resp.Body).Decode(changeList); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal merge request changes (%w)", err)
+	}
+	return changeList.ChangeList, nil
+}
+
+// FetchTagList returns all the tags of the project.
+func FetchTagList(instanceURL string, projectID string, token string) ([]Tag, error) {
+	var list []Tag
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("projects/%s/repository/tags?per_page=%d&page=%d", projectID, tagPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Tag
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("failed to fetch tag list, status code: %d, status: %s", resp.StatusCode, resp.Status)
+			}
+			return json.NewDecoder(resp.Body).Decode(&pageList)
+		}()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pageList...)
+		if len(pageList) < tagPageSize {
+			return list, nil
+		}
+	}
+}
+
+// FetchCompareDiffList returns the files changed from the commit from to the commit to of the project.
+func FetchCompareDiffList(instanceURL string, projectID string, from string, to string, token string) ([]Diff, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("projects/%s/repository/compare?from=%s&to=%s", projectID, url.QueryEscape(from), url.QueryEscape(to)), token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to compare %s...%s, status code: %d, status: %s", from, to, resp.StatusCode, resp.Status)
+	}
+	compare := &Compare{}
+	if err := json.NewDecoder(resp.Body).Decode(compare); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal compare (%w)", err)
+	}
+	return compare.DiffList, nil
+}
+
+// FetchCommitDiffList returns the files changed by the commit of the project.
+func FetchCommitDiffList(instanceURL string, projectID string, commitID string, token string) ([]Diff, error) {
+	var list []Diff
+	for page := 1; ; page++ {
+		resp, err := GET(instanceURL, fmt.Sprintf("projects/%s/repository/commits/%s/diff?per_page=%d&page=%d", projectID, url.PathEscape(commitID), diffPageSize, page), token)
+		if err != nil {
+			return nil, err
+		}
+		var pageList []Diff
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("failed to fetch commit %s diff, status code: %d, status: %s", commitID, resp.StatusCode, resp.Status)
+			}
+			return json.NewDecoder(resp.Body).Decode(&pageList)
+		}()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, pageList...)
+		if len(pageList) < diffPageSize {
+			return list, nil
+		}
+	}
+}
+
+// OAuthToken is the response of exchanging the OAuth code for the token.
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	// ExpiresTs is computed from ExpiresIn when the token is exchanged, it's 0 if the token doesn't expire.
+	ExpiresTs int64 `json:"-"`
+}
+
+// ExchangeToken exchanges the code returned to redirectURL by the OAuth authorization for the access token.
+func ExchangeToken(instanceURL string, clientID string, clientSecret string, code string, redirectURL string) (*OAuthToken, error) {
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("code", code)
+	values.Set("redirect_uri", redirectURL)
+	return requestToken(instanceURL, values)
+}
+
+// RefreshToken refreshes the expired access token. GitLab requires the same redirectURL as the authorization.
+func RefreshToken(instanceURL string, clientID string, clientSecret string, refreshToken string, redirectURL string) (*OAuthToken, error) {
+	values := url.Values{}
+	values.Set("grant_type", "refresh_token")
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("refresh_token", refreshToken)
+	values.Set("redirect_uri", redirectURL)
+	return requestToken(instanceURL, values)
+}
+
+func requestToken(instanceURL string, values url.Values) (*OAuthToken, error) {
+	url := fmt.Sprintf("%s/oauth/token", instanceURL)
+	req, err := http.NewRequest("POST",
+		url, strings.NewReader(values.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct POST %v (%w)", url, err)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed POST %v (%w)", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed POST %v, status code: %d, status: %s", url, resp.StatusCode, resp.Status)
+	}
+	token := &OAuthToken{}
+	if err := json.NewDecoder(resp.Body).Decode(token); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal OAuth token response (%w)", err)
+	}
+	if token.ExpiresIn > 0 {
+		token.ExpiresTs = time.Now().Unix() + token.ExpiresIn
+	}
+	return token, nil
+}
+
+// ReadFileContent returns the raw content of the file at ref in the project.
+func ReadFileContent(instanceURL string, projectID string, filePath string, ref string, token string) ([]byte, error) {
+	resp, err := GET(instanceURL, fmt.Sprintf("projects/%s/repository/files/%s/raw?ref=%s", projectID, url.QueryEscape(filePath), url.QueryEscape(ref)), token)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to read file %s, status code: %d, status: %s", filePath, resp.StatusCode, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
 }
 
 func POST(instanceURL string, resourcePath string, token string, body io.Reader) (*http.Response, error) {
@@ -0,0 +1,239 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PART_SIZE is the size of each part of the multipart upload, S3 requires at least 5 MiB except the last part.
+	PART_SIZE = 8 << 20
+	// DEFAULT_REGION is used if the region is not set, MinIO and most S3-compatible storages accept it.
+	DEFAULT_REGION = "us-east-1"
+)
+
+// Config is the config of a bucket on an S3-compatible object storage.
+type Config struct {
+	// Endpoint is the URL of the storage, e.g. https://s3.us-west-2.amazonaws.com or http://localhost:9000 for MinIO.
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// UsePathStyle addresses the bucket as {endpoint}/{bucket}/{key} instead of {bucket}.{endpoint host}/{key}.
+	// It's usually required by the S3-compatible storages such as MinIO.
+	UsePathStyle bool
+}
+
+// Client is a minimal S3 client supporting streaming upload and download.
+type Client struct {
+	config   Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewClient returns a client of the bucket specified by config.
+func NewClient(config Config) (*Client, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3: bucket is required")
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("s3: invalid endpoint %q: %w", config.Endpoint, err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint %q, expect http(s)://host[:port]", config.Endpoint)
+	}
+	if config.Region == "" {
+		config.Region = DEFAULT_REGION
+	}
+	return &Client{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{},
+	}, nil
+}
+
+// Error is the error response of S3.
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("s3: %s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// Upload uploads the content read from r to the object key. The content is uploaded in parts of PART_SIZE so that
+// the content of unknown size can be streamed, and the upload is aborted if reading r fails.
+func (c *Client) Upload(ctx context.Context, key string, r io.Reader) error {
+	buf := make([]byte, PART_SIZE)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	// Use a single request for the small content.
+	if n < PART_SIZE {
+		_, err := c.do(ctx, http.MethodPut, key, nil, buf[:n])
+		return err
+	}
+
+	uploadID, err := c.createMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err := c.uploadParts(ctx, key, uploadID, buf, r); err != nil {
+		if _, abortErr := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil); abortErr != nil {
+			return fmt.Errorf("%w, and failed to abort the multipart upload: %v", err, abortErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// Download returns the content of the object key, and the caller should close it after reading.
+func (c *Client) Download(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete deletes the object key, it succeeds if the object doesn't exist.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, http.MethodDelete, key, nil, nil)
+	return err
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+func (c *Client) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	body, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	result := &initiateMultipartUploadResult{}
+	if err := xml.Unmarshal(body, result); err != nil {
+		return "", fmt.Errorf("s3: invalid initiate multipart upload response: %w", err)
+	}
+	return result.UploadID, nil
+}
+
+// uploadParts uploads the first part in buf and the rest read from r, then completes the multipart upload.
+func (c *Client) uploadParts(ctx context.Context, key string, uploadID string, buf []byte, r io.Reader) error {
+	var partList []completedPart
+	part := buf
+	for len(part) > 0 {
+		partNumber := len(partList) + 1
+		query := url.Values{
+			"partNumber": {strconv.Itoa(partNumber)},
+			"uploadId":   {uploadID},
+		}
+		resp, err := c.send(ctx, http.MethodPut, key, query, part)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		partList = append(partList, completedPart{
+			PartNumber: partNumber,
+			ETag:       resp.Header.Get("ETag"),
+		})
+
+		n, err := io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		part = buf[:n]
+	}
+
+	complete, err := xml.Marshal(completeMultipartUpload{Parts: partList})
+	if err != nil {
+		return err
+	}
+	body, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, complete)
+	if err != nil {
+		return err
+	}
+	// CompleteMultipartUpload may fail after responding 200 OK, in which case the body is an error.
+	if bytes.Contains(body, []byte("<Error>")) {
+		s3Err := &Error{StatusCode: http.StatusOK}
+		if err := xml.Unmarshal(body, s3Err); err != nil {
+			return fmt.Errorf("s3: invalid complete multipart upload response: %w", err)
+		}
+		return s3Err
+	}
+	return nil
+}
+
+// do sends the request and returns the response body.
+func (c *Client) do(ctx context.Context, method string, key string, query url.Values, payload []byte) ([]byte, error) {
+	resp, err := c.send(ctx, method, key, query, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// send sends the signed request, and returns the response if the status is 2xx. The caller should close the
+// response body.
+func (c *Client) send(ctx context.Context, method string, key string, query url.Values, payload []byte) (*http.Response, error) {
+	u := *c.endpoint
+	key = strings.TrimPrefix(key, "/")
+	if c.config.UsePathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + c.config.Bucket + "/" + key
+	} else {
+		u.Host = c.config.Bucket + "." + u.Host
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	if query != nil {
+		// url.Values.Encode renders "uploads=" for the empty value, which S3 accepts.
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(payload))
+	signV4(req, c.config.Region, c.config.AccessKeyID, c.config.SecretAccessKey, hashHex(payload), time.Now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		s3Err := &Error{StatusCode: resp.StatusCode}
+		body, _ := ioutil.ReadAll(resp.Body)
+		if err := xml.Unmarshal(body, s3Err); err != nil || s3Err.Code == "" {
+			s3Err.Code = http.StatusText(resp.StatusCode)
+			s3Err.Message = string(body)
+		}
+		return nil, s3Err
+	}
+	return resp, nil
+}
@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestUploadDownload(t *testing.T) {
+	objects := make(map[string][]byte)
+	parts := make(map[string][]byte)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query["uploads"] != nil:
+			fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>u1</UploadId></InitiateMultipartUploadResult>")
+		case r.Method == http.MethodPut && query.Get("uploadId") == "u1":
+			parts[r.URL.Path] = append(parts[r.URL.Path], body...)
+			w.Header().Set("ETag", `"etag-`+query.Get("partNumber")+`"`)
+		case r.Method == http.MethodPost && query.Get("uploadId") == "u1":
+			complete := &completeMultipartUpload{}
+			if err := xml.Unmarshal(body, complete); err != nil || len(complete.Parts) != 2 || complete.Parts[1].ETag != `"etag-2"` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			objects[r.URL.Path] = parts[r.URL.Path]
+		case r.Method == http.MethodPut:
+			objects[r.URL.Path] = body
+		case r.Method == http.MethodGet:
+			object, ok := objects[r.URL.Path]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>")
+				return
+			}
+			w.Write(object)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{
+		Endpoint:        server.URL,
+		Bucket:          "bucket",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		UsePathStyle:    true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, size := range []int{10, PART_SIZE + 10} {
+		content := bytes.Repeat([]byte("a"), size)
+		key := fmt.Sprintf("backup/%d.sql", size)
+		if err := client.Upload(ctx, key, bytes.NewReader(content)); err != nil {
+			t.Fatalf("Upload(%d) got error: %v", size, err)
+		}
+		r, err := client.Download(ctx, key)
+		if err != nil {
+			t.Fatalf("Download(%d) got error: %v", size, err)
+		}
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil || !bytes.Equal(got, content) {
+			t.Errorf("Download(%d) got %d bytes, error %v", size, len(got), err)
+		}
+	}
+
+	if _, err := client.Download(ctx, "missing"); err == nil || err.(*Error).Code != "NoSuchKey" {
+		t.Errorf("expected NoSuchKey error, got %v", err)
+	}
+}
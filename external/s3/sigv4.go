@@ -0,0 +1,146 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	sigV4Service   = "s3"
+	// amzDateFormat is the ISO 8601 basic format used by x-amz-date.
+	amzDateFormat = "20060102T150405Z"
+	// EmptyPayloadHash is the hex encoded SHA256 of the empty payload.
+	EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// signV4 signs req with AWS Signature Version 4 in the Authorization header. payloadHash is the hex encoded SHA256
+// of the request body. All the headers set on req are signed, along with the host.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signV4(req *http.Request, region string, accessKeyID string, secretAccessKey string, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(amzDateFormat)
+	date := amzDate[:8]
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	canonicalHeaders, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, region, sigV4Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, sigV4Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalHeaders returns the canonical headers and the signed headers of req.
+func canonicalHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for name, values := range req.Header {
+		trimmed := make([]string, 0, len(values))
+		for _, v := range values {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		headers[strings.ToLower(name)] = strings.Join(trimmed, ",")
+	}
+
+	nameList := make([]string, 0, len(headers))
+	for name := range headers {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+
+	var b strings.Builder
+	for _, name := range nameList {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(headers[name])
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(nameList, ";")
+}
+
+// canonicalURI returns the URI encoded path, S3 doesn't normalize the path so each segment is encoded once as is.
+func canonicalURI(u *url.URL) string {
+	path := u.Path
+	if path == "" {
+		return "/"
+	}
+	segmentList := strings.Split(path, "/")
+	for i, segment := range segmentList {
+		segmentList[i] = uriEncode(segment)
+	}
+	return strings.Join(segmentList, "/")
+}
+
+// canonicalQuery returns the query string sorted by the parameter name, with the names and values URI encoded.
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	nameList := make([]string, 0, len(query))
+	for name := range query {
+		nameList = append(nameList, name)
+	}
+	sort.Strings(nameList)
+
+	var pairList []string
+	for _, name := range nameList {
+		valueList := query[name]
+		sort.Strings(valueList)
+		for _, value := range valueList {
+			pairList = append(pairList, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	return strings.Join(pairList, "&")
+}
+
+// uriEncode encodes every byte except the unreserved characters A-Z, a-z, 0-9, '-', '.', '_' and '~'.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
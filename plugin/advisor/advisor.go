@@ -0,0 +1,294 @@
+package advisor
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bytebase/bytebase/db"
+)
+
+var (
+	advisorMu sync.RWMutex
+	advisors  = make(map[Type]Advisor)
+)
+
+// Type is the type of the SQL review rule.
+type Type string
+
+const (
+	TableRequirePK              Type = "bb.advisor.table.require-pk"
+	StatementDropTable          Type = "bb.advisor.statement.drop-table"
+	StatementDropColumn         Type = "bb.advisor.statement.drop-column"
+	StatementRequireWhere       Type = "bb.advisor.statement.require-where"
+	NamingTable                 Type = "bb.advisor.naming.table"
+	NamingColumn                Type = "bb.advisor.naming.column"
+	ColumnNotNullRequireDefault Type = "bb.advisor.column.not-null-require-default"
+)
+
+// Level is the level an environment assigns to a rule.
+type Level string
+
+const (
+	LevelError    Level = "ERROR"
+	LevelWarning  Level = "WARNING"
+	LevelDisabled Level = "DISABLED"
+)
+
+func (e Level) String() string {
+	switch e {
+	case LevelError:
+		return "ERROR"
+	case LevelWarning:
+		return "WARNING"
+	case LevelDisabled:
+		return "DISABLED"
+	}
+	return "UNKNOWN"
+}
+
+// Policy maps the rule type to its level. Rules not in the policy use the WARNING level.
+type Policy map[Type]Level
+
+// UnmarshalPolicy parses the policy stored as json string. Empty string means the default policy.
+func UnmarshalPolicy(s string) (Policy, error) {
+	policy := Policy{}
+	if s == "" {
+		return policy, nil
+	}
+	if err := json.Unmarshal([]byte(s), &policy); err != nil {
+		return nil, fmt.Errorf("invalid SQL review policy: %w", err)
+	}
+	advisorMu.RLock()
+	defer advisorMu.RUnlock()
+	for ruleType, level := range policy {
+		if _, ok := advisors[ruleType]; !ok {
+			return nil, fmt.Errorf("invalid SQL review policy, unknown rule %q", ruleType)
+		}
+		if level.String() == "UNKNOWN" {
+			return nil, fmt.Errorf("invalid SQL review policy, unknown level %q for rule %q", level, ruleType)
+		}
+	}
+	return policy, nil
+}
+
+func (p Policy) level(ruleType Type) Level {
+	if level, ok := p[ruleType]; ok {
+		return level
+	}
+	return LevelWarning
+}
+
+// Advice is a single finding reported by a rule.
+type Advice struct {
+	Type    Type   `json:"type"`
+	Level   Level  `json:"level"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+// Advisor checks a single statement against a rule and returns the problems found.
+type Advisor interface {
+	title() string
+	check(stmt *statement) []string
+}
+
+// register makes an advisor available by the rule type.
+// If register is called twice with the same type or if advisor is nil,
+// it panics.
+func register(ruleType Type, a Advisor) {
+	advisorMu.Lock()
+	defer advisorMu.Unlock()
+	if a == nil {
+		panic("advisor: Register advisor is nil")
+	}
+	if _, dup := advisors[ruleType]; dup {
+		panic("advisor: Register called twice for rule " + ruleType)
+	}
+	advisors[ruleType] = a
+}
+
+// Check reviews each statement in sql of dbType against the enabled rules in policy.
+func Check(dbType db.Type, sql string, policy Policy) []Advice {
+	advisorMu.RLock()
+	defer advisorMu.RUnlock()
+
+	typeList := make([]string, 0, len(advisors))
+	for ruleType := range advisors {
+		typeList = append(typeList, string(ruleType))
+	}
+	sort.Strings(typeList)
+
+	adviceList := []Advice{}
+	for _, text := range db.SplitMultiSQL(dbType, sql) {
+		stmt := newStatement(dbType, text)
+		for _, ruleType := range typeList {
+			level := policy.level(Type(ruleType))
+			if level == LevelDisabled {
+				continue
+			}
+			a := advisors[Type(ruleType)]
+			for _, content := range a.check(stmt) {
+				adviceList = append(adviceList, Advice{
+					Type:    Type(ruleType),
+					Level:   level,
+					Title:   a.title(),
+					Content: content,
+				})
+			}
+		}
+	}
+	return adviceList
+}
+
+// HasError returns true if any advice is at the ERROR level.
+func HasError(adviceList []Advice) bool {
+	for _, advice := range adviceList {
+		if advice.Level == LevelError {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	whitespaceRegexp = regexp.MustCompile(`\s+`)
+)
+
+// statement is the normalized form of a single SQL statement for the rules to match.
+type statement struct {
+	// The original statement text.
+	text string
+	// The statement with comments removed, string literals replaced by '' and whitespaces collapsed.
+	normalized string
+	// The upper case of normalized.
+	upper string
+}
+
+func newStatement(dbType db.Type, text string) *statement {
+	s := strings.TrimSpace(whitespaceRegexp.ReplaceAllString(stripCommentAndString(dbType, text), " "))
+	return &statement{
+		text:       text,
+		normalized: s,
+		upper:      strings.ToUpper(s),
+	}
+}
+
+// stripCommentAndString replaces the comments by a space and empties the string literals in a single left to right
+// scan, so that neither the quote in a comment nor the comment marker in a string is mistaken for the other. The
+// quoted identifiers are kept as is. Only MySQL treats # as the start of a comment, while it's an operator in
+// PostgreSQL, and the backslash escapes in the string literals are also MySQL only unless the PostgreSQL string is
+// prefixed by E.
+func stripCommentAndString(dbType db.Type, text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case strings.HasPrefix(text[i:], "--") || (c == '#' && dbType == db.Mysql):
+			if j := strings.IndexByte(text[i:], '\n'); j >= 0 {
+				i += j
+			} else {
+				i = len(text)
+			}
+			b.WriteByte(' ')
+		case strings.HasPrefix(text[i:], "/*"):
+			if j := strings.Index(text[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(text)
+			}
+			b.WriteByte(' ')
+		case c == '\'':
+			backslash := dbType == db.Mysql ||
+				(i > 0 && (text[i-1] == 'E' || text[i-1] == 'e') && (i == 1 || !isIdentifierChar(text[i-2])))
+			i = skipQuoted(text, i, backslash)
+			b.WriteString("''")
+		case c == '"' || c == '`':
+			j := skipQuoted(text, i, false)
+			b.WriteString(text[i:j])
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipQuoted returns the index after the closing quote of the quoted text starting at i, where the quote is escaped
+// by doubling it, or by the backslash if backslash is true. It returns the end of text if the quote isn't closed.
+func skipQuoted(text string, i int, backslash bool) int {
+	quote := text[i]
+	for j := i + 1; j < len(text); j++ {
+		switch {
+		case backslash && text[j] == '\\':
+			j++
+		case text[j] == quote:
+			if j+1 < len(text) && text[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(text)
+}
+
+// unquote removes the identifier quotes and the schema prefix.
+func unquote(identifier string) string {
+	if i := strings.LastIndex(identifier, "."); i >= 0 {
+		identifier = identifier[i+1:]
+	}
+	return strings.Trim(identifier, "`\"[]")
+}
+
+// splitTopLevel splits s by the comma outside parentheses.
+func splitTopLevel(s string) []string {
+	var list []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				list = append(list, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		list = append(list, last)
+	}
+	return list
+}
+
+// hasTopLevelKeyword returns true if the upper case keyword appears as a whole word outside parentheses in upper.
+// String literals are already emptied in the normalized statement, so they can't contain the parentheses.
+func hasTopLevelKeyword(upper string, keyword string) bool {
+	depth := 0
+	for i := 0; i < len(upper); i++ {
+		switch upper[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(upper[i:], keyword) &&
+				(i == 0 || !isIdentifierChar(upper[i-1])) &&
+				(i+len(keyword) == len(upper) || !isIdentifierChar(upper[i+len(keyword)])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || c == '`' || c == '"' || c == '.' || ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,121 @@
+package advisor
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/db"
+)
+
+func TestCheck(t *testing.T) {
+	type test struct {
+		// dbType is MySQL if empty.
+		dbType    db.Type
+		statement string
+		policy    Policy
+		want      []Type
+	}
+
+	tests := []test{
+		{
+			statement: "CREATE TABLE user_role (id INT PRIMARY KEY, role_name VARCHAR(64) NOT NULL)",
+			want:      []Type{},
+		},
+		{
+			statement: "CREATE TABLE `t1` (id INT, name VARCHAR(64), CONSTRAINT pk PRIMARY KEY (id))",
+			want:      []Type{},
+		},
+		{
+			statement: "CREATE TABLE UserRole (id INT, RoleName TEXT, INDEX idx_id (id))",
+			want:      []Type{NamingColumn, NamingTable, TableRequirePK},
+		},
+		{
+			statement: "DROP TABLE IF EXISTS t1, t2;",
+			want:      []Type{StatementDropTable, StatementDropTable},
+		},
+		{
+			statement: "ALTER TABLE t1 DROP COLUMN name, DROP INDEX idx_name, ADD COLUMN `createdTs` INT NOT NULL, ADD INDEX idx_id (id)",
+			want:      []Type{ColumnNotNullRequireDefault, NamingColumn, StatementDropColumn},
+		},
+		{
+			statement: "ALTER TABLE t1 ADD created_ts INT NOT NULL DEFAULT 0, RENAME COLUMN a TO b",
+			want:      []Type{},
+		},
+		{
+			statement: "UPDATE t1 SET name = 'where'; DELETE FROM t1 WHERE id = 1; DELETE FROM t2",
+			want:      []Type{StatementRequireWhere, StatementRequireWhere},
+		},
+		{
+			statement: "UPDATE t1 SET name = (SELECT name FROM t2 WHERE t2.id = 1); DELETE FROM t1 WHERE id IN (SELECT id FROM t2 WHERE t2.id > 1)",
+			want:      []Type{StatementRequireWhere},
+		},
+		{
+			statement: "UPDATE t1 SET `where` = 1, where_id = 2; DELETE FROM t1 WHERE(id = 1); DELETE FROM t1 WHERE\nid = 1",
+			want:      []Type{StatementRequireWhere},
+		},
+		{
+			statement: "DROP TABLE t1; UPDATE t1 SET name = 'a'",
+			policy:    Policy{StatementDropTable: LevelDisabled},
+			want:      []Type{StatementRequireWhere},
+		},
+		// The quote in the comment doesn't start a string hiding the statement.
+		{
+			statement: "-- don't\nDELETE FROM t;",
+			want:      []Type{StatementRequireWhere},
+		},
+		{
+			statement: "-- don't\nDELETE FROM t -- isn't\n;",
+			want:      []Type{StatementRequireWhere},
+		},
+		{
+			statement: "/* it's */ DELETE FROM t1 # isn't\n; UPDATE t1 SET name = '-- WHERE'",
+			want:      []Type{StatementRequireWhere, StatementRequireWhere},
+		},
+		// # is the XOR operator in PostgreSQL rather than a comment.
+		{
+			dbType:    db.Postgres,
+			statement: "UPDATE t1 SET flag = flag # 1 WHERE id = 1",
+			want:      []Type{},
+		},
+		{
+			dbType:    db.Postgres,
+			statement: "UPDATE t1 SET name = 'a\\' WHERE id = 1",
+			want:      []Type{},
+		},
+	}
+
+	for _, tc := range tests {
+		got := []Type{}
+		dbType := tc.dbType
+		if dbType == "" {
+			dbType = db.Mysql
+		}
+		for _, advice := range Check(dbType, tc.statement, tc.policy) {
+			got = append(got, advice.Type)
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("statement=%q: expected %v, got %v", tc.statement, tc.want, got)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	policy, err := UnmarshalPolicy(`{"bb.advisor.statement.drop-table":"ERROR"}`)
+	if err != nil {
+		t.Fatalf("failed to unmarshal policy: %v", err)
+	}
+	adviceList := Check(db.Mysql, "DROP TABLE t1", policy)
+	if len(adviceList) != 1 || adviceList[0].Level != LevelError || !HasError(adviceList) {
+		t.Errorf("unexpected advice list %+v", adviceList)
+	}
+	if HasError(Check(db.Mysql, "DELETE FROM t1", policy)) {
+		t.Errorf("expected the default level to be WARNING")
+	}
+
+	if _, err := UnmarshalPolicy(`{"bb.advisor.unknown":"ERROR"}`); err == nil {
+		t.Errorf("expected error for unknown rule")
+	}
+	if _, err := UnmarshalPolicy(`{"bb.advisor.statement.drop-table":"FATAL"}`); err == nil {
+		t.Errorf("expected error for unknown level")
+	}
+}
@@ -0,0 +1,74 @@
+package advisor
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register(NamingColumn, &NamingColumnAdvisor{})
+	register(ColumnNotNullRequireDefault, &ColumnNotNullRequireDefaultAdvisor{})
+}
+
+// NamingColumnAdvisor requires the column name to be in lower snake case.
+type NamingColumnAdvisor struct {
+}
+
+func (a *NamingColumnAdvisor) title() string {
+	return "Column naming convention"
+}
+
+func (a *NamingColumnAdvisor) check(stmt *statement) []string {
+	var nameList []string
+	if _, defList, ok := parseCreateTable(stmt); ok {
+		for _, def := range defList {
+			if column, ok := parseColumnDef(def); ok {
+				nameList = append(nameList, column.name)
+			}
+		}
+	} else if _, actionList, ok := parseAlterTable(stmt); ok {
+		for _, action := range actionList {
+			if action.kind == "ADD" {
+				nameList = append(nameList, action.column.name)
+			} else if action.newColumnName != "" {
+				nameList = append(nameList, action.newColumnName)
+			}
+		}
+	}
+
+	var contentList []string
+	for _, name := range nameList {
+		if !namingRegexp.MatchString(name) {
+			contentList = append(contentList, fmt.Sprintf("Column name %q should be in lower snake case, e.g. \"created_ts\".", name))
+		}
+	}
+	return contentList
+}
+
+// ColumnNotNullRequireDefaultAdvisor requires the NOT NULL column added to an existing table to have a default value,
+// otherwise the statement fails or fills the existing rows with an implicit value.
+type ColumnNotNullRequireDefaultAdvisor struct {
+}
+
+func (a *ColumnNotNullRequireDefaultAdvisor) title() string {
+	return "NOT NULL column requires default"
+}
+
+func (a *ColumnNotNullRequireDefaultAdvisor) check(stmt *statement) []string {
+	table, actionList, ok := parseAlterTable(stmt)
+	if !ok {
+		return nil
+	}
+
+	var contentList []string
+	for _, action := range actionList {
+		if action.kind != "ADD" {
+			continue
+		}
+		def := action.column.def
+		if strings.Contains(def, "NOT NULL") && !strings.Contains(def, "DEFAULT") && !strings.Contains(def, "AUTO_INCREMENT") {
+			contentList = append(contentList, fmt.Sprintf("Column %q is added to table %q as NOT NULL without a default value.", action.column.name, table))
+		}
+	}
+	return contentList
+}
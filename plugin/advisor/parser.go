@@ -0,0 +1,188 @@
+package advisor
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	createTableRegexp = regexp.MustCompile(`(?i)^CREATE (?:TEMPORARY )?TABLE (?:IF NOT EXISTS )?(\S+?) ?\((.*)\)`)
+	alterTableRegexp  = regexp.MustCompile(`(?i)^ALTER TABLE (?:ONLY )?(?:IF EXISTS )?(\S+) (.*)$`)
+	renameTableRegexp = regexp.MustCompile(`(?i)^RENAME TABLE (.*)$`)
+	dropTableRegexp   = regexp.MustCompile(`(?i)^DROP (?:TEMPORARY )?TABLE (?:IF EXISTS )?(.*)$`)
+	identifierRegexp  = regexp.MustCompile("^(`[^`]*`|\"[^\"]*\"|\\S+)")
+	// Keywords starting a table level definition instead of a column definition.
+	constraintPrefixList = []string{"PRIMARY ", "PRIMARY(", "UNIQUE", "KEY ", "INDEX ", "CONSTRAINT ", "FOREIGN ", "FULLTEXT ", "SPATIAL ", "CHECK ", "CHECK(", "EXCLUDE "}
+)
+
+// columnDef is a column definition in a CREATE TABLE or ALTER TABLE ADD statement.
+type columnDef struct {
+	name string
+	// The upper case of the column definition after the name, e.g. "INT NOT NULL DEFAULT 0".
+	def string
+}
+
+// alterAction is a single action in an ALTER TABLE statement.
+type alterAction struct {
+	// The upper case of the action keyword, one of ADD, DROP, RENAME, CHANGE, MODIFY, ALTER or OTHER.
+	kind string
+	// The added column for ADD, CHANGE and MODIFY actions.
+	column *columnDef
+	// The dropped or renamed column.
+	columnName string
+	// The new column name for RENAME COLUMN and CHANGE actions.
+	newColumnName string
+	// The new table name for RENAME TO action.
+	newTableName string
+}
+
+// parseCreateTable returns the table name and the definitions if the statement is a CREATE TABLE with
+// column definitions, CREATE TABLE ... AS SELECT and CREATE TABLE ... LIKE are not matched.
+func parseCreateTable(stmt *statement) (string, []string, bool) {
+	matches := createTableRegexp.FindStringSubmatch(stmt.normalized)
+	if matches == nil {
+		return "", nil, false
+	}
+	return unquote(matches[1]), splitTopLevel(matches[2]), true
+}
+
+// parseColumnDef parses the column definition, returns false if def is a table level definition.
+func parseColumnDef(def string) (*columnDef, bool) {
+	upper := strings.ToUpper(def)
+	for _, prefix := range constraintPrefixList {
+		if strings.HasPrefix(upper, prefix) {
+			return nil, false
+		}
+	}
+	name := identifierRegexp.FindString(def)
+	if name == "" {
+		return nil, false
+	}
+	return &columnDef{
+		name: unquote(name),
+		def:  strings.TrimSpace(upper[len(name):]),
+	}, true
+}
+
+// parseAlterTable returns the table name and the action list if the statement is an ALTER TABLE.
+func parseAlterTable(stmt *statement) (string, []alterAction, bool) {
+	matches := alterTableRegexp.FindStringSubmatch(stmt.normalized)
+	if matches == nil {
+		return "", nil, false
+	}
+
+	var actionList []alterAction
+	for _, text := range splitTopLevel(matches[2]) {
+		words := strings.Fields(text)
+		if len(words) == 0 {
+			continue
+		}
+		kind := strings.ToUpper(words[0])
+		// Remove the leading keyword and the optional COLUMN keyword.
+		rest := strings.TrimSpace(text[len(words[0]):])
+		hasColumnKeyword := false
+		if strings.HasPrefix(strings.ToUpper(rest), "COLUMN ") {
+			rest = strings.TrimSpace(rest[len("COLUMN "):])
+			hasColumnKeyword = true
+		}
+		switch kind {
+		case "ADD":
+			if hasColumnKeyword {
+				rest = trimIfNotExists(rest)
+			}
+			// MySQL allows adding multiple columns in parentheses.
+			defList := []string{rest}
+			if strings.HasPrefix(rest, "(") && strings.HasSuffix(rest, ")") {
+				defList = splitTopLevel(rest[1 : len(rest)-1])
+			}
+			for _, def := range defList {
+				if column, ok := parseColumnDef(def); ok {
+					actionList = append(actionList, alterAction{kind: kind, column: column})
+				}
+			}
+		case "DROP":
+			upper := strings.ToUpper(rest)
+			if !hasColumnKeyword {
+				if _, ok := parseColumnDef(rest); !ok || strings.HasPrefix(upper, "DEFAULT") {
+					continue
+				}
+			}
+			name := identifierRegexp.FindString(trimIfExists(rest))
+			actionList = append(actionList, alterAction{kind: kind, columnName: unquote(name)})
+		case "RENAME":
+			upper := strings.ToUpper(rest)
+			if strings.HasPrefix(upper, "TO ") || strings.HasPrefix(upper, "AS ") {
+				actionList = append(actionList, alterAction{kind: kind, newTableName: unquote(strings.TrimSpace(rest[3:]))})
+			} else if hasColumnKeyword {
+				if i := strings.Index(upper, " TO "); i >= 0 {
+					actionList = append(actionList, alterAction{
+						kind:          kind,
+						columnName:    unquote(strings.TrimSpace(rest[:i])),
+						newColumnName: unquote(strings.TrimSpace(rest[i+len(" TO "):])),
+					})
+				}
+			}
+		case "CHANGE":
+			oldName := identifierRegexp.FindString(rest)
+			if column, ok := parseColumnDef(strings.TrimSpace(rest[len(oldName):])); ok {
+				actionList = append(actionList, alterAction{
+					kind:          kind,
+					column:        column,
+					columnName:    unquote(oldName),
+					newColumnName: column.name,
+				})
+			}
+		case "MODIFY":
+			if column, ok := parseColumnDef(rest); ok {
+				actionList = append(actionList, alterAction{kind: kind, column: column, columnName: column.name})
+			}
+		default:
+			actionList = append(actionList, alterAction{kind: "OTHER"})
+		}
+	}
+	return unquote(matches[1]), actionList, true
+}
+
+// parseRenameTable returns the new table name list if the statement is a MySQL RENAME TABLE.
+func parseRenameTable(stmt *statement) ([]string, bool) {
+	matches := renameTableRegexp.FindStringSubmatch(stmt.normalized)
+	if matches == nil {
+		return nil, false
+	}
+	var nameList []string
+	for _, pair := range splitTopLevel(matches[1]) {
+		if i := strings.Index(strings.ToUpper(pair), " TO "); i >= 0 {
+			nameList = append(nameList, unquote(strings.TrimSpace(pair[i+len(" TO "):])))
+		}
+	}
+	return nameList, true
+}
+
+// parseDropTable returns the dropped table name list if the statement is a DROP TABLE.
+func parseDropTable(stmt *statement) ([]string, bool) {
+	matches := dropTableRegexp.FindStringSubmatch(stmt.normalized)
+	if matches == nil {
+		return nil, false
+	}
+	var nameList []string
+	for _, name := range splitTopLevel(matches[1]) {
+		if name = identifierRegexp.FindString(name); name != "" {
+			nameList = append(nameList, unquote(name))
+		}
+	}
+	return nameList, true
+}
+
+func trimIfExists(s string) string {
+	if strings.HasPrefix(strings.ToUpper(s), "IF EXISTS ") {
+		return strings.TrimSpace(s[len("IF EXISTS "):])
+	}
+	return s
+}
+
+func trimIfNotExists(s string) string {
+	if strings.HasPrefix(strings.ToUpper(s), "IF NOT EXISTS ") {
+		return strings.TrimSpace(s[len("IF NOT EXISTS "):])
+	}
+	return s
+}
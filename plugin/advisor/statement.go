@@ -0,0 +1,80 @@
+package advisor
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	register(StatementDropTable, &StatementDropTableAdvisor{})
+	register(StatementDropColumn, &StatementDropColumnAdvisor{})
+	register(StatementRequireWhere, &StatementRequireWhereAdvisor{})
+}
+
+// StatementDropTableAdvisor reports the DROP TABLE statement.
+type StatementDropTableAdvisor struct {
+}
+
+func (a *StatementDropTableAdvisor) title() string {
+	return "Drop table"
+}
+
+func (a *StatementDropTableAdvisor) check(stmt *statement) []string {
+	nameList, ok := parseDropTable(stmt)
+	if !ok {
+		return nil
+	}
+	var contentList []string
+	for _, name := range nameList {
+		contentList = append(contentList, fmt.Sprintf("Table %q is dropped, the data can't be recovered without a backup.", name))
+	}
+	return contentList
+}
+
+// StatementDropColumnAdvisor reports the column dropped by ALTER TABLE.
+type StatementDropColumnAdvisor struct {
+}
+
+func (a *StatementDropColumnAdvisor) title() string {
+	return "Drop column"
+}
+
+func (a *StatementDropColumnAdvisor) check(stmt *statement) []string {
+	table, actionList, ok := parseAlterTable(stmt)
+	if !ok {
+		return nil
+	}
+	var contentList []string
+	for _, action := range actionList {
+		if action.kind == "DROP" {
+			contentList = append(contentList, fmt.Sprintf("Column %q is dropped from table %q, the data can't be recovered without a backup.", action.columnName, table))
+		}
+	}
+	return contentList
+}
+
+// StatementRequireWhereAdvisor reports the UPDATE and DELETE statement without a WHERE clause.
+type StatementRequireWhereAdvisor struct {
+}
+
+func (a *StatementRequireWhereAdvisor) title() string {
+	return "UPDATE and DELETE require WHERE"
+}
+
+func (a *StatementRequireWhereAdvisor) check(stmt *statement) []string {
+	var kind string
+	switch {
+	case strings.HasPrefix(stmt.upper, "UPDATE "):
+		kind = "UPDATE"
+	case strings.HasPrefix(stmt.upper, "DELETE "):
+		kind = "DELETE"
+	default:
+		return nil
+	}
+	// Only the WHERE of the statement itself counts, not the ones in the subqueries, e.g.
+	// "UPDATE t SET x = (SELECT max(x) FROM t2 WHERE t2.id = 1)" still affects all rows.
+	if hasTopLevelKeyword(stmt.upper, "WHERE") {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s statement without a WHERE clause affects all rows: %q.", kind, stmt.normalized)}
+}
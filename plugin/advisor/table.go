@@ -0,0 +1,67 @@
+package advisor
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var namingRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func init() {
+	register(TableRequirePK, &TableRequirePKAdvisor{})
+	register(NamingTable, &NamingTableAdvisor{})
+}
+
+// TableRequirePKAdvisor requires each created table to have a primary key.
+type TableRequirePKAdvisor struct {
+}
+
+func (a *TableRequirePKAdvisor) title() string {
+	return "Table requires primary key"
+}
+
+func (a *TableRequirePKAdvisor) check(stmt *statement) []string {
+	table, defList, ok := parseCreateTable(stmt)
+	if !ok {
+		return nil
+	}
+	for _, def := range defList {
+		upper := strings.ToUpper(def)
+		if strings.HasPrefix(upper, "PRIMARY KEY") || strings.Contains(upper, " PRIMARY KEY") {
+			return nil
+		}
+	}
+	return []string{fmt.Sprintf("Table %q is created without a primary key.", table)}
+}
+
+// NamingTableAdvisor requires the table name to be in lower snake case.
+type NamingTableAdvisor struct {
+}
+
+func (a *NamingTableAdvisor) title() string {
+	return "Table naming convention"
+}
+
+func (a *NamingTableAdvisor) check(stmt *statement) []string {
+	var nameList []string
+	if table, _, ok := parseCreateTable(stmt); ok {
+		nameList = append(nameList, table)
+	} else if _, actionList, ok := parseAlterTable(stmt); ok {
+		for _, action := range actionList {
+			if action.newTableName != "" {
+				nameList = append(nameList, action.newTableName)
+			}
+		}
+	} else if renameList, ok := parseRenameTable(stmt); ok {
+		nameList = append(nameList, renameList...)
+	}
+
+	var contentList []string
+	for _, name := range nameList {
+		if !namingRegexp.MatchString(name) {
+			contentList = append(contentList, fmt.Sprintf("Table name %q should be in lower snake case, e.g. \"user_role\".", name))
+		}
+	}
+	return contentList
+}
@@ -0,0 +1,259 @@
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitea"
+	"go.uber.org/zap"
+)
+
+func init() {
+	register(common.GITEA, newGitea)
+}
+
+// Gitea is the provider of the self-hosted Gitea and Forgejo, which addresses the repository by full path.
+type Gitea struct {
+	l *zap.Logger
+}
+
+func newGitea(config ProviderConfig) Provider {
+	return &Gitea{l: config.Logger}
+}
+
+func (p *Gitea) APIURL(instanceURL string) string {
+	return fmt.Sprintf("%s/%s", instanceURL, gitea.ApiPath)
+}
+
+func (p *Gitea) ExchangeOAuthToken(instanceURL string, oauthCtx OAuthContext, code string) (*OAuthToken, error) {
+	token, err := gitea.ExchangeToken(instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, code, oauthCtx.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: token.AccessToken, ExpiresTs: token.ExpiresTs, RefreshToken: token.RefreshToken}, nil
+}
+
+func (p *Gitea) RefreshOAuthToken(instanceURL string, oauthCtx OAuthContext, refreshToken string) (*OAuthToken, error) {
+	token, err := gitea.RefreshToken(instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: token.AccessToken, ExpiresTs: token.ExpiresTs, RefreshToken: token.RefreshToken}, nil
+}
+
+func (p *Gitea) FetchRepositoryList(instanceURL string, token string) ([]*Repository, error) {
+	repositoryList, err := gitea.FetchRepositoryList(instanceURL, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Repository
+	for _, repository := range repositoryList {
+		list = append(list, &Repository{
+			ID:       strconv.Itoa(repository.ID),
+			Name:     repository.Name,
+			FullPath: repository.FullName,
+			WebURL:   repository.HTMLURL,
+		})
+	}
+	return list, nil
+}
+
+func (p *Gitea) ReadFileContent(instanceURL string, repository Repository, filePath string, ref string, token string) ([]byte, error) {
+	return gitea.ReadFileContent(instanceURL, repository.FullPath, filePath, ref, token)
+}
+
+func (p *Gitea) FetchTagList(instanceURL string, repository Repository, token string) ([]Tag, error) {
+	tagList, err := gitea.FetchTagList(instanceURL, repository.FullPath, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []Tag
+	for _, tag := range tagList {
+		list = append(list, Tag{Name: tag.Name, CommitID: tag.Commit.SHA})
+	}
+	return list, nil
+}
+
+// FetchAddedFileList returns the files added by the commits between from and to, which Gitea compares commit by
+// commit rather than as a whole, so the files added and then removed are left out.
+func (p *Gitea) FetchAddedFileList(instanceURL string, repository Repository, from string, to string, token string) ([]string, error) {
+	var commitList []gitea.Commit
+	if from == "" {
+		commit, err := gitea.FetchCommit(instanceURL, repository.FullPath, to, token)
+		if err != nil {
+			return nil, err
+		}
+		commitList = []gitea.Commit{*commit}
+	} else {
+		var err error
+		commitList, err = gitea.FetchCompareCommitList(instanceURL, repository.FullPath, from, to, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var list []string
+	added := make(map[string]bool)
+	// The commits are the newest first.
+	for i := len(commitList) - 1; i >= 0; i-- {
+		for _, file := range commitList[i].FileList {
+			switch file.Status {
+			case "added":
+				if !added[file.Filename] {
+					list = append(list, file.Filename)
+				}
+				added[file.Filename] = true
+			case "removed":
+				added[file.Filename] = false
+			}
+		}
+	}
+	var addedList []string
+	for _, file := range list {
+		if added[file] {
+			addedList = append(addedList, file)
+		}
+	}
+	return addedList, nil
+}
+
+func (p *Gitea) CreateWebhook(instanceURL string, repository Repository, config WebhookConfig, token string) (string, error) {
+	// Like GitHub, the hooks API addresses the repository by its full name.
+	if repository.FullPath == "" {
+		return "", fmt.Errorf("repository full path is required to create Gitea webhook")
+	}
+	webhookPost := gitea.WebhookPost{
+		Type:   "gitea",
+		Active: true,
+		Events: []string{string(gitea.WebhookPush)},
+		Config: gitea.WebhookConfig{
+			URL:         config.URL,
+			ContentType: "json",
+			Secret:      config.SecretToken,
+		},
+		BranchFilter: giteaBranchFilter(config),
+	}
+	body, err := json.Marshal(webhookPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post request for creating webhook: %w", err)
+	}
+	resp, err := gitea.POST(instanceURL, fmt.Sprintf("repos/%s/hooks", repository.FullPath), token, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to create webhook, status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	webhookInfo := &gitea.WebhookInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+	}
+	return strconv.Itoa(webhookInfo.ID), nil
+}
+
+func (p *Gitea) PatchWebhook(instanceURL string, repository Repository, webhookID string, config WebhookConfig, token string) error {
+	webhookPatch := gitea.WebhookPatch{
+		Config: gitea.WebhookConfig{
+			URL:         config.URL,
+			ContentType: "json",
+			Secret:      config.SecretToken,
+		},
+		BranchFilter: giteaBranchFilter(config),
+	}
+	body, err := json.Marshal(webhookPatch)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch request for updating webhook %s: %w", webhookID, err)
+	}
+	resp, err := gitea.PATCH(instanceURL, fmt.Sprintf("repos/%s/hooks/%s", repository.FullPath, webhookID), token, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to update webhook %s, status code: %d, status: %s", webhookID, resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+func (p *Gitea) DeleteWebhook(instanceURL string, repository Repository, webhookID string, token string) error {
+	resp, err := gitea.DELETE(instanceURL, fmt.Sprintf("repos/%s/hooks/%s", repository.FullPath, webhookID), token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook %s, status code: %d, status: %s", webhookID, resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// ParsePushEvent verifies the X-Gitea-Signature signature. Gitea filters the branches itself, and the push events of
+// the tags are delivered only if the branch filter is empty.
+func (p *Gitea) ParsePushEvent(header http.Header, body []byte, config WebhookConfig) (*PushEvent, error) {
+	// Verify the signature before looking into the payload.
+	if !gitea.ValidateSignature(config.SecretToken, body, header.Get(gitea.SignatureHeader)) {
+		return nil, fmt.Errorf("signature mismatch")
+	}
+
+	// This shouldn't happen as we only setup webhook to receive push event, just in case.
+	if eventType := gitea.GiteaWebhookType(header.Get(gitea.EventHeader)); eventType != gitea.WebhookPush {
+		return nil, fmt.Errorf("invalid webhook event type, got %s, want push", eventType)
+	}
+
+	pushEvent := &gitea.WebhookPushEvent{}
+	if err := json.Unmarshal(body, pushEvent); err != nil {
+		return nil, fmt.Errorf("malformatted push event: %w", err)
+	}
+
+	event := &PushEvent{
+		Ref:                pushEvent.Ref,
+		RepositoryID:       strconv.Itoa(pushEvent.Repository.ID),
+		RepositoryURL:      pushEvent.Repository.HTMLURL,
+		RepositoryFullPath: pushEvent.Repository.FullName,
+		AuthorName:         pushEvent.Pusher.Login,
+	}
+	commitList := pushEvent.CommitList
+	// The head commit is the tagged commit, which isn't in the commits when pushing a tag of an existing commit.
+	if strings.HasPrefix(pushEvent.Ref, "refs/tags/") {
+		commitList = nil
+		if pushEvent.HeadCommit != nil {
+			commitList = []gitea.WebhookCommit{*pushEvent.HeadCommit}
+		}
+	}
+	for _, commit := range commitList {
+		createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
+		if err != nil {
+			p.l.Warn("Failed to parse timestamp", zap.String("commit", commit.ID), zap.Error(err))
+		}
+		event.CommitList = append(event.CommitList, Commit{
+			ID: commit.ID,
+			// Gitea doesn't provide the commit title, which is the first line of the message by convention.
+			Title:      strings.SplitN(commit.Message, "\n", 2)[0],
+			Message:    commit.Message,
+			CreatedTs:  createdTime.Unix(),
+			URL:        commit.URL,
+			AuthorName: commit.Author.Name,
+			AddedList:  commit.AddedList,
+		})
+	}
+	return event, nil
+}
+
+// giteaBranchFilter returns the branch filter of the Gitea webhook. Gitea applies the branch filter to the refs of
+// the tags as well, so the filter is cleared to receive the push events of the tags if TagFilter is set.
+func giteaBranchFilter(config WebhookConfig) string {
+	if config.TagFilter != "" {
+		return ""
+	}
+	return config.BranchFilter
+}
@@ -0,0 +1,212 @@
+package vcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/github"
+	"go.uber.org/zap"
+)
+
+func init() {
+	register(common.GITHUB, newGitHub)
+}
+
+// GitHub is the provider of github.com and GitHub Enterprise Server, which addresses the repository by full path.
+type GitHub struct {
+	l *zap.Logger
+}
+
+func newGitHub(config ProviderConfig) Provider {
+	return &GitHub{l: config.Logger}
+}
+
+func (p *GitHub) APIURL(instanceURL string) string {
+	return github.ApiURL(instanceURL)
+}
+
+func (p *GitHub) ExchangeOAuthToken(instanceURL string, oauthCtx OAuthContext, code string) (*OAuthToken, error) {
+	token, err := github.ExchangeToken(instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, code, oauthCtx.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: token.AccessToken, ExpiresTs: token.ExpiresTs, RefreshToken: token.RefreshToken}, nil
+}
+
+func (p *GitHub) RefreshOAuthToken(instanceURL string, oauthCtx OAuthContext, refreshToken string) (*OAuthToken, error) {
+	token, err := github.RefreshToken(instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: token.AccessToken, ExpiresTs: token.ExpiresTs, RefreshToken: token.RefreshToken}, nil
+}
+
+func (p *GitHub) FetchRepositoryList(instanceURL string, token string) ([]*Repository, error) {
+	repositoryList, err := github.FetchRepositoryList(instanceURL, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Repository
+	for _, repository := range repositoryList {
+		list = append(list, &Repository{
+			ID:       strconv.Itoa(repository.ID),
+			Name:     repository.Name,
+			FullPath: repository.FullName,
+			WebURL:   repository.HTMLURL,
+		})
+	}
+	return list, nil
+}
+
+func (p *GitHub) ReadFileContent(instanceURL string, repository Repository, filePath string, ref string, token string) ([]byte, error) {
+	return github.ReadFileContent(instanceURL, repository.FullPath, filePath, ref, token)
+}
+
+func (p *GitHub) FetchTagList(instanceURL string, repository Repository, token string) ([]Tag, error) {
+	tagList, err := github.FetchTagList(instanceURL, repository.FullPath, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []Tag
+	for _, tag := range tagList {
+		list = append(list, Tag{Name: tag.Name, CommitID: tag.Commit.SHA})
+	}
+	return list, nil
+}
+
+func (p *GitHub) FetchAddedFileList(instanceURL string, repository Repository, from string, to string, token string) ([]string, error) {
+	var fileList []github.CommitFile
+	var err error
+	if from == "" {
+		fileList, err = github.FetchCommitFileList(instanceURL, repository.FullPath, to, token)
+	} else {
+		fileList, err = github.FetchCompareFileList(instanceURL, repository.FullPath, from, to, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, file := range fileList {
+		if file.Status == "added" {
+			list = append(list, file.Filename)
+		}
+	}
+	return list, nil
+}
+
+func (p *GitHub) CreateWebhook(instanceURL string, repository Repository, config WebhookConfig, token string) (string, error) {
+	// The hooks API addresses the repository by its full name, while the push event identifies it by ID.
+	if repository.FullPath == "" {
+		return "", fmt.Errorf("repository full path is required to create GitHub webhook")
+	}
+	webhookPost := github.WebhookPost{
+		Name:   "web",
+		Active: true,
+		Events: []string{string(github.WebhookPush)},
+		Config: github.WebhookConfig{
+			URL:         config.URL,
+			ContentType: "json",
+			Secret:      config.SecretToken,
+			InsecureSSL: "0",
+		},
+	}
+	body, err := json.Marshal(webhookPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post request for creating webhook: %w", err)
+	}
+	resp, err := github.POST(instanceURL, fmt.Sprintf("repos/%s/hooks", repository.FullPath), token, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to create webhook, status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	webhookInfo := &github.WebhookInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+	}
+	return strconv.Itoa(webhookInfo.ID), nil
+}
+
+// PatchWebhook does nothing, since GitHub webhooks don't filter the branches. The branch and tag filters are applied
+// to the parsed push event instead.
+func (p *GitHub) PatchWebhook(instanceURL string, repository Repository, webhookID string, config WebhookConfig, token string) error {
+	return nil
+}
+
+func (p *GitHub) DeleteWebhook(instanceURL string, repository Repository, webhookID string, token string) error {
+	resp, err := github.DELETE(instanceURL, fmt.Sprintf("repos/%s/hooks/%s", repository.FullPath, webhookID), token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook %s, status code: %d, status: %s", webhookID, resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// ParsePushEvent verifies the X-Hub-Signature-256 signature. GitHub delivers the push events of all the branches and
+// tags regardless of the branch filter.
+func (p *GitHub) ParsePushEvent(header http.Header, body []byte, config WebhookConfig) (*PushEvent, error) {
+	// Verify the signature before looking into the payload.
+	if !github.ValidateSignature(config.SecretToken, body, header.Get(github.SignatureHeader)) {
+		return nil, fmt.Errorf("signature mismatch")
+	}
+
+	switch eventType := github.GitHubWebhookType(header.Get(github.EventHeader)); eventType {
+	case github.WebhookPush:
+	case github.WebhookPing:
+		return nil, nil
+	default:
+		// This shouldn't happen as we only setup webhook to receive push event, just in case.
+		return nil, fmt.Errorf("invalid webhook event type, got %s, want push", eventType)
+	}
+
+	pushEvent := &github.WebhookPushEvent{}
+	if err := json.Unmarshal(body, pushEvent); err != nil {
+		return nil, fmt.Errorf("malformatted push event: %w", err)
+	}
+
+	event := &PushEvent{
+		Ref:                pushEvent.Ref,
+		RepositoryID:       strconv.Itoa(pushEvent.Repository.ID),
+		RepositoryURL:      pushEvent.Repository.HTMLURL,
+		RepositoryFullPath: pushEvent.Repository.FullName,
+		AuthorName:         pushEvent.Pusher.Name,
+	}
+	commitList := pushEvent.CommitList
+	// The head commit is the tagged commit, which isn't in the commits when pushing a tag of an existing commit.
+	if strings.HasPrefix(pushEvent.Ref, "refs/tags/") {
+		commitList = nil
+		if pushEvent.HeadCommit != nil {
+			commitList = []github.WebhookCommit{*pushEvent.HeadCommit}
+		}
+	}
+	for _, commit := range commitList {
+		createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
+		if err != nil {
+			p.l.Warn("Failed to parse timestamp", zap.String("commit", commit.ID), zap.Error(err))
+		}
+		event.CommitList = append(event.CommitList, Commit{
+			ID: commit.ID,
+			// GitHub doesn't provide the commit title, which is the first line of the message by convention.
+			Title:      strings.SplitN(commit.Message, "\n", 2)[0],
+			Message:    commit.Message,
+			CreatedTs:  createdTime.Unix(),
+			URL:        commit.URL,
+			AuthorName: commit.Author.Name,
+			AddedList:  commit.AddedList,
+		})
+	}
+	return event, nil
+}
@@ -0,0 +1,344 @@
+package vcs
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/common"
+	"github.com/bytebase/bytebase/external/gitlab"
+	"go.uber.org/zap"
+)
+
+func init() {
+	register(common.GITLAB_SELF_HOST, newGitLab)
+}
+
+var _ MergeRequestProvider = (*GitLab)(nil)
+
+// GitLab is the provider of the self-hosted GitLab, which addresses the project by ID.
+type GitLab struct {
+	l *zap.Logger
+}
+
+func newGitLab(config ProviderConfig) Provider {
+	return &GitLab{l: config.Logger}
+}
+
+func (p *GitLab) APIURL(instanceURL string) string {
+	return fmt.Sprintf("%s/%s", instanceURL, gitlab.ApiPath)
+}
+
+func (p *GitLab) ExchangeOAuthToken(instanceURL string, oauthCtx OAuthContext, code string) (*OAuthToken, error) {
+	token, err := gitlab.ExchangeToken(instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, code, oauthCtx.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: token.AccessToken, ExpiresTs: token.ExpiresTs, RefreshToken: token.RefreshToken}, nil
+}
+
+func (p *GitLab) RefreshOAuthToken(instanceURL string, oauthCtx OAuthContext, refreshToken string) (*OAuthToken, error) {
+	token, err := gitlab.RefreshToken(instanceURL, oauthCtx.ClientID, oauthCtx.ClientSecret, refreshToken, oauthCtx.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthToken{AccessToken: token.AccessToken, ExpiresTs: token.ExpiresTs, RefreshToken: token.RefreshToken}, nil
+}
+
+func (p *GitLab) FetchRepositoryList(instanceURL string, token string) ([]*Repository, error) {
+	projectList, err := gitlab.FetchProjectList(instanceURL, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []*Repository
+	for _, project := range projectList {
+		list = append(list, &Repository{
+			ID:       strconv.Itoa(project.ID),
+			Name:     project.Name,
+			FullPath: project.FullPath,
+			WebURL:   project.WebURL,
+		})
+	}
+	return list, nil
+}
+
+func (p *GitLab) ReadFileContent(instanceURL string, repository Repository, filePath string, ref string, token string) ([]byte, error) {
+	return gitlab.ReadFileContent(instanceURL, repository.ID, filePath, ref, token)
+}
+
+func (p *GitLab) FetchTagList(instanceURL string, repository Repository, token string) ([]Tag, error) {
+	tagList, err := gitlab.FetchTagList(instanceURL, repository.ID, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []Tag
+	for _, tag := range tagList {
+		list = append(list, Tag{Name: tag.Name, CommitID: tag.Commit.ID})
+	}
+	return list, nil
+}
+
+func (p *GitLab) FetchAddedFileList(instanceURL string, repository Repository, from string, to string, token string) ([]string, error) {
+	var diffList []gitlab.Diff
+	var err error
+	if from == "" {
+		diffList, err = gitlab.FetchCommitDiffList(instanceURL, repository.ID, to, token)
+	} else {
+		diffList, err = gitlab.FetchCompareDiffList(instanceURL, repository.ID, from, to, token)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, diff := range diffList {
+		if diff.NewFile {
+			list = append(list, diff.NewPath)
+		}
+	}
+	return list, nil
+}
+
+func (p *GitLab) CreateWebhook(instanceURL string, repository Repository, config WebhookConfig, token string) (string, error) {
+	webhookPost := gitlab.WebhookPost{
+		URL:                    config.URL,
+		SecretToken:            config.SecretToken,
+		PushEvents:             true,
+		TagPushEvents:          config.TagFilter != "",
+		MergeRequestsEvents:    true,
+		PushEventsBranchFilter: config.BranchFilter,
+		EnableSSLVerification:  true,
+	}
+	body, err := json.Marshal(webhookPost)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal post request for creating webhook: %w", err)
+	}
+	resp, err := gitlab.POST(instanceURL, fmt.Sprintf("projects/%s/hooks", repository.ID), token, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("failed to create webhook, status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	webhookInfo := &gitlab.WebhookInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
+		return "", fmt.Errorf("failed to unmarshal create webhook response: %w", err)
+	}
+	return strconv.Itoa(webhookInfo.ID), nil
+}
+
+func (p *GitLab) PatchWebhook(instanceURL string, repository Repository, webhookID string, config WebhookConfig, token string) error {
+	webhookPut := gitlab.WebhookPut{
+		URL:                    config.URL,
+		TagPushEvents:          config.TagFilter != "",
+		MergeRequestsEvents:    true,
+		PushEventsBranchFilter: config.BranchFilter,
+		EnableSSLVerification:  true,
+	}
+	body, err := json.Marshal(webhookPut)
+	if err != nil {
+		return fmt.Errorf("failed to marshal put request for updating webhook %s: %w", webhookID, err)
+	}
+	resp, err := gitlab.PUT(instanceURL, fmt.Sprintf("projects/%s/hooks/%s", repository.ID, webhookID), token, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to update webhook %s, status code: %d, status: %s", webhookID, resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+func (p *GitLab) DeleteWebhook(instanceURL string, repository Repository, webhookID string, token string) error {
+	resp, err := gitlab.DELETE(instanceURL, fmt.Sprintf("projects/%s/hooks/%s", repository.ID, webhookID), token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to delete webhook %s, status code: %d, status: %s", webhookID, resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+// validateSecretToken returns true if the X-Gitlab-Token header is the secret token, compared in constant time.
+func validateSecretToken(header http.Header, secretToken string) bool {
+	return subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(secretToken)) == 1
+}
+
+// ParsePushEvent verifies the secret token in the X-Gitlab-Token header. GitLab filters the branches itself, and
+// delivers the tag push events if the webhook is created with TagFilter.
+func (p *GitLab) ParsePushEvent(header http.Header, body []byte, config WebhookConfig) (*PushEvent, error) {
+	if !validateSecretToken(header, config.SecretToken) {
+		return nil, fmt.Errorf("secret token mismatch")
+	}
+
+	pushEvent := &gitlab.WebhookPushEvent{}
+	if err := json.Unmarshal(body, pushEvent); err != nil {
+		return nil, fmt.Errorf("malformatted push event: %w", err)
+	}
+	// This shouldn't happen as we only setup webhook to receive push and tag push events, just in case.
+	if pushEvent.ObjectKind != gitlab.WebhookPush && pushEvent.ObjectKind != gitlab.WebhookTagPush {
+		return nil, fmt.Errorf("invalid webhook event type, got %s, want push or tag_push", pushEvent.ObjectKind)
+	}
+
+	event := &PushEvent{
+		Ref:                pushEvent.Ref,
+		RepositoryID:       strconv.Itoa(pushEvent.Project.ID),
+		RepositoryURL:      pushEvent.Project.WebURL,
+		RepositoryFullPath: pushEvent.Project.FullPath,
+		AuthorName:         pushEvent.AuthorName,
+	}
+	commitList := pushEvent.CommitList
+	if pushEvent.ObjectKind == gitlab.WebhookTagPush {
+		commitList = nil
+		for _, commit := range pushEvent.CommitList {
+			if commit.ID == pushEvent.CheckoutSHA {
+				commitList = []gitlab.WebhookCommit{commit}
+			}
+		}
+		// Pushing a tag of an existing commit delivers no commits, and deleting a tag has no checkout SHA.
+		if commitList == nil && pushEvent.CheckoutSHA != "" {
+			event.CommitList = append(event.CommitList, Commit{
+				ID:         pushEvent.CheckoutSHA,
+				Title:      fmt.Sprintf("Tag %s", strings.TrimPrefix(pushEvent.Ref, "refs/tags/")),
+				Message:    pushEvent.Message,
+				CreatedTs:  time.Now().Unix(),
+				URL:        fmt.Sprintf("%s/-/commit/%s", pushEvent.Project.WebURL, pushEvent.CheckoutSHA),
+				AuthorName: pushEvent.AuthorName,
+			})
+		}
+	}
+	for _, commit := range commitList {
+		createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
+		if err != nil {
+			p.l.Warn("Failed to parse timestamp", zap.String("commit", commit.ID), zap.Error(err))
+		}
+		event.CommitList = append(event.CommitList, Commit{
+			ID:         commit.ID,
+			Title:      commit.Title,
+			Message:    commit.Message,
+			CreatedTs:  createdTime.Unix(),
+			URL:        commit.URL,
+			AuthorName: commit.Author.Name,
+			AddedList:  commit.AddedList,
+		})
+	}
+	return event, nil
+}
+
+// IsMergeRequestEvent returns true for the "Merge Request Hook" event.
+func (p *GitLab) IsMergeRequestEvent(header http.Header) bool {
+	return header.Get("X-Gitlab-Event") == "Merge Request Hook"
+}
+
+// ParseMergeRequestEvent returns the merge request event if the merge request is opened, reopened or updated with new
+// commits.
+func (p *GitLab) ParseMergeRequestEvent(header http.Header, body []byte, config WebhookConfig) (*MergeRequestEvent, error) {
+	if !validateSecretToken(header, config.SecretToken) {
+		return nil, fmt.Errorf("secret token mismatch")
+	}
+
+	mergeRequestEvent := &gitlab.WebhookMergeRequestEvent{}
+	if err := json.Unmarshal(body, mergeRequestEvent); err != nil {
+		return nil, fmt.Errorf("malformatted merge request event: %w", err)
+	}
+	if mergeRequestEvent.ObjectKind != gitlab.WebhookMergeRequest {
+		return nil, fmt.Errorf("invalid webhook event type, got %s, want merge_request", mergeRequestEvent.ObjectKind)
+	}
+
+	attributes := mergeRequestEvent.ObjectAttributes
+	switch attributes.Action {
+	case "open", "reopen":
+	case "update":
+		// The update without oldrev changes the title, labels and so on, but not the commits.
+		if attributes.OldRev == "" {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+
+	return &MergeRequestEvent{
+		ID:           strconv.Itoa(attributes.IID),
+		Title:        attributes.Title,
+		URL:          attributes.URL,
+		AuthorName:   mergeRequestEvent.User.Name,
+		SourceBranch: attributes.SourceBranch,
+		TargetBranch: attributes.TargetBranch,
+		RepositoryID: strconv.Itoa(mergeRequestEvent.Project.ID),
+		SourceRepository: Repository{
+			ID: strconv.Itoa(attributes.SourceProjectID),
+		},
+		CommitID: attributes.LastCommit.ID,
+	}, nil
+}
+
+func (p *GitLab) FetchMergeRequestAddedFileList(instanceURL string, repository Repository, mergeRequestID string, token string) ([]string, error) {
+	changeList, err := gitlab.FetchMergeRequestChangeList(instanceURL, repository.ID, mergeRequestID, token)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, change := range changeList {
+		if change.NewFile {
+			list = append(list, change.NewPath)
+		}
+	}
+	return list, nil
+}
+
+func (p *GitLab) CreateMergeRequestComment(instanceURL string, repository Repository, mergeRequestID string, comment string, token string) error {
+	body, err := json.Marshal(gitlab.MergeRequestNotePost{Body: comment})
+	if err != nil {
+		return fmt.Errorf("failed to marshal post request for creating merge request note: %w", err)
+	}
+	resp, err := gitlab.POST(instanceURL, fmt.Sprintf("projects/%s/merge_requests/%s/notes", repository.ID, mergeRequestID), token, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to create merge request note, status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
+
+func (p *GitLab) SetCommitStatus(instanceURL string, repository Repository, commitID string, status CommitStatus, token string) error {
+	state := gitlab.CommitStatusPending
+	switch status.State {
+	case CommitStateSuccess:
+		state = gitlab.CommitStatusSuccess
+	case CommitStateFailed:
+		state = gitlab.CommitStatusFailed
+	}
+	body, err := json.Marshal(gitlab.CommitStatusPost{
+		State:       state,
+		Name:        status.Name,
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal post request for setting commit status: %w", err)
+	}
+	resp, err := gitlab.POST(instanceURL, fmt.Sprintf("projects/%s/statuses/%s", repository.ID, commitID), token, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to set commit status, status code: %d, status: %s", resp.StatusCode, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,309 @@
+package vcs
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+var (
+	providerMu sync.RWMutex
+	providers  = make(map[common.VCSType]ProviderFunc)
+)
+
+type ProviderConfig struct {
+	Logger *zap.Logger
+}
+
+type ProviderFunc func(ProviderConfig) Provider
+
+// OAuthContext is the OAuth application registered on the VCS.
+type OAuthContext struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// OAuthToken is the OAuth token of the user on the VCS.
+type OAuthToken struct {
+	AccessToken string
+	// ExpiresTs is 0 if the token doesn't expire.
+	ExpiresTs    int64
+	RefreshToken string
+}
+
+// Repository is a repository on the VCS. Depending on the VCS, it's addressed by either ID or FullPath.
+type Repository struct {
+	ID       string
+	Name     string
+	FullPath string
+	WebURL   string
+}
+
+// WebhookConfig is the config of the push event webhook of the repository.
+type WebhookConfig struct {
+	URL         string
+	SecretToken string
+	// BranchFilter is the glob pattern of the branches to receive the push event for, all branches if empty.
+	BranchFilter string
+	// TagFilter is the glob pattern of the tags to receive the push event for. If set, the push events of the
+	// branches are ignored, and pushing a tag processes the migration files added since the previous tag matching
+	// TagFilter, see PreviousTag.
+	TagFilter string
+}
+
+// MatchRef returns true if the push to ref should be processed. If TagFilter is set, only the tags matching it are;
+// otherwise, only the branches matching BranchFilter are.
+func (c WebhookConfig) MatchRef(ref string) bool {
+	if c.TagFilter != "" {
+		tag := strings.TrimPrefix(ref, "refs/tags/")
+		return tag != ref && matchGlob(c.TagFilter, tag)
+	}
+	branch := strings.TrimPrefix(ref, "refs/heads/")
+	return branch != ref && MatchBranch(c.BranchFilter, branch)
+}
+
+// PreviousTag returns the tag before tag in tagList, which is the greatest one matching TagFilter and less than tag in
+// the natural order, e.g. "v1.9" < "v1.10". It returns nil if there is none, e.g. tag is the first release.
+func (c WebhookConfig) PreviousTag(tagList []Tag, tag string) *Tag {
+	var previous *Tag
+	for i := range tagList {
+		t := &tagList[i]
+		if !matchGlob(c.TagFilter, t.Name) || compareNatural(t.Name, tag) >= 0 {
+			continue
+		}
+		if previous == nil || compareNatural(t.Name, previous.Name) > 0 {
+			previous = t
+		}
+	}
+	return previous
+}
+
+// compareNatural compares a and b in the natural order, where the digit sequences are compared by their numeric
+// values, e.g. "v1.9" < "v1.10". It returns -1 if a < b, 1 if a > b and 0 if they're equal.
+func compareNatural(a string, b string) int {
+	for a != "" && b != "" {
+		aDigit, bDigit := isDigit(a[0]), isDigit(b[0])
+		if aDigit && bDigit {
+			aNum, bNum := leadingDigits(a), leadingDigits(b)
+			a, b = a[len(aNum):], b[len(bNum):]
+			// Compare the values by the length and then the digits without the leading zeros.
+			aValue, bValue := strings.TrimLeft(aNum, "0"), strings.TrimLeft(bNum, "0")
+			if len(aValue) != len(bValue) {
+				return compareInt(len(aValue), len(bValue))
+			}
+			if aValue != bValue {
+				return strings.Compare(aValue, bValue)
+			}
+			continue
+		}
+		if a[0] != b[0] {
+			return compareInt(int(a[0]), int(b[0]))
+		}
+		a, b = a[1:], b[1:]
+	}
+	return compareInt(len(a), len(b))
+}
+
+func compareInt(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+// MatchBranch returns true if branch matches the glob pattern of branchFilter, or branchFilter is empty.
+func MatchBranch(branchFilter string, branch string) bool {
+	return branchFilter == "" || matchGlob(branchFilter, branch)
+}
+
+// matchGlob returns true if s matches pattern, where "*" matches any sequence of characters and "?" matches any
+// single character. Unlike path.Match, "*" also matches "/", which is consistent with the branch filter of GitLab and
+// Gitea, e.g. "release/*" matches "release/1.0/hotfix".
+func matchGlob(pattern string, s string) bool {
+	p, r := []rune(pattern), []rune(s)
+	pi, ri := 0, 0
+	// The position after the last "*" and the position of r it matches up to, to backtrack to on mismatch.
+	starPi, starRi := -1, 0
+	for ri < len(r) {
+		switch {
+		case pi < len(p) && p[pi] == '*':
+			starPi, starRi = pi+1, ri
+			pi++
+		case pi < len(p) && (p[pi] == '?' || p[pi] == r[ri]):
+			pi++
+			ri++
+		case starPi >= 0:
+			// Let the last "*" match one more character.
+			starRi++
+			pi, ri = starPi, starRi
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// Commit is a commit in the push event.
+type Commit struct {
+	ID         string
+	Title      string
+	Message    string
+	CreatedTs  int64
+	URL        string
+	AuthorName string
+	AddedList  []string
+}
+
+// Tag is a tag of the repository.
+type Tag struct {
+	Name string
+	// CommitID is the commit the tag points to.
+	CommitID string
+}
+
+// PushEvent is the push event of a branch or a tag. For the push of a tag, CommitList only has the tagged commit, or
+// nothing if the tag is deleted.
+type PushEvent struct {
+	Ref                string
+	RepositoryID       string
+	RepositoryURL      string
+	RepositoryFullPath string
+	AuthorName         string
+	CommitList         []Commit
+}
+
+// MergeRequestEvent is the event of opening a merge request or pushing new commits to it.
+type MergeRequestEvent struct {
+	// ID identifies the merge request in the repository, e.g. the IID in GitLab.
+	ID           string
+	Title        string
+	URL          string
+	AuthorName   string
+	SourceBranch string
+	TargetBranch string
+	// RepositoryID is the ID of the target repository.
+	RepositoryID string
+	// SourceRepository is the repository of the source branch, which may be a fork.
+	SourceRepository Repository
+	// CommitID is the head commit of the source branch.
+	CommitID string
+}
+
+type CommitState string
+
+const (
+	CommitStatePending CommitState = "PENDING"
+	CommitStateSuccess CommitState = "SUCCESS"
+	CommitStateFailed  CommitState = "FAILED"
+)
+
+// CommitStatus is the status of a check on the commit, e.g. the SQL review.
+type CommitStatus struct {
+	State CommitState
+	// Name identifies the check, the status with the same name replaces the previous one.
+	Name        string
+	Description string
+	TargetURL   string
+}
+
+// Provider is the client of a VCS, e.g. GitLab.
+type Provider interface {
+	// Returns the REST API URL of the VCS instance.
+	APIURL(instanceURL string) string
+
+	// Exchanges the code returned to the redirect URL of the OAuth authorization for the token.
+	ExchangeOAuthToken(instanceURL string, oauthCtx OAuthContext, code string) (*OAuthToken, error)
+	// Refreshes the expired token.
+	RefreshOAuthToken(instanceURL string, oauthCtx OAuthContext, refreshToken string) (*OAuthToken, error)
+
+	// Fetches all the repositories the user of token can access.
+	FetchRepositoryList(instanceURL string, token string) ([]*Repository, error)
+	// Reads the content of the file at ref in the repository.
+	ReadFileContent(instanceURL string, repository Repository, filePath string, ref string, token string) ([]byte, error)
+	// Fetches all the tags of the repository.
+	FetchTagList(instanceURL string, repository Repository, token string) ([]Tag, error)
+	// Fetches the files added from the commit from to the commit to by comparing them. If from is empty, it fetches
+	// the files added by the commit to itself.
+	FetchAddedFileList(instanceURL string, repository Repository, from string, to string, token string) ([]string, error)
+
+	// Creates the push event webhook of the repository and returns its ID.
+	CreateWebhook(instanceURL string, repository Repository, config WebhookConfig, token string) (string, error)
+	// Updates the push event webhook of the repository, e.g. after the branch filter is changed.
+	PatchWebhook(instanceURL string, repository Repository, webhookID string, config WebhookConfig, token string) error
+	// Deletes the push event webhook of the repository.
+	DeleteWebhook(instanceURL string, repository Repository, webhookID string, token string) error
+
+	// Verifies and parses the event delivered by the webhook created with config. It returns nil without error if
+	// the event should be acknowledged but not processed, e.g. a ping. The push events of both the branches and the
+	// tags are returned, and the caller filters them with WebhookConfig.MatchRef. For the push of a tag, the
+	// AddedList of the tagged commit may be empty, e.g. GitLab doesn't deliver the commit for the tag of an existing
+	// commit, so the caller fetches the files added since the previous tag by FetchAddedFileList instead.
+	ParsePushEvent(header http.Header, body []byte, config WebhookConfig) (*PushEvent, error)
+}
+
+// MergeRequestProvider is implemented by the provider supporting to review the merge requests before they're merged.
+// The merge request events are delivered by the same webhook as the push events.
+type MergeRequestProvider interface {
+	// Returns true if the webhook event is a merge request event, which is parsed by ParseMergeRequestEvent instead of
+	// ParsePushEvent.
+	IsMergeRequestEvent(header http.Header) bool
+	// Verifies and parses the merge request event. It returns nil without error if the merge request doesn't need to
+	// be reviewed, e.g. it's closed, or updated without new commits.
+	ParseMergeRequestEvent(header http.Header, body []byte, config WebhookConfig) (*MergeRequestEvent, error)
+	// Fetches the files added by the merge request.
+	FetchMergeRequestAddedFileList(instanceURL string, repository Repository, mergeRequestID string, token string) ([]string, error)
+	// Posts the comment to the merge request.
+	CreateMergeRequestComment(instanceURL string, repository Repository, mergeRequestID string, comment string, token string) error
+	// Sets the status of the commit.
+	SetCommitStatus(instanceURL string, repository Repository, commitID string, status CommitStatus, token string) error
+}
+
+// Register makes a VCS provider available by the provided type.
+// If Register is called twice with the same type or if provider is nil,
+// it panics.
+func register(vcsType common.VCSType, f ProviderFunc) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	if f == nil {
+		panic("vcs: Register provider is nil")
+	}
+	if _, dup := providers[vcsType]; dup {
+		panic(fmt.Sprintf("vcs: Register called twice for provider %s", vcsType))
+	}
+	providers[vcsType] = f
+}
+
+// Get returns the provider of the VCS type.
+func Get(vcsType common.VCSType, providerConfig ProviderConfig) (Provider, error) {
+	providerMu.RLock()
+	f, ok := providers[vcsType]
+	providerMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("vcs: unknown provider %v", vcsType)
+	}
+
+	return f(providerConfig), nil
+}
@@ -0,0 +1,447 @@
+package vcs
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/common"
+	"go.uber.org/zap"
+)
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParsePushEvent(t *testing.T) {
+	config := WebhookConfig{SecretToken: "secret", BranchFilter: "main"}
+	gitLabBody := []byte(`{"object_kind":"push","ref":"refs/heads/main","user_name":"bob","project":{"id":13,"web_url":"https://gitlab.example.com/org/repo","path_with_namespace":"org/repo"},` +
+		`"commits":[{"id":"abc","title":"Add table","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://gitlab.example.com/org/repo/-/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}]}`)
+	// The tag push event only has the tagged commit, which is the checkout SHA, see PushEvent.
+	gitLabTagBody := []byte(`{"object_kind":"tag_push","ref":"refs/tags/release-1","checkout_sha":"abc","user_name":"bob","project":{"id":13,"web_url":"https://gitlab.example.com/org/repo","path_with_namespace":"org/repo"},` +
+		`"commits":[{"id":"def","title":"Fix typo","message":"Fix typo","timestamp":"2021-01-01T03:04:05Z","url":"https://gitlab.example.com/org/repo/-/commit/def","author":{"name":"Alice"},"added":[]},` +
+		`{"id":"abc","title":"Add table","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://gitlab.example.com/org/repo/-/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}]}`)
+	gitHubBody := func(ref string) []byte {
+		return []byte(`{"ref":"` + ref + `","repository":{"id":13,"full_name":"org/repo","html_url":"https://github.com/org/repo"},"pusher":{"name":"bob"},` +
+			`"commits":[{"id":"abc","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://github.com/org/repo/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}],` +
+			`"head_commit":{"id":"abc","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://github.com/org/repo/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}}`)
+	}
+	gitHubTagBody := []byte(`{"ref":"refs/tags/release-1","repository":{"id":13,"full_name":"org/repo","html_url":"https://github.com/org/repo"},"pusher":{"name":"bob"},"commits":[],` +
+		`"head_commit":{"id":"abc","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://github.com/org/repo/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}}`)
+	giteaBody := func(ref string) []byte {
+		return []byte(`{"ref":"` + ref + `","repository":{"id":13,"full_name":"org/repo","html_url":"https://gitea.example.com/org/repo"},"pusher":{"login":"bob"},` +
+			`"commits":[{"id":"abc","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://gitea.example.com/org/repo/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}],` +
+			`"head_commit":{"id":"abc","message":"Add table\n\nDetail","timestamp":"2021-01-02T03:04:05Z","url":"https://gitea.example.com/org/repo/commit/abc","author":{"name":"Bob"},"added":["db/v1__init.sql"]}}`)
+	}
+
+	tests := []struct {
+		name    string
+		vcsType common.VCSType
+		header  map[string]string
+		body    []byte
+		// wantSkip is true if the event is acknowledged without the push event.
+		wantSkip bool
+		wantErr  bool
+	}{
+		{"gitlab push", common.GITLAB_SELF_HOST, map[string]string{"X-Gitlab-Token": "secret"}, gitLabBody, false, false},
+		{"gitlab tag", common.GITLAB_SELF_HOST, map[string]string{"X-Gitlab-Token": "secret"}, gitLabTagBody, false, false},
+		{"gitlab bad token", common.GITLAB_SELF_HOST, map[string]string{"X-Gitlab-Token": "another"}, gitLabBody, false, true},
+		{"gitlab token prefix", common.GITLAB_SELF_HOST, map[string]string{"X-Gitlab-Token": "secre"}, gitLabBody, false, true},
+		{"gitlab no token", common.GITLAB_SELF_HOST, map[string]string{}, gitLabBody, false, true},
+		{"github push", common.GITHUB, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign("secret", gitHubBody("refs/heads/main"))}, gitHubBody("refs/heads/main"), false, false},
+		{"github bad signature", common.GITHUB, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign("another", gitHubBody("refs/heads/main"))}, gitHubBody("refs/heads/main"), false, true},
+		{"github ping", common.GITHUB, map[string]string{"X-GitHub-Event": "ping", "X-Hub-Signature-256": "sha256=" + sign("secret", []byte(`{}`))}, []byte(`{}`), true, false},
+		{"github other branch", common.GITHUB, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign("secret", gitHubBody("refs/heads/dev"))}, gitHubBody("refs/heads/dev"), false, false},
+		{"github tag", common.GITHUB, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign("secret", gitHubBody("refs/tags/main"))}, gitHubBody("refs/tags/main"), false, false},
+		{"github tag of existing commit", common.GITHUB, map[string]string{"X-GitHub-Event": "push", "X-Hub-Signature-256": "sha256=" + sign("secret", gitHubTagBody)}, gitHubTagBody, false, false},
+		{"gitea push", common.GITEA, map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign("secret", giteaBody("refs/heads/main"))}, giteaBody("refs/heads/main"), false, false},
+		{"gitea bad signature", common.GITEA, map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign("another", giteaBody("refs/heads/main"))}, giteaBody("refs/heads/main"), false, true},
+		{"gitea tag", common.GITEA, map[string]string{"X-Gitea-Event": "push", "X-Gitea-Signature": sign("secret", giteaBody("refs/tags/v1"))}, giteaBody("refs/tags/v1"), false, false},
+	}
+	for _, test := range tests {
+		provider, err := Get(test.vcsType, ProviderConfig{Logger: zap.NewNop()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{}
+		for k, v := range test.header {
+			header.Set(k, v)
+		}
+		pushEvent, err := provider.ParsePushEvent(header, test.body, config)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: ParsePushEvent() got no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParsePushEvent() got error: %v", test.name, err)
+			continue
+		}
+		if test.wantSkip {
+			if pushEvent != nil {
+				t.Errorf("%s: ParsePushEvent() = %+v, want nil", test.name, pushEvent)
+			}
+			continue
+		}
+		if pushEvent == nil {
+			t.Errorf("%s: ParsePushEvent() = nil", test.name)
+			continue
+		}
+		if pushEvent.RepositoryID != "13" || pushEvent.RepositoryFullPath != "org/repo" || pushEvent.AuthorName != "bob" || len(pushEvent.CommitList) != 1 {
+			t.Errorf("%s: unexpected push event %+v", test.name, pushEvent)
+			continue
+		}
+		commit := pushEvent.CommitList[0]
+		if commit.ID != "abc" || commit.Title != "Add table" || commit.CreatedTs != 1609556645 || commit.AuthorName != "Bob" || len(commit.AddedList) != 1 || commit.AddedList[0] != "db/v1__init.sql" {
+			t.Errorf("%s: unexpected commit %+v", test.name, commit)
+		}
+	}
+}
+
+func TestParseGitLabTagPushEvent(t *testing.T) {
+	config := WebhookConfig{SecretToken: "secret", TagFilter: "release-*"}
+	header := http.Header{}
+	header.Set("X-Gitlab-Token", "secret")
+	provider, err := Get(common.GITLAB_SELF_HOST, ProviderConfig{Logger: zap.NewNop()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pushing a tag of an existing commit has no commits.
+	body := []byte(`{"object_kind":"tag_push","ref":"refs/tags/release-1","checkout_sha":"abc","message":"First release","user_name":"bob",` +
+		`"project":{"id":13,"web_url":"https://gitlab.example.com/org/repo","path_with_namespace":"org/repo"},"commits":[],"total_commits_count":0}`)
+	pushEvent, err := provider.ParsePushEvent(header, body, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pushEvent.Ref != "refs/tags/release-1" || len(pushEvent.CommitList) != 1 {
+		t.Fatalf("unexpected push event %+v", pushEvent)
+	}
+	commit := pushEvent.CommitList[0]
+	if commit.ID != "abc" || commit.Title != "Tag release-1" || commit.Message != "First release" || commit.AuthorName != "bob" ||
+		commit.URL != "https://gitlab.example.com/org/repo/-/commit/abc" || len(commit.AddedList) != 0 {
+		t.Errorf("unexpected commit %+v", commit)
+	}
+
+	// Deleting a tag has no checkout SHA.
+	body = []byte(`{"object_kind":"tag_push","ref":"refs/tags/release-1","checkout_sha":null,"user_name":"bob",` +
+		`"project":{"id":13,"web_url":"https://gitlab.example.com/org/repo","path_with_namespace":"org/repo"},"commits":[],"total_commits_count":0}`)
+	pushEvent, err = provider.ParsePushEvent(header, body, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pushEvent.CommitList) != 0 {
+		t.Errorf("unexpected commits %+v of deleting the tag", pushEvent.CommitList)
+	}
+}
+
+func TestWebhookConfigMatchRef(t *testing.T) {
+	tests := []struct {
+		config WebhookConfig
+		ref    string
+		want   bool
+	}{
+		{WebhookConfig{}, "refs/heads/feature", true},
+		{WebhookConfig{}, "refs/tags/v1", false},
+		{WebhookConfig{BranchFilter: "main"}, "refs/heads/main", true},
+		{WebhookConfig{BranchFilter: "main"}, "refs/heads/main-2", false},
+		{WebhookConfig{BranchFilter: "main"}, "refs/tags/main", false},
+		{WebhookConfig{BranchFilter: "release/*"}, "refs/heads/release/1.0/hotfix", true},
+		{WebhookConfig{BranchFilter: "release/*"}, "refs/heads/feature/release", false},
+		{WebhookConfig{BranchFilter: "v?.x"}, "refs/heads/v1.x", true},
+		{WebhookConfig{BranchFilter: "v?.x"}, "refs/heads/v10.x", false},
+		{WebhookConfig{BranchFilter: "a+b"}, "refs/heads/aab", false},
+		{WebhookConfig{BranchFilter: "*"}, "refs/heads/main", true},
+		{WebhookConfig{BranchFilter: "*-*-*"}, "refs/heads/a-b-c-d", true},
+		{WebhookConfig{BranchFilter: "*-*-*"}, "refs/heads/a-b", false},
+		{WebhookConfig{BranchFilter: "feature/*/db"}, "refs/heads/feature/x/y/db", true},
+		{WebhookConfig{BranchFilter: "feature/*/db"}, "refs/heads/feature/x/db/y", false},
+		{WebhookConfig{BranchFilter: "**main"}, "refs/heads/main", true},
+		{WebhookConfig{BranchFilter: "[main]"}, "refs/heads/m", false},
+		{WebhookConfig{BranchFilter: "v?"}, "refs/heads/v中", true},
+		{WebhookConfig{BranchFilter: "main", TagFilter: "release-*"}, "refs/tags/release-1.0", true},
+		{WebhookConfig{BranchFilter: "main", TagFilter: "release-*"}, "refs/tags/v1.0", false},
+		{WebhookConfig{BranchFilter: "main", TagFilter: "release-*"}, "refs/heads/main", false},
+	}
+	for _, test := range tests {
+		if got := test.config.MatchRef(test.ref); got != test.want {
+			t.Errorf("%+v.MatchRef(%q) = %v, want %v", test.config, test.ref, got, test.want)
+		}
+	}
+}
+
+func TestWebhookConfigPreviousTag(t *testing.T) {
+	tagList := []Tag{
+		{Name: "release-1.9", CommitID: "a"},
+		{Name: "release-1.10", CommitID: "b"},
+		{Name: "v2.0", CommitID: "c"},
+		{Name: "release-1.2", CommitID: "d"},
+		{Name: "release-1.11", CommitID: "e"},
+	}
+	tests := []struct {
+		tagFilter string
+		tag       string
+		// want is the name of the previous tag, empty if there is none.
+		want string
+	}{
+		{"release-*", "release-1.11", "release-1.10"},
+		{"release-*", "release-1.12", "release-1.11"},
+		{"release-*", "release-1.10", "release-1.9"},
+		{"release-*", "release-1.2", ""},
+		// The new tag may not be the latest, e.g. a hotfix of an old release.
+		{"release-*", "release-1.9.1", "release-1.9"},
+		{"release-?.??", "release-1.11", "release-1.10"},
+		{"v*", "v2.1", "v2.0"},
+		{"v*", "v2.0", ""},
+	}
+	for _, test := range tests {
+		got := WebhookConfig{TagFilter: test.tagFilter}.PreviousTag(tagList, test.tag)
+		if test.want == "" {
+			if got != nil {
+				t.Errorf("tagFilter=%s tag=%s: PreviousTag() = %+v, want nil", test.tagFilter, test.tag, got)
+			}
+			continue
+		}
+		if got == nil || got.Name != test.want {
+			t.Errorf("tagFilter=%s tag=%s: PreviousTag() = %+v, want %s", test.tagFilter, test.tag, got, test.want)
+		}
+	}
+}
+
+func TestCompareNatural(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{"v1.9", "v1.10", -1},
+		{"v1.10", "v1.9", 1},
+		{"v1.01", "v1.1", 0},
+		{"v1", "v1.0", -1},
+		{"a2", "b1", -1},
+		{"release-10", "release-9", 1},
+		{"", "", 0},
+	}
+	for _, test := range tests {
+		if got := compareNatural(test.a, test.b); got != test.want {
+			t.Errorf("compareNatural(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestFetchTagAndAddedFileList(t *testing.T) {
+	tests := []struct {
+		vcsType common.VCSType
+		// response maps the request URI to the response body.
+		response map[string]string
+	}{
+		{
+			vcsType: common.GITLAB_SELF_HOST,
+			response: map[string]string{
+				"/api/v4/projects/1/repository/tags?per_page=100&page=1": `[{"name":"v1","commit":{"id":"c1"}},{"name":"v2","commit":{"id":"c2"}}]`,
+				"/api/v4/projects/1/repository/compare?from=c1&to=c2": `{"diffs":[{"new_path":"db/v2__a.sql","new_file":true},{"new_path":"db/v1__init.sql","new_file":false},` +
+					`{"new_path":"db/v3__b.sql","new_file":true}]}`,
+				"/api/v4/projects/1/repository/commits/c1/diff?per_page=100&page=1": `[{"new_path":"db/v1__init.sql","new_file":true}]`,
+			},
+		},
+		{
+			vcsType: common.GITHUB,
+			response: map[string]string{
+				"/api/v3/repos/org/repo/tags?per_page=100&page=1": `[{"name":"v1","commit":{"sha":"c1"}},{"name":"v2","commit":{"sha":"c2"}}]`,
+				"/api/v3/repos/org/repo/compare/c1...c2": `{"files":[{"filename":"db/v2__a.sql","status":"added"},{"filename":"db/v1__init.sql","status":"modified"},` +
+					`{"filename":"db/v3__b.sql","status":"added"}]}`,
+				"/api/v3/repos/org/repo/commits/c1": `{"files":[{"filename":"db/v1__init.sql","status":"added"}]}`,
+			},
+		},
+		{
+			vcsType: common.GITEA,
+			response: map[string]string{
+				"/api/v1/repos/org/repo/tags?limit=50&page=1": `[{"name":"v1","commit":{"sha":"c1"}},{"name":"v2","commit":{"sha":"c2"}}]`,
+				// The commits are the newest first, and db/v0__tmp.sql is added and then removed.
+				"/api/v1/repos/org/repo/compare/c1...c2": `{"commits":[{"sha":"c2","files":[{"filename":"db/v3__b.sql","status":"added"},{"filename":"db/v0__tmp.sql","status":"removed"}]},` +
+					`{"sha":"c1b","files":[{"filename":"db/v2__a.sql","status":"added"},{"filename":"db/v1__init.sql","status":"modified"},{"filename":"db/v0__tmp.sql","status":"added"}]}]}`,
+				"/api/v1/repos/org/repo/git/commits/c1": `{"sha":"c1","files":[{"filename":"db/v1__init.sql","status":"added"}]}`,
+			},
+		},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, ok := test.response[r.URL.RequestURI()]
+			if r.Method != http.MethodGet || !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, body)
+		}))
+
+		provider, err := Get(test.vcsType, ProviderConfig{Logger: zap.NewNop()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		repository := Repository{ID: "1", FullPath: "org/repo"}
+		tagList, err := provider.FetchTagList(server.URL, repository, "token")
+		if err != nil {
+			t.Errorf("%s: FetchTagList() got error: %v", test.vcsType, err)
+		} else if want := []Tag{{Name: "v1", CommitID: "c1"}, {Name: "v2", CommitID: "c2"}}; !reflect.DeepEqual(tagList, want) {
+			t.Errorf("%s: FetchTagList() = %+v, want %+v", test.vcsType, tagList, want)
+		}
+
+		addedList, err := provider.FetchAddedFileList(server.URL, repository, "c1", "c2", "token")
+		if err != nil {
+			t.Errorf("%s: FetchAddedFileList() got error: %v", test.vcsType, err)
+		} else if want := []string{"db/v2__a.sql", "db/v3__b.sql"}; !reflect.DeepEqual(addedList, want) {
+			t.Errorf("%s: FetchAddedFileList() = %v, want %v", test.vcsType, addedList, want)
+		}
+
+		addedList, err = provider.FetchAddedFileList(server.URL, repository, "", "c1", "token")
+		if err != nil {
+			t.Errorf("%s: FetchAddedFileList() of the first tag got error: %v", test.vcsType, err)
+		} else if want := []string{"db/v1__init.sql"}; !reflect.DeepEqual(addedList, want) {
+			t.Errorf("%s: FetchAddedFileList() of the first tag = %v, want %v", test.vcsType, addedList, want)
+		}
+
+		if _, err := provider.FetchAddedFileList(server.URL, repository, "c2", "c3", "token"); err == nil {
+			t.Errorf("%s: FetchAddedFileList() of the unknown commit got no error", test.vcsType)
+		}
+		server.Close()
+	}
+}
+
+func TestParseMergeRequestEvent(t *testing.T) {
+	config := WebhookConfig{SecretToken: "secret"}
+	body := func(action string, oldRev string) []byte {
+		return []byte(`{"object_kind":"merge_request","user":{"name":"Bob"},"project":{"id":13,"path_with_namespace":"org/repo"},` +
+			`"object_attributes":{"iid":7,"title":"Add table","url":"https://gitlab.example.com/org/repo/-/merge_requests/7","source_branch":"feature","target_branch":"main",` +
+			`"source_project_id":14,"action":"` + action + `","oldrev":"` + oldRev + `","last_commit":{"id":"abc"}}}`)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		body  []byte
+		// wantSkip is true if the merge request doesn't need to be reviewed.
+		wantSkip bool
+		wantErr  bool
+	}{
+		{"open", "secret", body("open", ""), false, false},
+		{"reopen", "secret", body("reopen", ""), false, false},
+		{"update with commits", "secret", body("update", "def"), false, false},
+		{"update without commits", "secret", body("update", ""), true, false},
+		{"close", "secret", body("close", ""), true, false},
+		{"bad token", "another", body("open", ""), false, true},
+		{"push", "secret", []byte(`{"object_kind":"push"}`), false, true},
+	}
+	provider := &GitLab{l: zap.NewNop()}
+	for _, test := range tests {
+		header := http.Header{}
+		header.Set("X-Gitlab-Event", "Merge Request Hook")
+		header.Set("X-Gitlab-Token", test.token)
+		if !provider.IsMergeRequestEvent(header) {
+			t.Errorf("%s: IsMergeRequestEvent() = false", test.name)
+		}
+		event, err := provider.ParseMergeRequestEvent(header, test.body, config)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("%s: ParseMergeRequestEvent() got no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ParseMergeRequestEvent() got error: %v", test.name, err)
+			continue
+		}
+		if test.wantSkip {
+			if event != nil {
+				t.Errorf("%s: ParseMergeRequestEvent() = %+v, want nil", test.name, event)
+			}
+			continue
+		}
+		if event == nil {
+			t.Errorf("%s: ParseMergeRequestEvent() = nil", test.name)
+			continue
+		}
+		if event.ID != "7" || event.RepositoryID != "13" || event.SourceRepository.ID != "14" || event.CommitID != "abc" || event.TargetBranch != "main" || event.AuthorName != "Bob" {
+			t.Errorf("%s: unexpected merge request event %+v", test.name, event)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	for _, vcsType := range []common.VCSType{common.GITLAB_SELF_HOST, common.GITHUB, common.GITEA} {
+		if _, err := Get(vcsType, ProviderConfig{Logger: zap.NewNop()}); err != nil {
+			t.Errorf("Get(%s) got error: %v", vcsType, err)
+		}
+	}
+	if _, err := Get("UNKNOWN", ProviderConfig{Logger: zap.NewNop()}); err == nil {
+		t.Errorf("Get(UNKNOWN) got no error")
+	}
+}
+
+func TestCreateWebhookSSLVerification(t *testing.T) {
+	tests := []struct {
+		vcsType common.VCSType
+		path    string
+		// verified returns true if the webhook posted verifies the certificate of the URL.
+		verified func(hook map[string]interface{}) bool
+	}{
+		{
+			vcsType: common.GITLAB_SELF_HOST,
+			path:    "/api/v4/projects/1/hooks",
+			verified: func(hook map[string]interface{}) bool {
+				return hook["enable_ssl_verification"] == true && hook["token"] == "secret"
+			},
+		},
+		{
+			vcsType: common.GITHUB,
+			path:    "/api/v3/repos/org/repo/hooks",
+			verified: func(hook map[string]interface{}) bool {
+				config, ok := hook["config"].(map[string]interface{})
+				return ok && config["insecure_ssl"] == "0" && config["secret"] == "secret"
+			},
+		},
+	}
+	for _, test := range tests {
+		var hook map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != test.path {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &hook); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id":42}`)
+		}))
+
+		provider, err := Get(test.vcsType, ProviderConfig{Logger: zap.NewNop()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		webhookID, err := provider.CreateWebhook(server.URL, Repository{ID: "1", FullPath: "org/repo"}, WebhookConfig{URL: "https://bytebase.example.com/hook/abc", SecretToken: "secret"}, "token")
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: CreateWebhook() got error: %v", test.vcsType, err)
+			continue
+		}
+		if webhookID != "42" {
+			t.Errorf("%s: webhook ID = %q, want %q", test.vcsType, webhookID, "42")
+		}
+		// The webhook must verify the certificate of the Bytebase endpoint, otherwise the secret could be intercepted.
+		if !test.verified(hook) {
+			t.Errorf("%s: webhook %v doesn't verify the SSL certificate", test.vcsType, hook)
+		}
+	}
+}
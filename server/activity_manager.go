@@ -51,6 +51,11 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 			if update.NewStatus == api.TaskFailed {
 				postInbox = true
 			}
+		case api.ActivityPipelineTaskSQLReview:
+			// Only post the SQL review blocking the task to inbox.
+			if create.Level == api.ACTIVITY_ERROR {
+				postInbox = true
+			}
 		}
 
 		if postInbox {
@@ -217,6 +222,15 @@ func (m *ActivityManager) CreateActivity(ctx context.Context, create *api.Activi
 						case api.TaskFailed:
 							title = fmt.Sprintf("Task failed - %s", task.Name)
 						}
+					case api.ActivityPipelineTaskSQLReview:
+						review := &api.ActivityPipelineTaskSQLReviewPayload{}
+						if err := json.Unmarshal([]byte(activity.Payload), review); err != nil {
+							m.s.l.Warn("Failed to post webhook event after reviewing the task SQL, failed to unmarshal paylaod",
+								zap.String("issue_name", meta.issue.Name),
+								zap.Error(err))
+							return
+						}
+						title = fmt.Sprintf("SQL review found %d problem(s) - %s", len(review.AdviceList), review.TaskName)
 					}
 
 					metaList = append(metaList, webhook.WebhookMeta{
@@ -0,0 +1,292 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// The encrypted backup file is laid out as:
+//
+//	magic (8 bytes) | nonce prefix (8 bytes) | chunk | chunk | ... | final chunk
+//
+// where each chunk is:
+//
+//	final flag (1 byte) | ciphertext length (4 bytes, big endian) | ciphertext
+//
+// Each chunk seals up to BACKUP_ENCRYPTION_CHUNK_SIZE bytes of the plaintext with AES-GCM, using the nonce prefix
+// followed by the big endian chunk index as the nonce, and the final flag as the additional data. So the chunks can't
+// be reordered, and the file can't be truncated at a chunk boundary without failing the authentication.
+const (
+	// BACKUP_ENCRYPTION_CHUNK_SIZE is the size of the plaintext sealed in each chunk of the encrypted backup.
+	BACKUP_ENCRYPTION_CHUNK_SIZE = 64 << 10
+
+	backupEncryptionMagic = "BBBAKENC"
+	noncePrefixSize       = 8
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	errBackupEncryptionKeyNotConfigured = fmt.Errorf("backup encryption key is not configured, start Bytebase with --backup-encryption-key or the BB_BACKUP_ENCRYPTION_KEY environment variable")
+)
+
+// backupEncoder compresses and encrypts the dump written to it, Close must be called to flush the trailing data.
+type backupEncoder struct {
+	w         io.Writer
+	closeList []io.Closer
+}
+
+// newBackupEncoder returns the encoder writing to w. The dump is compressed first, and then encrypted if key is
+// not nil.
+func newBackupEncoder(w io.Writer, compression api.BackupCompression, key []byte) (*backupEncoder, error) {
+	encoder := &backupEncoder{w: w}
+	if key != nil {
+		ew, err := newEncryptWriter(encoder.w, key)
+		if err != nil {
+			return nil, err
+		}
+		encoder.w = ew
+		encoder.closeList = append(encoder.closeList, ew)
+	}
+	switch compression {
+	case api.BackupCompressionNone:
+	case api.BackupCompressionGzip:
+		gw := gzip.NewWriter(encoder.w)
+		encoder.w = gw
+		encoder.closeList = append(encoder.closeList, gw)
+	default:
+		return nil, fmt.Errorf("unsupported backup compression %q", compression)
+	}
+	return encoder, nil
+}
+
+func (e *backupEncoder) Write(p []byte) (int, error) {
+	return e.w.Write(p)
+}
+
+// Close flushes the compressor and then the encryptor, it doesn't close the underlying writer.
+func (e *backupEncoder) Close() error {
+	for i := len(e.closeList) - 1; i >= 0; i-- {
+		if err := e.closeList[i].Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newBackupDecoder returns the reader decoding the backup read from r. The format is detected from the content,
+// so the backups taken before compression and encryption were supported are read as is. getKey is only called if
+// the backup is encrypted.
+func newBackupDecoder(r io.Reader, getKey func() ([]byte, error)) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(backupEncryptionMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if string(magic) == backupEncryptionMagic {
+		key, err := getKey()
+		if err != nil {
+			return nil, err
+		}
+		dr, err := newDecryptReader(br, key)
+		if err != nil {
+			return nil, err
+		}
+		br = bufio.NewReader(dr)
+	}
+
+	magic, err = br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(magic, gzipMagic) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip backup: %w", err)
+		}
+		return gr, nil
+	}
+	return br, nil
+}
+
+// getBackupEncryptionKey returns the key to encrypt the backups, which is passed to the server on startup.
+func (s *Server) getBackupEncryptionKey() ([]byte, error) {
+	if len(s.backupEncryptionKey) == 0 {
+		return nil, errBackupEncryptionKeyNotConfigured
+	}
+	return s.backupEncryptionKey, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func chunkNonce(aead cipher.AEAD, noncePrefix []byte, index uint32) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, noncePrefix)
+	binary.BigEndian.PutUint32(nonce[len(nonce)-4:], index)
+	return nonce
+}
+
+func chunkAdditionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+type encryptWriter struct {
+	aead        cipher.AEAD
+	w           io.Writer
+	noncePrefix []byte
+	index       uint32
+	buf         []byte
+}
+
+func newEncryptWriter(w io.Writer, key []byte) (*encryptWriter, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	noncePrefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(noncePrefix); err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(w, backupEncryptionMagic); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(noncePrefix); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		aead:        aead,
+		w:           w,
+		noncePrefix: noncePrefix,
+		buf:         make([]byte, 0, BACKUP_ENCRYPTION_CHUNK_SIZE),
+	}, nil
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		// Keep the last chunk in buf, so that Close can seal it as the final one.
+		if len(e.buf) == BACKUP_ENCRYPTION_CHUNK_SIZE {
+			if err := e.writeChunk(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close writes the final chunk, it doesn't close the underlying writer.
+func (e *encryptWriter) Close() error {
+	return e.writeChunk(true)
+}
+
+func (e *encryptWriter) writeChunk(final bool) error {
+	if e.index == math.MaxUint32 {
+		return fmt.Errorf("backup is too large to encrypt")
+	}
+	ad := chunkAdditionalData(final)
+	ciphertext := e.aead.Seal(nil, chunkNonce(e.aead, e.noncePrefix, e.index), e.buf, ad)
+	header := make([]byte, 5)
+	header[0] = ad[0]
+	binary.BigEndian.PutUint32(header[1:], uint32(len(ciphertext)))
+	if _, err := e.w.Write(header); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(ciphertext); err != nil {
+		return err
+	}
+	e.index++
+	e.buf = e.buf[:0]
+	return nil
+}
+
+type decryptReader struct {
+	aead        cipher.AEAD
+	r           io.Reader
+	noncePrefix []byte
+	index       uint32
+	final       bool
+	buf         []byte
+}
+
+func newDecryptReader(r io.Reader, key []byte) (*decryptReader, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, len(backupEncryptionMagic)+noncePrefixSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("invalid encrypted backup header: %w", err)
+	}
+	return &decryptReader{
+		aead:        aead,
+		r:           r,
+		noncePrefix: header[len(backupEncryptionMagic):],
+	}, nil
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.final {
+			return 0, io.EOF
+		}
+		if err := d.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) readChunk() error {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(d.r, header); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("encrypted backup is truncated: %w", err)
+	}
+	final := header[0] == 1
+	size := binary.BigEndian.Uint32(header[1:])
+	if header[0] > 1 || size > uint32(BACKUP_ENCRYPTION_CHUNK_SIZE+d.aead.Overhead()) {
+		return fmt.Errorf("invalid encrypted backup chunk %d", d.index)
+	}
+	ciphertext := make([]byte, size)
+	if _, err := io.ReadFull(d.r, ciphertext); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("encrypted backup is truncated: %w", err)
+	}
+	plaintext, err := d.aead.Open(ciphertext[:0], chunkNonce(d.aead, d.noncePrefix, d.index), ciphertext, chunkAdditionalData(final))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt backup chunk %d, the backup is corrupted or encrypted with another key: %w", d.index, err)
+	}
+	d.index++
+	d.final = final
+	d.buf = plaintext
+	return nil
+}
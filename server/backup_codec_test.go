@@ -0,0 +1,174 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func encodeBackup(t *testing.T, plaintext []byte, compression api.BackupCompression, key []byte) []byte {
+	var buf bytes.Buffer
+	encoder, err := newBackupEncoder(&buf, compression, key)
+	if err != nil {
+		t.Fatalf("failed to create backup encoder: %v", err)
+	}
+	// Write in odd sizes to cross the chunk boundaries in the middle of a write.
+	for p := plaintext; len(p) > 0; {
+		n := 10007
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err := encoder.Write(p[:n]); err != nil {
+			t.Fatalf("failed to write backup: %v", err)
+		}
+		p = p[n:]
+	}
+	if err := encoder.Close(); err != nil {
+		t.Fatalf("failed to close backup encoder: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func decodeBackup(encoded []byte, key []byte) ([]byte, error) {
+	r, err := newBackupDecoder(bytes.NewReader(encoded), func() ([]byte, error) {
+		if key == nil {
+			return nil, fmt.Errorf("unexpected key request")
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+func TestBackupCodecRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	// Not a multiple of the chunk size, so there is a partial final chunk.
+	var sb strings.Builder
+	for i := 0; sb.Len() < 3*BACKUP_ENCRYPTION_CHUNK_SIZE+123; i++ {
+		fmt.Fprintf(&sb, "INSERT INTO t1 VALUES (%d, 'row %d');\n", i, i)
+	}
+	large := []byte(sb.String())
+	exact := bytes.Repeat([]byte{'x'}, 2*BACKUP_ENCRYPTION_CHUNK_SIZE)
+
+	for _, plaintext := range [][]byte{large, exact, []byte("SELECT 1;"), {}} {
+		for _, compression := range []api.BackupCompression{api.BackupCompressionNone, api.BackupCompressionGzip} {
+			for _, encrypted := range []bool{false, true} {
+				var encodeKey []byte
+				if encrypted {
+					encodeKey = key
+				}
+				encoded := encodeBackup(t, plaintext, compression, encodeKey)
+				if bytes.HasPrefix(encoded, []byte(backupEncryptionMagic)) != encrypted {
+					t.Errorf("size=%d compression=%s encrypted=%v: unexpected encryption header", len(plaintext), compression, encrypted)
+				}
+				if encrypted && bytes.Contains(encoded, []byte("INSERT INTO")) {
+					t.Errorf("size=%d compression=%s: the encrypted backup contains the plaintext", len(plaintext), compression)
+				}
+
+				decoded, err := decodeBackup(encoded, encodeKey)
+				if err != nil {
+					t.Errorf("size=%d compression=%s encrypted=%v: failed to decode backup: %v", len(plaintext), compression, encrypted, err)
+					continue
+				}
+				if !bytes.Equal(plaintext, decoded) {
+					t.Errorf("size=%d compression=%s encrypted=%v: decoded %d bytes different from the plaintext", len(plaintext), compression, encrypted, len(decoded))
+				}
+			}
+		}
+	}
+}
+
+func TestBackupCodecPlainBackup(t *testing.T) {
+	// The backups taken before the encoding was supported are read as is.
+	plaintext := []byte("CREATE TABLE t1 (id INT);\n")
+	decoded, err := decodeBackup(plaintext, nil)
+	if err != nil || !bytes.Equal(plaintext, decoded) {
+		t.Errorf("expected plain backup %q, got %q, %v", plaintext, decoded, err)
+	}
+}
+
+func TestBackupCodecDecryptFailure(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	plaintext := bytes.Repeat([]byte("0123456789"), BACKUP_ENCRYPTION_CHUNK_SIZE/4)
+	encoded := encodeBackup(t, plaintext, api.BackupCompressionGzip, key)
+	uncompressed := encodeBackup(t, plaintext, api.BackupCompressionNone, key)
+	headerSize := len(backupEncryptionMagic) + noncePrefixSize
+	chunkSize := 5 + BACKUP_ENCRYPTION_CHUNK_SIZE + 16
+
+	flip := func(b []byte, i int) []byte {
+		tampered := append([]byte{}, b...)
+		tampered[i] ^= 0x01
+		return tampered
+	}
+
+	tests := []struct {
+		name    string
+		encoded []byte
+		key     []byte
+	}{
+		{
+			name:    "wrong key",
+			encoded: encoded,
+			key:     bytes.Repeat([]byte{0x24}, 32),
+		},
+		{
+			name:    "invalid key length",
+			encoded: encoded,
+			key:     []byte("short"),
+		},
+		{
+			name:    "tampered nonce prefix",
+			encoded: flip(encoded, len(backupEncryptionMagic)),
+			key:     key,
+		},
+		{
+			name:    "tampered ciphertext",
+			encoded: flip(encoded, len(encoded)-1),
+			key:     key,
+		},
+		{
+			name:    "tampered final flag",
+			encoded: flip(uncompressed, headerSize),
+			key:     key,
+		},
+		{
+			name: "swapped chunks",
+			encoded: append(append(append([]byte{}, uncompressed[:headerSize]...),
+				uncompressed[headerSize+chunkSize:headerSize+2*chunkSize]...),
+				append(append([]byte{}, uncompressed[headerSize:headerSize+chunkSize]...), uncompressed[headerSize+2*chunkSize:]...)...),
+			key: key,
+		},
+		{
+			name:    "truncated in the header",
+			encoded: encoded[:headerSize-1],
+			key:     key,
+		},
+		{
+			name:    "truncated in a chunk",
+			encoded: uncompressed[:headerSize+chunkSize/2],
+			key:     key,
+		},
+		{
+			name:    "truncated at a chunk boundary",
+			encoded: uncompressed[:headerSize+chunkSize],
+			key:     key,
+		},
+		{
+			name:    "final chunk removed",
+			encoded: uncompressed[:len(uncompressed)-(len(uncompressed)-headerSize)%chunkSize],
+			key:     key,
+		},
+	}
+
+	for _, tc := range tests {
+		if decoded, err := decodeBackup(tc.encoded, tc.key); err == nil {
+			t.Errorf("%s: expected decode error, got %d bytes", tc.name, len(decoded))
+		}
+	}
+}
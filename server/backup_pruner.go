@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"go.uber.org/zap"
+)
+
+const (
+	// BACKUP_PRUNE_INTERVAL is the interval for the backup pruner to prune the expired backups.
+	BACKUP_PRUNE_INTERVAL = time.Duration(1) * time.Hour
+)
+
+// NewBackupPruner creates a new backup pruner.
+func NewBackupPruner(logger *zap.Logger, server *Server) *BackupPruner {
+	return &BackupPruner{
+		l:      logger,
+		server: server,
+	}
+}
+
+// BackupPruner is the backup pruner deleting the automatic backups expired according to the backup retention.
+type BackupPruner struct {
+	l      *zap.Logger
+	server *Server
+}
+
+// Run is the runner for backup pruner.
+func (s *BackupPruner) Run() error {
+	go func() {
+		for {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						s.l.Error("Backup pruner PANIC RECOVER", zap.Error(err))
+					}
+				}()
+
+				s.prune()
+			}()
+
+			time.Sleep(BACKUP_PRUNE_INTERVAL)
+		}
+	}()
+
+	return nil
+}
+
+func (s *BackupPruner) prune() {
+	backupSettingList, err := s.server.BackupService.FindBackupSettingList(context.Background(), &api.BackupSettingFind{})
+	if err != nil {
+		s.l.Error("Failed to retrieve backup settings", zap.Error(err))
+		return
+	}
+
+	for _, backupSetting := range backupSettingList {
+		if backupSetting.RetentionCount <= 0 && backupSetting.RetentionDays <= 0 {
+			continue
+		}
+
+		rowStatus := api.Normal
+		backupFind := &api.BackupFind{
+			RowStatus:  &rowStatus,
+			DatabaseId: &backupSetting.DatabaseId,
+		}
+		backupList, err := s.server.BackupService.FindBackupList(context.Background(), backupFind)
+		if err != nil {
+			s.l.Error("Failed to retrieve backups for pruning",
+				zap.Int("databaseID", backupSetting.DatabaseId),
+				zap.Error(err))
+			continue
+		}
+		expiredBackupList := getExpiredBackupList(backupList, backupSetting, time.Now())
+		if len(expiredBackupList) == 0 {
+			continue
+		}
+
+		databaseFind := &api.DatabaseFind{
+			ID: &backupSetting.DatabaseId,
+		}
+		database, err := s.server.ComposeDatabaseByFind(context.Background(), databaseFind)
+		if err != nil {
+			s.l.Error("Failed to get database for backup setting",
+				zap.Int("id", backupSetting.ID),
+				zap.Int("databaseID", backupSetting.DatabaseId),
+				zap.Error(err))
+			continue
+		}
+
+		for _, backup := range expiredBackupList {
+			if err := s.pruneBackup(database, backup); err != nil {
+				s.l.Error("Failed to prune backup",
+					zap.Int("databaseID", database.ID),
+					zap.String("backup", backup.Name),
+					zap.Error(err))
+				continue
+			}
+			s.l.Info("Pruned expired backup",
+				zap.Int("databaseID", database.ID),
+				zap.String("backup", backup.Name))
+		}
+	}
+}
+
+// pruneBackup deletes the backup file and archives the backup.
+func (s *BackupPruner) pruneBackup(database *api.Database, backup *api.Backup) error {
+	if backup.StorageBackend == api.BackupStorageBackendS3 {
+		client, err := getBackupS3Client(database, backup)
+		if err != nil {
+			return err
+		}
+		if err := client.Delete(context.Background(), backup.Path); err != nil {
+			return fmt.Errorf("failed to delete backup %q: %w", backup.Path, err)
+		}
+	} else {
+		if err := os.Remove(filepath.Join(s.server.dataDir, backup.Path)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete backup %q: %w", backup.Path, err)
+		}
+	}
+
+	rowStatus := string(api.Archived)
+	backupPatch := &api.BackupPatch{
+		ID:        backup.ID,
+		RowStatus: &rowStatus,
+		UpdaterId: api.SYSTEM_BOT_ID,
+	}
+	if _, err := s.server.BackupService.PatchBackup(context.Background(), backupPatch); err != nil {
+		return fmt.Errorf("failed to archive backup: %w", err)
+	}
+	return nil
+}
+
+// getExpiredBackupList returns the automatic backups expired according to the retention of backupSetting.
+// A backup is kept if it's one of the latest RetentionCount automatic backups, or if it's created after
+// RetentionDays days ago. The manual backups, the backups in progress and the most recent DONE backup never expire,
+// so that there is always a backup to restore from.
+func getExpiredBackupList(backupList []*api.Backup, backupSetting *api.BackupSetting, now time.Time) []*api.Backup {
+	if backupSetting.RetentionCount <= 0 && backupSetting.RetentionDays <= 0 {
+		return nil
+	}
+
+	sortedList := make([]*api.Backup, len(backupList))
+	copy(sortedList, backupList)
+	sort.Slice(sortedList, func(i, j int) bool {
+		if sortedList[i].CreatedTs != sortedList[j].CreatedTs {
+			return sortedList[i].CreatedTs > sortedList[j].CreatedTs
+		}
+		return sortedList[i].ID > sortedList[j].ID
+	})
+
+	var expiredList []*api.Backup
+	latestDoneFound := false
+	automaticCount := 0
+	retentionTs := now.Add(-time.Duration(backupSetting.RetentionDays) * 24 * time.Hour).Unix()
+	for _, backup := range sortedList {
+		latestDone := !latestDoneFound && backup.Status == api.BackupStatusDone
+		if latestDone {
+			latestDoneFound = true
+		}
+		if backup.Type != api.BackupTypeAutomatic || backup.Status == api.BackupStatusPendingCreate {
+			continue
+		}
+
+		automaticCount++
+		keep := latestDone ||
+			backupSetting.RetentionCount > 0 && automaticCount <= backupSetting.RetentionCount ||
+			backupSetting.RetentionDays > 0 && backup.CreatedTs > retentionTs
+		if !keep {
+			expiredList = append(expiredList, backup)
+		}
+	}
+	return expiredList
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestGetExpiredBackupList(t *testing.T) {
+	now := time.Date(2021, 11, 20, 12, 0, 0, 0, time.UTC)
+	day := int64(24 * time.Hour / time.Second)
+	backup := func(id int, daysAgo int64, backupType api.BackupType, status api.BackupStatus) *api.Backup {
+		return &api.Backup{
+			ID:        id,
+			CreatedTs: now.Unix() - daysAgo*day,
+			Type:      backupType,
+			Status:    status,
+		}
+	}
+	automatic := func(id int, daysAgo int64) *api.Backup {
+		return backup(id, daysAgo, api.BackupTypeAutomatic, api.BackupStatusDone)
+	}
+
+	type test struct {
+		name           string
+		backupList     []*api.Backup
+		retentionCount int
+		retentionDays  int
+		// The IDs of the expired backups, most recent first.
+		want []int
+	}
+
+	tests := []test{
+		{
+			name:       "retention disabled",
+			backupList: []*api.Backup{automatic(1, 30), automatic(2, 20)},
+			want:       nil,
+		},
+		{
+			name:           "retention count",
+			backupList:     []*api.Backup{automatic(1, 4), automatic(2, 3), automatic(3, 2), automatic(4, 1)},
+			retentionCount: 2,
+			want:           []int{2, 1},
+		},
+		{
+			name:          "retention days",
+			backupList:    []*api.Backup{automatic(1, 10), automatic(2, 8), automatic(3, 6)},
+			retentionDays: 7,
+			want:          []int{2, 1},
+		},
+		{
+			name:           "kept by either rule",
+			backupList:     []*api.Backup{automatic(1, 10), automatic(2, 8), automatic(3, 2), automatic(4, 1)},
+			retentionCount: 3,
+			retentionDays:  5,
+			want:           []int{1},
+		},
+		{
+			name: "retention days boundary",
+			backupList: []*api.Backup{
+				{ID: 1, CreatedTs: now.Unix() - 7*day - 1, Type: api.BackupTypeAutomatic, Status: api.BackupStatusDone},
+				{ID: 2, CreatedTs: now.Unix() - 7*day, Type: api.BackupTypeAutomatic, Status: api.BackupStatusDone},
+				{ID: 3, CreatedTs: now.Unix() - 7*day + 1, Type: api.BackupTypeAutomatic, Status: api.BackupStatusDone},
+			},
+			retentionDays: 7,
+			want:          []int{2, 1},
+		},
+		{
+			name:          "only DONE backup",
+			backupList:    []*api.Backup{automatic(1, 30)},
+			retentionDays: 7,
+			want:          nil,
+		},
+		{
+			name: "most recent DONE backup",
+			backupList: []*api.Backup{
+				automatic(1, 30),
+				automatic(2, 20),
+				backup(3, 10, api.BackupTypeAutomatic, api.BackupStatusFailed),
+			},
+			retentionDays: 7,
+			want:          []int{3, 1},
+		},
+		{
+			name: "most recent DONE backup with older manual backup",
+			backupList: []*api.Backup{
+				backup(1, 40, api.BackupTypeManual, api.BackupStatusDone),
+				automatic(2, 30),
+				automatic(3, 20),
+			},
+			retentionDays: 7,
+			want:          []int{2},
+		},
+		{
+			name: "most recent DONE backup is manual",
+			backupList: []*api.Backup{
+				automatic(1, 30),
+				automatic(2, 20),
+				backup(3, 10, api.BackupTypeManual, api.BackupStatusDone),
+			},
+			retentionDays: 7,
+			want:          []int{2, 1},
+		},
+		{
+			name: "backup in progress",
+			backupList: []*api.Backup{
+				automatic(1, 30),
+				backup(2, 20, api.BackupTypeAutomatic, api.BackupStatusPendingCreate),
+				automatic(3, 1),
+			},
+			retentionCount: 1,
+			want:           []int{1},
+		},
+		{
+			name:           "same created time",
+			backupList:     []*api.Backup{automatic(2, 1), automatic(1, 1), automatic(3, 1)},
+			retentionCount: 1,
+			want:           []int{2, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		backupSetting := &api.BackupSetting{
+			RetentionCount: tc.retentionCount,
+			RetentionDays:  tc.retentionDays,
+		}
+		var got []int
+		for _, backup := range getExpiredBackupList(tc.backupList, backupSetting, now) {
+			got = append(got, backup.ID)
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
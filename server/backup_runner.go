@@ -30,6 +30,9 @@ type BackupRunner struct {
 // Run is the runner for backup runner.
 func (s *BackupRunner) Run() error {
 	go func() {
+		// Take the backups scheduled earlier in the hour the server starts, which may have been missed while the
+		// server was down. They're not taken twice since the automatic backups are named after the scheduled time.
+		lastCheckTime := time.Now().UTC().Truncate(time.Hour).Add(-time.Nanosecond)
 		for {
 			func() {
 				defer func() {
@@ -41,19 +44,33 @@ func (s *BackupRunner) Run() error {
 						s.l.Error("Backup runner PANIC RECOVER", zap.Error(err))
 					}
 				}()
-				// Find all databases that need a backup in this hour.
-				t := time.Now().UTC().Truncate(time.Hour)
-
-				match := &api.BackupSettingsMatch{
-					Hour:      t.Hour(),
-					DayOfWeek: int(t.Weekday()),
-				}
-				list, err := s.server.BackupService.FindBackupSettingsMatch(context.Background(), match)
+				// Find all databases that need a backup since the last check.
+				now := time.Now().UTC()
+				enabled := true
+				list, err := s.server.BackupService.FindBackupSettingList(context.Background(), &api.BackupSettingFind{Enabled: &enabled})
 				if err != nil {
-					s.l.Error("Failed to retrieve backup settings match", zap.Error(err))
+					s.l.Error("Failed to retrieve enabled backup settings", zap.Error(err))
+					return
 				}
+				checkTime := lastCheckTime
+				lastCheckTime = now
 
 				for _, backupSetting := range list {
+					schedule, err := getBackupSchedule(backupSetting)
+					if err != nil {
+						s.l.Error("Invalid backup schedule",
+							zap.Int("id", backupSetting.ID),
+							zap.String("schedule", backupSetting.Schedule),
+							zap.Error(err))
+						continue
+					}
+					// Only take the latest backup if several are due, e.g. the runner interval is longer than the
+					// schedule interval.
+					t := schedule.Last(checkTime, now)
+					if t.IsZero() {
+						continue
+					}
+
 					databaseFind := &api.DatabaseFind{
 						ID: &backupSetting.DatabaseId,
 					}
@@ -65,16 +82,19 @@ func (s *BackupRunner) Run() error {
 							zap.String("error", err.Error()))
 						continue
 					}
+					if !isBackupSupported(database.Instance.Engine) {
+						continue
+					}
 					backupSetting.Database = database
 
-					backupName := t.Format("20060102T030405")
-					go func(database *api.Database, backupName string) {
-						if err := s.scheduleBackupTask(database, backupName); err != nil {
+					backupName := t.Format("20060102T150405")
+					go func(backupSetting *api.BackupSetting, backupName string) {
+						if err := s.scheduleBackupTask(backupSetting, backupName); err != nil {
 							s.l.Error("Failed to create automatic backup for database",
-								zap.Int("databaseID", database.ID),
+								zap.Int("databaseID", backupSetting.DatabaseId),
 								zap.String("error", err.Error()))
 						}
-					}(database, backupName)
+					}(backupSetting, backupName)
 				}
 			}()
 
@@ -85,21 +105,27 @@ func (s *BackupRunner) Run() error {
 	return nil
 }
 
-func (s *BackupRunner) scheduleBackupTask(database *api.Database, backupName string) error {
-	path, err := getAndCreateBackupPath(s.server.dataDir, database, fmt.Sprintf("%s-autobackup", backupName))
+func (s *BackupRunner) scheduleBackupTask(backupSetting *api.BackupSetting, backupName string) error {
+	database := backupSetting.Database
+	storageBackend, path, storageLocation, err := getBackupStorageAndPath(s.server.dataDir, database, fmt.Sprintf("%s-autobackup", backupName), backupSetting.Compression, backupSetting.Encrypted)
 	if err != nil {
 		return err
 	}
 
 	backupCreate := &api.BackupCreate{
-		CreatorId:      api.SYSTEM_BOT_ID,
-		DatabaseId:     database.ID,
-		Name:           backupName,
-		Status:         api.BackupStatusPendingCreate,
-		Type:           api.BackupTypeAutomatic,
-		StorageBackend: api.BackupStorageBackendLocal,
-		Path:           path,
-		Comment:        fmt.Sprintf("Automatic backup for database %s.", database.Name),
+		CreatorId:              api.SYSTEM_BOT_ID,
+		DatabaseId:             database.ID,
+		Name:                   backupName,
+		Status:                 api.BackupStatusPendingCreate,
+		Type:                   api.BackupTypeAutomatic,
+		StorageBackend:         storageBackend,
+		Path:                   path,
+		StorageLocation:        storageLocation,
+		Comment:                fmt.Sprintf("Automatic backup for database %s.", database.Name),
+		Compression:            backupSetting.Compression,
+		Encrypted:              backupSetting.Encrypted,
+		VerificationEnabled:    backupSetting.VerificationEnabled,
+		VerificationInstanceId: backupSetting.VerificationInstanceId,
 	}
 
 	backup, err := s.server.BackupService.CreateBackup(context.Background(), backupCreate)
@@ -112,7 +138,8 @@ func (s *BackupRunner) scheduleBackupTask(database *api.Database, backupName str
 	}
 
 	payload := api.TaskDatabaseBackupPayload{
-		BackupID: backup.ID,
+		BackupID:               backup.ID,
+		VerificationInstanceID: backupSetting.VerificationInstanceId,
 	}
 	bytes, err := json.Marshal(payload)
 	if err != nil {
@@ -151,5 +178,6 @@ func (s *BackupRunner) scheduleBackupTask(database *api.Database, backupName str
 	if err != nil {
 		return fmt.Errorf("failed to create task: %v", err)
 	}
+	s.server.TaskScheduler.Notify(createdPipeline.ID)
 	return nil
 }
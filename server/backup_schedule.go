@@ -0,0 +1,184 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+// backupSchedule is a parsed cron expression of the standard 5 fields: minute, hour, day of month, month and day of
+// week. Each field supports *, lists (1,3), ranges (1-5) and steps (*/2, 1-10/3), and the day of week is 0-7 where
+// both 0 and 7 are Sunday. Like cron, the day matches if either the day of month or the day of week matches when
+// both are restricted.
+type backupSchedule struct {
+	minute     uint64
+	hour       uint64
+	dayOfMonth uint64
+	month      uint64
+	dayOfWeek  uint64
+	// domStar and dowStar are true if the day of month and the day of week are unrestricted, i.e. start with *.
+	domStar bool
+	dowStar bool
+}
+
+type cronFieldBound struct {
+	name string
+	min  int
+	max  int
+}
+
+var (
+	cronFieldBoundList = []cronFieldBound{
+		{name: "minute", min: 0, max: 59},
+		{name: "hour", min: 0, max: 23},
+		{name: "day of month", min: 1, max: 31},
+		{name: "month", min: 1, max: 12},
+		{name: "day of week", min: 0, max: 7},
+	}
+	cronMacroList = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// parseBackupSchedule parses the cron expression, which can also be one of the macros such as @daily and @hourly.
+func parseBackupSchedule(expr string) (*backupSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if v, ok := cronMacroList[strings.ToLower(expr)]; ok {
+		expr = v
+	}
+	fieldList := strings.Fields(expr)
+	if len(fieldList) != len(cronFieldBoundList) {
+		return nil, fmt.Errorf("invalid cron expression %q, expect %d fields but got %d", expr, len(cronFieldBoundList), len(fieldList))
+	}
+
+	var bitsList []uint64
+	for i, field := range fieldList {
+		bits, err := parseCronField(field, cronFieldBoundList[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		bitsList = append(bitsList, bits)
+	}
+	schedule := &backupSchedule{
+		minute:     bitsList[0],
+		hour:       bitsList[1],
+		dayOfMonth: bitsList[2],
+		month:      bitsList[3],
+		dayOfWeek:  bitsList[4],
+		domStar:    strings.HasPrefix(fieldList[2], "*"),
+		dowStar:    strings.HasPrefix(fieldList[4], "*"),
+	}
+	// Both 0 and 7 are Sunday.
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+	return schedule, nil
+}
+
+// parseCronField returns the bit set of the values matched by the field.
+func parseCronField(field string, bound cronFieldBound) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			v, err := strconv.Atoi(part[i+1:])
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid step %q of %s", part[i+1:], bound.name)
+			}
+			rangePart, step = part[:i], v
+		}
+
+		start, end := bound.min, bound.max
+		if rangePart != "*" {
+			var err error
+			bounds := strings.SplitN(rangePart, "-", 2)
+			if start, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", bound.name, bounds[0])
+			}
+			end = start
+			if len(bounds) == 2 {
+				if end, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", bound.name, bounds[1])
+				}
+			} else if step > 1 {
+				// a/n is the same as a-max/n.
+				end = bound.max
+			}
+		}
+		if start < bound.min || end > bound.max || start > end {
+			return 0, fmt.Errorf("%s %q is out of range %d-%d", bound.name, rangePart, bound.min, bound.max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matchDay returns true if the day of t matches the day of month and the day of week.
+func (s *backupSchedule) matchDay(t time.Time) bool {
+	domMatch := s.dayOfMonth&(1<<uint(t.Day())) != 0
+	dowMatch := s.dayOfWeek&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first time matching the schedule after t, in the location of t. It returns the zero time if
+// there is none within 5 years, e.g. for February 30th.
+func (s *backupSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// Last returns the last time matching the schedule in (start, end], or the zero time if there is none.
+func (s *backupSchedule) Last(start time.Time, end time.Time) time.Time {
+	var last time.Time
+	for t := s.Next(start); !t.IsZero() && !t.After(end); t = s.Next(t) {
+		last = t
+	}
+	return last
+}
+
+// getBackupSchedule returns the schedule of the automatic backups of the backup setting. It's the weekly schedule
+// at Hour on DayOfWeek, or the daily one if DayOfWeek is -1, unless the cron expression Schedule is set.
+func getBackupSchedule(backupSetting *api.BackupSetting) (*backupSchedule, error) {
+	if backupSetting.Schedule != "" {
+		return parseBackupSchedule(backupSetting.Schedule)
+	}
+	dayOfWeek := "*"
+	if backupSetting.DayOfWeek >= 0 {
+		dayOfWeek = strconv.Itoa(backupSetting.DayOfWeek)
+	}
+	return parseBackupSchedule(fmt.Sprintf("0 %d * * %s", backupSetting.Hour, dayOfWeek))
+}
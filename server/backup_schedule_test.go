@@ -0,0 +1,268 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestParseBackupSchedule(t *testing.T) {
+	bits := func(valueList ...int) uint64 {
+		var b uint64
+		for _, v := range valueList {
+			b |= 1 << uint(v)
+		}
+		return b
+	}
+	bitRange := func(start, end, step int) uint64 {
+		var b uint64
+		for v := start; v <= end; v += step {
+			b |= 1 << uint(v)
+		}
+		return b
+	}
+
+	tests := []struct {
+		expr string
+		want backupSchedule
+	}{
+		{
+			expr: "* * * * *",
+			want: backupSchedule{minute: bitRange(0, 59, 1), hour: bitRange(0, 23, 1), dayOfMonth: bitRange(1, 31, 1), month: bitRange(1, 12, 1), dayOfWeek: bitRange(0, 7, 1), domStar: true, dowStar: true},
+		},
+		{
+			expr: "5,10,15 1-3 1,15-17 */4 1-5",
+			want: backupSchedule{minute: bits(5, 10, 15), hour: bits(1, 2, 3), dayOfMonth: bits(1, 15, 16, 17), month: bits(1, 5, 9), dayOfWeek: bits(1, 2, 3, 4, 5)},
+		},
+		{
+			expr: "*/15 0-23/6 10/7 2-11/3 *",
+			want: backupSchedule{minute: bits(0, 15, 30, 45), hour: bits(0, 6, 12, 18), dayOfMonth: bits(10, 17, 24, 31), month: bits(2, 5, 8, 11), dayOfWeek: bitRange(0, 7, 1), dowStar: true},
+		},
+		// Both 0 and 7 are Sunday.
+		{
+			expr: "0 0 * * 7",
+			want: backupSchedule{minute: bits(0), hour: bits(0), dayOfMonth: bitRange(1, 31, 1), month: bitRange(1, 12, 1), dayOfWeek: bits(0, 7), domStar: true},
+		},
+		// The day of month is restricted by */2 but still counted as unrestricted, the same as cron.
+		{
+			expr: "59 23 */2 12 5,6",
+			want: backupSchedule{minute: bits(59), hour: bits(23), dayOfMonth: bitRange(1, 31, 2), month: bits(12), dayOfWeek: bits(5, 6), domStar: true},
+		},
+		{
+			expr: " @Daily ",
+			want: backupSchedule{minute: bits(0), hour: bits(0), dayOfMonth: bitRange(1, 31, 1), month: bitRange(1, 12, 1), dayOfWeek: bitRange(0, 7, 1), domStar: true, dowStar: true},
+		},
+		{
+			expr: "@weekly",
+			want: backupSchedule{minute: bits(0), hour: bits(0), dayOfMonth: bitRange(1, 31, 1), month: bitRange(1, 12, 1), dayOfWeek: bits(0), domStar: true},
+		},
+	}
+	for _, tc := range tests {
+		got, err := parseBackupSchedule(tc.expr)
+		if err != nil {
+			t.Errorf("expr=%q: unexpected error %v", tc.expr, err)
+			continue
+		}
+		if *got != tc.want {
+			t.Errorf("expr=%q: expected %+v, got %+v", tc.expr, tc.want, *got)
+		}
+	}
+}
+
+func TestParseBackupScheduleInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"@reboot",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"-1 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * JAN *",
+		"* * * * MON",
+		"5-1 * * * *",
+		"1-2-3 * * * *",
+		"*/0 * * * *",
+		"*/-1 * * * *",
+		"*/ * * * *",
+		"1/2/3 * * * *",
+		"1, * * * *",
+		",1 * * * *",
+		"a * * * *",
+		"** * * * *",
+		"*-5 * * * *",
+		"? * * * *",
+		"L * * * *",
+	} {
+		if schedule, err := parseBackupSchedule(expr); err == nil {
+			t.Errorf("expr=%q: expected error, got %+v", expr, *schedule)
+		}
+	}
+}
+
+func TestBackupScheduleNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatalf("invalid time %q: %v", s, err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr  string
+		start string
+		want  string
+	}{
+		{expr: "* * * * *", start: "2021-11-20 10:00", want: "2021-11-20 10:01"},
+		// The seconds are truncated.
+		{expr: "30 10 * * *", start: "2021-11-20 10:29", want: "2021-11-20 10:30"},
+		{expr: "30 10 * * *", start: "2021-11-20 10:30", want: "2021-11-21 10:30"},
+		{expr: "*/20 * * * *", start: "2021-11-20 23:50", want: "2021-11-21 00:00"},
+		{expr: "0 0 1 * *", start: "2021-12-15 00:00", want: "2022-01-01 00:00"},
+		{expr: "0 0 31 * *", start: "2021-04-01 00:00", want: "2021-05-31 00:00"},
+		{expr: "0 0 29 2 *", start: "2021-03-01 00:00", want: "2024-02-29 00:00"},
+		// Only the day of week is restricted, 2021-11-20 is Saturday.
+		{expr: "0 3 * * 1", start: "2021-11-20 00:00", want: "2021-11-22 03:00"},
+		{expr: "0 3 * * 0", start: "2021-11-20 00:00", want: "2021-11-21 03:00"},
+		{expr: "0 3 * * 7", start: "2021-11-20 00:00", want: "2021-11-21 03:00"},
+		// Both are restricted, so either the day of month or the day of week matches.
+		{expr: "0 0 13 * 5", start: "2021-11-20 00:00", want: "2021-11-26 00:00"},
+		{expr: "0 0 13 * 5", start: "2021-11-26 00:00", want: "2021-12-03 00:00"},
+		{expr: "0 0 1,15 * 1", start: "2021-11-29 00:00", want: "2021-12-01 00:00"},
+		// The day of month with a step counts as unrestricted, so both must match.
+		{expr: "0 0 */2 * 1", start: "2021-11-20 00:00", want: "2021-11-29 00:00"},
+		{expr: "0 0 * 2-12/2 1", start: "2021-11-20 00:00", want: "2021-12-06 00:00"},
+		// Never matches.
+		{expr: "0 0 30 2 *", start: "2021-11-20 00:00", want: ""},
+	}
+	for _, tc := range tests {
+		schedule, err := parseBackupSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("expr=%q: unexpected error %v", tc.expr, err)
+		}
+		got := schedule.Next(utc(tc.start).Add(30 * time.Second))
+		if tc.want == "" {
+			if !got.IsZero() {
+				t.Errorf("expr=%q start=%s: expected no match, got %s", tc.expr, tc.start, got)
+			}
+			continue
+		}
+		if !got.Equal(utc(tc.want)) {
+			t.Errorf("expr=%q start=%s: expected %s, got %s", tc.expr, tc.start, tc.want, got)
+		}
+	}
+}
+
+func TestBackupScheduleLast(t *testing.T) {
+	utc := func(s string) time.Time {
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatalf("invalid time %q: %v", s, err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		start string
+		end   string
+		want  string
+	}{
+		{
+			name:  "start is excluded",
+			expr:  "0 * * * *",
+			start: "2021-11-20 10:00",
+			end:   "2021-11-20 10:59",
+			want:  "",
+		},
+		{
+			name:  "end is included",
+			expr:  "0 * * * *",
+			start: "2021-11-20 10:00",
+			end:   "2021-11-20 11:00",
+			want:  "2021-11-20 11:00",
+		},
+		{
+			name:  "latest of several",
+			expr:  "*/15 * * * *",
+			start: "2021-11-20 10:00",
+			end:   "2021-11-20 11:20",
+			want:  "2021-11-20 11:15",
+		},
+		{
+			name:  "across month",
+			expr:  "0 0 1,15 * *",
+			start: "2021-11-20 00:00",
+			end:   "2021-12-10 00:00",
+			want:  "2021-12-01 00:00",
+		},
+		{
+			name:  "across month and year",
+			expr:  "0 23 * * *",
+			start: "2021-12-31 22:00",
+			end:   "2022-01-01 01:00",
+			want:  "2021-12-31 23:00",
+		},
+		{
+			name:  "last day of month",
+			expr:  "0 0 28-31 * *",
+			start: "2021-02-27 00:00",
+			end:   "2021-03-27 00:00",
+			want:  "2021-02-28 00:00",
+		},
+	}
+	for _, tc := range tests {
+		schedule, err := parseBackupSchedule(tc.expr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tc.name, err)
+		}
+		got := schedule.Last(utc(tc.start), utc(tc.end))
+		if tc.want == "" {
+			if !got.IsZero() {
+				t.Errorf("%s: expected no match, got %s", tc.name, got)
+			}
+			continue
+		}
+		if !got.Equal(utc(tc.want)) {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestGetBackupSchedule(t *testing.T) {
+	start := time.Date(2021, 11, 20, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		backupSetting *api.BackupSetting
+		want          time.Time
+	}{
+		{
+			backupSetting: &api.BackupSetting{Hour: 8, DayOfWeek: -1},
+			want:          time.Date(2021, 11, 20, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			backupSetting: &api.BackupSetting{Hour: 8, DayOfWeek: 1},
+			want:          time.Date(2021, 11, 22, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			backupSetting: &api.BackupSetting{Hour: 8, DayOfWeek: 1, Schedule: "0 */6 * * *"},
+			want:          time.Date(2021, 11, 20, 6, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tc := range tests {
+		schedule, err := getBackupSchedule(tc.backupSetting)
+		if err != nil {
+			t.Fatalf("backupSetting=%+v: unexpected error %v", tc.backupSetting, err)
+		}
+		if got := schedule.Next(start); !got.Equal(tc.want) {
+			t.Errorf("backupSetting=%+v: expected %s, got %s", tc.backupSetting, tc.want, got)
+		}
+	}
+}
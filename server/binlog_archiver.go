@@ -0,0 +1,327 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/bin/bb/connect"
+	"github.com/bytebase/bytebase/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// BINLOG_ARCHIVE_INTERVAL is the interval for the binlog archiver to archive the rotated binlog files. The file
+	// being written is only archived on demand by the point-in-time recovery, so the data loss if the instance is lost
+	// is bounded by the binlog rotation, see max_binlog_size.
+	BINLOG_ARCHIVE_INTERVAL = time.Duration(1) * time.Minute
+)
+
+// NewBinlogArchiver creates a new binlog archiver.
+func NewBinlogArchiver(logger *zap.Logger, server *Server) *BinlogArchiver {
+	return &BinlogArchiver{
+		l:      logger,
+		server: server,
+	}
+}
+
+// BinlogArchiver copies the binlog files of the MySQL instances with BinlogArchiveEnabled to the data directory using
+// mysqlbinlog, so that the changes after a backup can be replayed for the point-in-time recovery.
+type BinlogArchiver struct {
+	l      *zap.Logger
+	server *Server
+	// mu serializes archiving, since the restore archives the instance on demand.
+	mu sync.Mutex
+}
+
+// Run is the runner for binlog archiver.
+func (s *BinlogArchiver) Run() error {
+	go func() {
+		for {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						err, ok := r.(error)
+						if !ok {
+							err = fmt.Errorf("%v", r)
+						}
+						s.l.Error("Binlog archiver PANIC RECOVER", zap.Error(err))
+					}
+				}()
+
+				s.archive()
+			}()
+
+			time.Sleep(BINLOG_ARCHIVE_INTERVAL)
+		}
+	}()
+
+	return nil
+}
+
+func (s *BinlogArchiver) archive() {
+	rowStatus := api.Normal
+	instanceList, err := s.server.InstanceService.FindInstanceList(context.Background(), &api.InstanceFind{RowStatus: &rowStatus})
+	if err != nil {
+		s.l.Error("Failed to retrieve instances for binlog archive", zap.Error(err))
+		return
+	}
+
+	for _, instance := range instanceList {
+		if !instance.BinlogArchiveEnabled || instance.Engine != db.Mysql {
+			continue
+		}
+		if err := s.server.ComposeInstanceRelationship(context.Background(), instance); err != nil {
+			s.l.Error("Failed to compose instance for binlog archive",
+				zap.String("instance", instance.Name),
+				zap.Error(err))
+			continue
+		}
+		if err := s.ArchiveInstance(context.Background(), instance, false /* includeActive */); err != nil {
+			s.l.Error("Failed to archive binlog",
+				zap.String("instance", instance.Name),
+				zap.Error(err))
+			continue
+		}
+		if err := s.pruneInstance(context.Background(), instance); err != nil {
+			s.l.Error("Failed to prune archived binlog",
+				zap.String("instance", instance.Name),
+				zap.Error(err))
+		}
+	}
+}
+
+// ArchiveInstance copies the binlog files of the instance not archived yet. The last binlog file is being written,
+// so it's only copied if includeActive is set, otherwise it would be downloaded again and again as it grows.
+func (s *BinlogArchiver) ArchiveInstance(ctx context.Context, instance *api.Instance, includeActive bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dir, err := getAndCreateBinlogDirectory(s.server.dataDir, instance)
+	if err != nil {
+		return err
+	}
+
+	binlogList, err := getBinlogList(ctx, instance)
+	if err != nil {
+		return err
+	}
+	archivedSize := make(map[string]int64)
+	for _, binlog := range binlogList {
+		if fi, err := os.Stat(filepath.Join(dir, binlog.name)); err == nil {
+			archivedSize[binlog.name] = fi.Size()
+		}
+	}
+	for _, binlog := range getBinlogListToArchive(binlogList, archivedSize, includeActive) {
+		if err := downloadBinlog(ctx, instance, dir, binlog.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getBinlogListToArchive returns the binlog files in binlogList not archived yet, given the size of the archived
+// files. The binlog file only grows until it's rotated, so an archived file with the same size is complete. The last
+// binlog file in binlogList is the one being written, which is skipped unless includeActive is set.
+func getBinlogListToArchive(binlogList []binlogFile, archivedSize map[string]int64, includeActive bool) []binlogFile {
+	var list []binlogFile
+	for i, binlog := range binlogList {
+		if i == len(binlogList)-1 && !includeActive {
+			break
+		}
+		if size, ok := archivedSize[binlog.name]; ok && size == binlog.size {
+			continue
+		}
+		list = append(list, binlog)
+	}
+	return list
+}
+
+// pruneInstance deletes the archived binlog files older than the earliest backup of the instance which can be
+// recovered from.
+func (s *BinlogArchiver) pruneInstance(ctx context.Context, instance *api.Instance) error {
+	databaseList, err := s.server.DatabaseService.FindDatabaseList(ctx, &api.DatabaseFind{InstanceId: &instance.ID})
+	if err != nil {
+		return err
+	}
+	earliestFile := ""
+	for _, database := range databaseList {
+		rowStatus := api.Normal
+		backupList, err := s.server.BackupService.FindBackupList(ctx, &api.BackupFind{
+			RowStatus:  &rowStatus,
+			DatabaseId: &database.ID,
+		})
+		if err != nil {
+			return err
+		}
+		for _, backup := range backupList {
+			payload, err := getBackupPayload(backup)
+			if err != nil || payload.BinlogFile == "" {
+				continue
+			}
+			if earliestFile == "" || payload.BinlogFile < earliestFile {
+				earliestFile = payload.BinlogFile
+			}
+		}
+	}
+	// Keep all the binlog files until there is a backup to recover from.
+	if earliestFile == "" {
+		return nil
+	}
+
+	dir := filepath.Join(s.server.dataDir, getBinlogDirectory(instance))
+	fileList, err := getArchivedBinlogFileList(dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range fileList {
+		if name >= earliestFile {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+type binlogFile struct {
+	name string
+	size int64
+}
+
+// getBinlogList returns the binlog files on the instance.
+func getBinlogList(ctx context.Context, instance *api.Instance) ([]binlogFile, error) {
+	conn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, "", nil /* tlsConfig */)
+	if err != nil {
+		return nil, fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+	}
+	defer conn.Close()
+
+	rows, err := conn.DB.QueryContext(ctx, "SHOW BINARY LOGS;")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list binlog of instance %q, binlog may be disabled: %w", instance.Name, err)
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	var list []binlogFile
+	for rows.Next() {
+		// The columns vary among the versions, but the first two are always Log_name and File_size.
+		var binlog binlogFile
+		values := []interface{}{&binlog.name, &binlog.size}
+		for i := len(values); i < len(cols); i++ {
+			values = append(values, new(sql.RawBytes))
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		list = append(list, binlog)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// downloadBinlog copies the binlog file name of the instance to dir.
+func downloadBinlog(ctx context.Context, instance *api.Instance, dir string, name string) error {
+	args := append(mysqlClientArgs(instance), "--read-from-remote-server", "--raw", "--result-file", dir+string(filepath.Separator), name)
+	cmd := exec.CommandContext(ctx, "mysqlbinlog", args...)
+	cmd.Env = append(os.Environ(), "MYSQL_PWD="+instance.Password)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to download binlog %q: %w, output: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// mysqlClientArgs returns the args of the mysql client programs to connect the instance. The password is passed by
+// the MYSQL_PWD environment variable instead, so that it doesn't show up in the process list.
+func mysqlClientArgs(instance *api.Instance) []string {
+	args := []string{"--host", instance.Host, "--user", instance.Username}
+	if instance.Port != "" {
+		args = append(args, "--port", instance.Port)
+	}
+	return args
+}
+
+// getBinlogDirectory returns the directory of the archived binlog of the instance, relative to the data directory.
+func getBinlogDirectory(instance *api.Instance) string {
+	return filepath.Join("backup", "instance", strconv.Itoa(instance.ID), "binlog")
+}
+
+// getAndCreateBinlogDirectory returns the absolute directory of the archived binlog of the instance.
+func getAndCreateBinlogDirectory(dataDir string, instance *api.Instance) (string, error) {
+	dir := filepath.Join(dataDir, getBinlogDirectory(instance))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// getArchivedBinlogFileList returns the names of the archived binlog files in dir in order. The binlog files are
+// named as {basename}.{sequence number} with the fixed-length sequence number, so they're in order by name.
+func getArchivedBinlogFileList(dir string) ([]string, error) {
+	fileInfoList, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []string
+	for _, fi := range fileInfoList {
+		if !fi.IsDir() {
+			list = append(list, fi.Name())
+		}
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// getBackupPayload returns the payload of the backup, which is empty for the backups taken before it's recorded.
+func getBackupPayload(backup *api.Backup) (*api.BackupPayload, error) {
+	payload := &api.BackupPayload{}
+	if backup.Payload == "" {
+		return payload, nil
+	}
+	if err := json.Unmarshal([]byte(backup.Payload), payload); err != nil {
+		return nil, fmt.Errorf("invalid payload of backup %q: %w", backup.Name, err)
+	}
+	return payload, nil
+}
+
+// getRecoverableBackup returns the latest successful backup in backupList taken in a snapshot before ts, from which
+// the database can be recovered to ts by replaying the archived binlog. It returns nil if there is none.
+func getRecoverableBackup(backupList []*api.Backup, ts int64) *api.Backup {
+	var recoverableBackup *api.Backup
+	var recoverableTs int64
+	for _, backup := range backupList {
+		if backup.Status != api.BackupStatusDone {
+			continue
+		}
+		payload, err := getBackupPayload(backup)
+		if err != nil || payload.BinlogFile == "" || payload.SnapshotTs > ts {
+			continue
+		}
+		if recoverableBackup == nil || payload.SnapshotTs > recoverableTs {
+			recoverableBackup = backup
+			recoverableTs = payload.SnapshotTs
+		}
+	}
+	return recoverableBackup
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestGetBinlogListToArchive(t *testing.T) {
+	binlogList := []binlogFile{
+		{name: "binlog.000001", size: 100},
+		{name: "binlog.000002", size: 200},
+		{name: "binlog.000003", size: 300},
+	}
+
+	type test struct {
+		name          string
+		archivedSize  map[string]int64
+		includeActive bool
+		want          []string
+	}
+
+	tests := []test{
+		{
+			name:         "nothing archived",
+			archivedSize: map[string]int64{},
+			want:         []string{"binlog.000001", "binlog.000002"},
+		},
+		{
+			name:          "nothing archived including active",
+			archivedSize:  map[string]int64{},
+			includeActive: true,
+			want:          []string{"binlog.000001", "binlog.000002", "binlog.000003"},
+		},
+		{
+			name: "active archived on demand and grown since",
+			archivedSize: map[string]int64{
+				"binlog.000001": 100,
+				"binlog.000002": 200,
+				"binlog.000003": 150,
+			},
+			want: nil,
+		},
+		{
+			name: "rotated after archived on demand",
+			archivedSize: map[string]int64{
+				"binlog.000001": 100,
+				"binlog.000002": 120,
+			},
+			want: []string{"binlog.000002"},
+		},
+		{
+			name: "all archived",
+			archivedSize: map[string]int64{
+				"binlog.000001": 100,
+				"binlog.000002": 200,
+				"binlog.000003": 300,
+			},
+			includeActive: true,
+			want:          nil,
+		},
+	}
+
+	for _, tc := range tests {
+		var got []string
+		for _, binlog := range getBinlogListToArchive(binlogList, tc.archivedSize, tc.includeActive) {
+			got = append(got, binlog.name)
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+	if got := getBinlogListToArchive(nil, map[string]int64{}, false); len(got) != 0 {
+		t.Errorf("expected nothing to archive without binlog, got %v", got)
+	}
+}
+
+func TestGetRecoverableBackup(t *testing.T) {
+	backup := func(id int, status api.BackupStatus, payload *api.BackupPayload) *api.Backup {
+		b := &api.Backup{ID: id, Status: status}
+		if payload != nil {
+			bytes, err := json.Marshal(payload)
+			if err != nil {
+				t.Fatalf("failed to marshal backup payload: %v", err)
+			}
+			b.Payload = string(bytes)
+		}
+		return b
+	}
+	withBinlog := func(snapshotTs int64) *api.BackupPayload {
+		return &api.BackupPayload{BinlogFile: "binlog.000001", BinlogPosition: 4, SnapshotTs: snapshotTs}
+	}
+	backupList := []*api.Backup{
+		backup(1, api.BackupStatusDone, withBinlog(100)),
+		backup(2, api.BackupStatusDone, withBinlog(300)),
+		backup(3, api.BackupStatusDone, withBinlog(200)),
+		// Taken before the binlog archive is enabled.
+		backup(4, api.BackupStatusDone, &api.BackupPayload{SnapshotTs: 250}),
+		// Taken before the payload is recorded.
+		backup(5, api.BackupStatusDone, nil),
+		backup(6, api.BackupStatusFailed, withBinlog(260)),
+		backup(7, api.BackupStatusPendingCreate, withBinlog(270)),
+		{ID: 8, Status: api.BackupStatusDone, Payload: "{invalid"},
+	}
+
+	tests := []struct {
+		ts   int64
+		want int
+	}{
+		{ts: 50, want: 0},
+		{ts: 100, want: 1},
+		{ts: 199, want: 1},
+		{ts: 200, want: 3},
+		{ts: 280, want: 3},
+		{ts: 300, want: 2},
+		{ts: 1000, want: 2},
+	}
+
+	for _, tc := range tests {
+		got := 0
+		if backup := getRecoverableBackup(backupList, tc.ts); backup != nil {
+			got = backup.ID
+		}
+		if got != tc.want {
+			t.Errorf("ts=%d: expected backup %d, got %d", tc.ts, tc.want, got)
+		}
+	}
+	if backup := getRecoverableBackup(nil, 100); backup != nil {
+		t.Errorf("expected no backup to recover from, got %d", backup.ID)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/db"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 )
@@ -260,6 +262,109 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		return nil
 	})
 
+	g.POST("/database/:id/diff", func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("id"))).SetInternal(err)
+		}
+		targetId, err := strconv.Atoi(c.QueryParam("target"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Target database ID is not a number: %s", c.QueryParam("target"))).SetInternal(err)
+		}
+		if targetId == id {
+			return echo.NewHTTPError(http.StatusBadRequest, "Target database must be different from the source database")
+		}
+
+		var databaseList []*api.Database
+		for _, databaseId := range []int{id, targetId} {
+			databaseFind := &api.DatabaseFind{
+				ID: &databaseId,
+			}
+			database, err := s.ComposeDatabaseByFind(context.Background(), databaseFind)
+			if err != nil {
+				if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", databaseId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", databaseId)).SetInternal(err)
+			}
+			databaseList = append(databaseList, database)
+		}
+		source, target := databaseList[0], databaseList[1]
+		if source.Instance.Engine != target.Instance.Engine {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot diff %s database with %s database", source.Instance.Engine, target.Instance.Engine))
+		}
+
+		sourceSchema, err := s.fetchDatabaseSchema(context.Background(), source)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch schema for database: %v", source.Name)).SetInternal(err)
+		}
+		targetSchema, err := s.fetchDatabaseSchema(context.Background(), target)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch schema for database: %v", target.Name)).SetInternal(err)
+		}
+		statementList, err := db.DiffSchema(source.Instance.Engine, sourceSchema, targetSchema)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+
+		diff := &api.DatabaseDiff{
+			SourceDatabaseId: source.ID,
+			TargetDatabaseId: target.ID,
+			StatementList:    statementList,
+		}
+		if len(statementList) > 0 {
+			diff.Statement = strings.Join(statementList, ";\n") + ";"
+		}
+
+		if c.QueryParam("createIssue") == "true" {
+			if diff.Statement == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Database %q already matches database %q, no issue to create", target.Name, source.Name))
+			}
+			taskStatus := api.TaskPendingApproval
+			if target.Instance.Environment.ApprovalPolicy == api.ManualApprovalNever {
+				taskStatus = api.TaskPending
+			}
+			name := fmt.Sprintf("Sync schema of %s from %s", target.Name, source.Name)
+			issueCreate := &api.IssueCreate{
+				ProjectId: target.ProjectId,
+				Pipeline: api.PipelineCreate{
+					StageList: []api.StageCreate{
+						{
+							EnvironmentId: target.Instance.EnvironmentId,
+							TaskList: []api.TaskCreate{
+								{
+									InstanceId: target.InstanceId,
+									DatabaseId: &target.ID,
+									Name:       name,
+									Status:     taskStatus,
+									Type:       api.TaskDatabaseSchemaUpdate,
+									Statement:  diff.Statement,
+								},
+							},
+							Name: target.Instance.Environment.Name,
+						},
+					},
+					Name: fmt.Sprintf("Pipeline - %s", name),
+				},
+				Name:        name,
+				Type:        api.IssueDatabaseSchemaUpdate,
+				Description: fmt.Sprintf("Apply the DDL generated by comparing database %q on instance %q with database %q on instance %q.", target.Name, target.Instance.Name, source.Name, source.Instance.Name),
+				AssigneeId:  api.SYSTEM_BOT_ID,
+			}
+			issue, err := s.CreateIssue(context.Background(), issueCreate, c.Get(GetPrincipalIdContextKey()).(int))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create schema update issue from the diff").SetInternal(err)
+			}
+			diff.IssueId = &issue.ID
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, diff); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal database diff response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.POST("/database/:id/backup", func(c echo.Context) error {
 		id, err := strconv.Atoi(c.Param("id"))
 		if err != nil {
@@ -283,9 +388,23 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
 		}
 
-		backupCreate.Path, err = getAndCreateBackupPath(s.dataDir, database, backupCreate.Name)
+		if !isBackupSupported(database.Instance.Engine) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup is not supported for %s instance", database.Instance.Engine))
+		}
+
+		if err := s.validateBackupEncoding(&backupCreate.Compression, backupCreate.Encrypted); err != nil {
+			return err
+		}
+
+		if backupCreate.VerificationEnabled {
+			if err := s.validateBackupVerificationInstance(context.Background(), database, backupCreate.VerificationInstanceId); err != nil {
+				return err
+			}
+		}
+
+		backupCreate.StorageBackend, backupCreate.Path, backupCreate.StorageLocation, err = getBackupStorageAndPath(s.dataDir, database, backupCreate.Name, backupCreate.Compression, backupCreate.Encrypted)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create backup directory for database ID: %v", id)).SetInternal(err)
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to prepare backup storage for database ID: %v", id)).SetInternal(err)
 		}
 		// TODO(spinningbot): fetch the current migration history version.
 		backupCreate.MigrationHistoryVersion = "TODO(spinningbot)"
@@ -303,7 +422,8 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		}
 
 		payload := api.TaskDatabaseBackupPayload{
-			BackupID: backup.ID,
+			BackupID:               backup.ID,
+			VerificationInstanceID: backupCreate.VerificationInstanceId,
 		}
 		bytes, err := json.Marshal(payload)
 		if err != nil {
@@ -342,6 +462,7 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create task").SetInternal(err)
 		}
+		s.TaskScheduler.Notify(createdPipeline.ID)
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
 		if err := jsonapi.MarshalPayload(c.Response().Writer, backup); err != nil {
@@ -367,7 +488,10 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
 		}
 
+		// Skip the pruned backups.
+		rowStatus := api.Normal
 		backupFind := &api.BackupFind{
+			RowStatus:  &rowStatus,
 			DatabaseId: &id,
 		}
 		backupList, err := s.BackupService.FindBackupList(context.Background(), backupFind)
@@ -412,20 +536,104 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			}
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
 		}
-		backupFind := &api.BackupFind{
-			DatabaseId: &id,
-			ID:         &restoreBackup.BackupId,
+		if restoreBackup.PointInTimeTs != 0 {
+			if restoreBackup.TargetDatabaseName == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "Target database name is required for the point-in-time recovery")
+			}
+			if !database.Instance.BinlogArchiveEnabled {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Binlog archive is not enabled for instance %q", database.Instance.Name))
+			}
+			if restoreBackup.PointInTimeTs > time.Now().Unix() {
+				return echo.NewHTTPError(http.StatusBadRequest, "Cannot recover to a point in the future")
+			}
 		}
-		backup, err := s.BackupService.FindBackup(context.Background(), backupFind)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to backup find for database id: %d", id)).SetInternal(err)
+
+		var backup *api.Backup
+		if restoreBackup.PointInTimeTs != 0 && restoreBackup.BackupId == 0 {
+			rowStatus := api.Normal
+			backupFind := &api.BackupFind{
+				RowStatus:  &rowStatus,
+				DatabaseId: &id,
+			}
+			backupList, err := s.BackupService.FindBackupList(context.Background(), backupFind)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to backup list for database id: %d", id)).SetInternal(err)
+			}
+			if backup = getRecoverableBackup(backupList, restoreBackup.PointInTimeTs); backup == nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("No backup to recover from before %s", time.Unix(restoreBackup.PointInTimeTs, 0).UTC().Format(time.RFC3339)))
+			}
+		} else {
+			backupFind := &api.BackupFind{
+				DatabaseId: &id,
+				ID:         &restoreBackup.BackupId,
+			}
+			backup, err = s.BackupService.FindBackup(context.Background(), backupFind)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to backup find for database id: %d", id)).SetInternal(err)
+			}
+		}
+		if backup.RowStatus != api.Normal {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup %q has been pruned", backup.Name))
+		}
+		if restoreBackup.PointInTimeTs != 0 && getRecoverableBackup([]*api.Backup{backup}, restoreBackup.PointInTimeTs) == nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup %q cannot be recovered to %s, it must be a successful backup taken before that with binlog archive enabled", backup.Name, time.Unix(restoreBackup.PointInTimeTs, 0).UTC().Format(time.RFC3339)))
+		}
+
+		// Restore into the backup database itself unless a new target database is specified.
+		instance := database.Instance
+		targetDatabaseId := &database.ID
+		if restoreBackup.TargetInstanceId != 0 && restoreBackup.TargetInstanceId != database.InstanceId {
+			if restoreBackup.TargetDatabaseName == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "Target database name is required to restore into another instance")
+			}
+			instance, err = s.ComposeInstanceById(context.Background(), restoreBackup.TargetInstanceId)
+			if err != nil {
+				if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+					return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Target instance ID not found: %d", restoreBackup.TargetInstanceId))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch target instance ID: %v", restoreBackup.TargetInstanceId)).SetInternal(err)
+			}
+			if instance.RowStatus != api.Normal {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Target instance %q is archived", instance.Name))
+			}
+			if instance.Engine != database.Instance.Engine {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot restore %s backup into %s instance %q", database.Instance.Engine, instance.Engine, instance.Name))
+			}
+		}
+		if err := s.checkRestoreTarget(context.Background(), c.Get(GetPrincipalIdContextKey()).(int), database, instance); err != nil {
+			switch bytebase.ErrorCode(err) {
+			case bytebase.EUNAUTHORIZED:
+				return echo.NewHTTPError(http.StatusForbidden, fmt.Sprintf("Cannot restore into instance %q: %s", instance.Name, bytebase.ErrorMessage(err)))
+			case bytebase.EINVALID:
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot restore into instance %q: %s", instance.Name, bytebase.ErrorMessage(err)))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to check restore target instance %q", instance.Name)).SetInternal(err)
+		}
+		if restoreBackup.TargetDatabaseName != "" {
+			if strings.ContainsAny(restoreBackup.TargetDatabaseName, "`\"/\\") {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid target database name %q", restoreBackup.TargetDatabaseName))
+			}
+			databaseFind := &api.DatabaseFind{
+				InstanceId: &instance.ID,
+				Name:       &restoreBackup.TargetDatabaseName,
+			}
+			existingList, err := s.DatabaseService.FindDatabaseList(context.Background(), databaseFind)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch target database %q", restoreBackup.TargetDatabaseName)).SetInternal(err)
+			}
+			if len(existingList) > 0 {
+				return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf("Database %q already exists on instance %q", restoreBackup.TargetDatabaseName, instance.Name))
+			}
+			targetDatabaseId = nil
 		}
 
 		creatorID := c.Get(GetPrincipalIdContextKey()).(int)
 		uniqueKey := time.Now().UTC().Unix()
 
 		payload := api.TaskDatabaseRestorePayload{
-			BackupID: backup.ID,
+			BackupID:      backup.ID,
+			DatabaseName:  restoreBackup.TargetDatabaseName,
+			PointInTimeTs: restoreBackup.PointInTimeTs,
 		}
 		bytes, err := json.Marshal(payload)
 		if err != nil {
@@ -442,7 +650,7 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 
 		createdStage, err := s.StageService.CreateStage(context.Background(), &api.StageCreate{
 			Name:          fmt.Sprintf("restore-stage-%s-%v", backup.Name, uniqueKey),
-			EnvironmentId: database.Instance.EnvironmentId,
+			EnvironmentId: instance.EnvironmentId,
 			PipelineId:    createdPipeline.ID,
 			CreatorId:     creatorID,
 		})
@@ -454,8 +662,8 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			Name:       fmt.Sprintf("restore-task-%s-%v", backup.Name, uniqueKey),
 			PipelineId: createdPipeline.ID,
 			StageId:    createdStage.ID,
-			InstanceId: database.InstanceId,
-			DatabaseId: &database.ID,
+			InstanceId: instance.ID,
+			DatabaseId: targetDatabaseId,
 			Status:     api.TaskPending,
 			Type:       api.TaskDatabaseRestore,
 			Payload:    string(bytes),
@@ -464,6 +672,7 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create task").SetInternal(err)
 		}
+		s.TaskScheduler.Notify(createdPipeline.ID)
 
 		if err := s.ComposeBackupRelationship(context.Background(), backup); err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to compose backup relationship").SetInternal(err)
@@ -487,17 +696,36 @@ func (s *Server) registerDatabaseRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted set backup setting request").SetInternal(err)
 		}
 		backupSettingUpsert.UpdaterId = c.Get(GetPrincipalIdContextKey()).(int)
+		if backupSettingUpsert.RetentionCount < 0 || backupSettingUpsert.RetentionDays < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Backup retention count and days must not be negative")
+		}
+		if backupSettingUpsert.Schedule != "" {
+			if _, err := parseBackupSchedule(backupSettingUpsert.Schedule); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid backup schedule: %v", err))
+			}
+		}
+		if err := s.validateBackupEncoding(&backupSettingUpsert.Compression, backupSettingUpsert.Encrypted); err != nil {
+			return err
+		}
 
 		databaseFind := &api.DatabaseFind{
 			ID: &id,
 		}
-		_, err = s.ComposeDatabaseByFind(context.Background(), databaseFind)
+		database, err := s.ComposeDatabaseByFind(context.Background(), databaseFind)
 		if err != nil {
 			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
 				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Database ID not found: %d", id))
 			}
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch database ID: %v", id)).SetInternal(err)
 		}
+		if backupSettingUpsert.Enabled && !isBackupSupported(database.Instance.Engine) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup is not supported for %s instance", database.Instance.Engine))
+		}
+		if backupSettingUpsert.VerificationEnabled {
+			if err := s.validateBackupVerificationInstance(context.Background(), database, backupSettingUpsert.VerificationInstanceId); err != nil {
+				return err
+			}
+		}
 
 		backupSetting, err := s.BackupService.UpsertBackupSetting(context.Background(), backupSettingUpsert)
 		if err != nil {
@@ -620,3 +848,133 @@ func (s *Server) ComposeBackupRelationship(ctx context.Context, backup *api.Back
 	}
 	return nil
 }
+
+// fetchDatabaseSchema fetches the current schema of the database from its instance.
+func (s *Server) fetchDatabaseSchema(ctx context.Context, database *api.Database) (*db.DBSchema, error) {
+	instance := database.Instance
+	driver, err := db.Open(
+		instance.Engine,
+		db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
+		db.ConnectionConfig{
+			Username: instance.Username,
+			Password: instance.Password,
+			Host:     instance.Host,
+			Port:     instance.Port,
+			SSLMode:  instance.SSLMode,
+		},
+		db.ConnectionContext{
+			EnvironmentName: instance.Environment.Name,
+			InstanceName:    instance.Name,
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect instance: %v with user: %v. Error %w", instance.Name, instance.Username, err)
+	}
+	defer driver.Close(context.Background())
+
+	_, schemaList, err := driver.SyncSchema(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, schema := range schemaList {
+		if schema.Name == database.Name {
+			return schema, nil
+		}
+	}
+	return nil, fmt.Errorf("database %q not found on instance %q", database.Name, instance.Name)
+}
+
+// validateBackupEncoding validates the encoding of the backups, and defaults the compression to none if it's empty.
+func (s *Server) validateBackupEncoding(compression *api.BackupCompression, encrypted bool) error {
+	if *compression == "" {
+		*compression = api.BackupCompressionNone
+	}
+	if *compression == api.BackupCompressionZstd {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Backup compression %s is not supported yet, use %s instead", api.BackupCompressionZstd, api.BackupCompressionGzip))
+	}
+	if *compression != api.BackupCompressionNone && *compression != api.BackupCompressionGzip {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid backup compression %q, supported compressions are %s and %s", *compression, api.BackupCompressionNone, api.BackupCompressionGzip))
+	}
+	if encrypted && len(s.backupEncryptionKey) == 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "Backup encryption is not available, the backup encryption key is not configured")
+	}
+	return nil
+}
+
+// validateBackupVerificationInstance validates the sandbox instance to verify the backups of database on. It returns
+// nil for instanceID 0, which verifies the backups on the instance of database.
+func (s *Server) validateBackupVerificationInstance(ctx context.Context, database *api.Database, instanceID int) error {
+	if instanceID == 0 || instanceID == database.InstanceId {
+		return nil
+	}
+	instance, err := s.ComposeInstanceById(ctx, instanceID)
+	if err != nil {
+		if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Verification instance ID not found: %d", instanceID))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch verification instance ID: %v", instanceID)).SetInternal(err)
+	}
+	if instance.RowStatus != api.Normal {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Verification instance %q is archived", instance.Name))
+	}
+	if instance.Engine != database.Instance.Engine {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot verify %s backup on %s instance %q", database.Instance.Engine, instance.Engine, instance.Name))
+	}
+	return nil
+}
+
+// checkRestoreTarget checks whether the principal can restore the backup of database into a new database on instance.
+// The new database is registered in the project of database, so the principal must be able to access the project,
+// and instance must already host a database of the project if it's not the instance of database. Restoring into
+// another environment copies the data across the environment boundary, which requires the Owner or DBA role.
+// It returns EUNAUTHORIZED or EINVALID if the restore is not allowed.
+func (s *Server) checkRestoreTarget(ctx context.Context, principalID int, database *api.Database, instance *api.Instance) error {
+	member, err := s.MemberService.FindMember(ctx, &api.MemberFind{PrincipalId: &principalID})
+	if err != nil {
+		if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+			return bytebase.Errorf(bytebase.EUNAUTHORIZED, "user ID %d is not a member", principalID)
+		}
+		return fmt.Errorf("failed to find member for user ID %d: %w", principalID, err)
+	}
+	role := member.Role
+	// If admin feature is not enabled, then we treat all user as OWNER, the same as the ACL check.
+	if !s.feature("bb.admin") {
+		role = api.Owner
+	}
+	privileged := role == api.Owner || role == api.DBA
+
+	if !privileged {
+		projectMemberList, err := s.ComposeProjectMemberListByProjectId(ctx, database.ProjectId)
+		if err != nil {
+			return fmt.Errorf("failed to find members of project ID %d: %w", database.ProjectId, err)
+		}
+		isProjectMember := false
+		for _, projectMember := range projectMemberList {
+			if projectMember.PrincipalId == principalID {
+				isProjectMember = true
+				break
+			}
+		}
+		if !isProjectMember {
+			return bytebase.Errorf(bytebase.EUNAUTHORIZED, "only the members of the project can restore database %q", database.Name)
+		}
+	}
+
+	if instance.ID != database.InstanceId {
+		databaseList, err := s.DatabaseService.FindDatabaseList(ctx, &api.DatabaseFind{
+			InstanceId: &instance.ID,
+			ProjectId:  &database.ProjectId,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to find databases of project ID %d on instance %q: %w", database.ProjectId, instance.Name, err)
+		}
+		if len(databaseList) == 0 {
+			return bytebase.Errorf(bytebase.EINVALID, "instance %q has no database in the project of database %q", instance.Name, database.Name)
+		}
+	}
+
+	if instance.EnvironmentId != database.Instance.EnvironmentId && !privileged {
+		return bytebase.Errorf(bytebase.EUNAUTHORIZED, "only Owner and DBA can restore database %q into another environment", database.Name)
+	}
+	return nil
+}
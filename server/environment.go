@@ -9,6 +9,7 @@ import (
 
 	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/advisor"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 )
@@ -22,6 +23,19 @@ func (s *Server) registerEnvironmentRoutes(g *echo.Group) {
 
 		environmentCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
 
+		if _, err := advisor.UnmarshalPolicy(environmentCreate.SQLReviewPolicy); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		if _, err := api.UnmarshalMaintenanceWindow(environmentCreate.MaintenanceWindow); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		if _, err := api.UnmarshalTaskRetryPolicy(environmentCreate.TaskRetryPolicy); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		if _, err := api.UnmarshalBackupStorage(environmentCreate.BackupStorage); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+
 		environment, err := s.EnvironmentService.CreateEnvironment(context.Background(), environmentCreate)
 		if err != nil {
 			if bytebase.ErrorCode(err) == bytebase.ECONFLICT {
@@ -79,6 +93,27 @@ func (s *Server) registerEnvironmentRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch environment request").SetInternal(err)
 		}
 
+		if v := environmentPatch.SQLReviewPolicy; v != nil {
+			if _, err := advisor.UnmarshalPolicy(*v); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+		if v := environmentPatch.MaintenanceWindow; v != nil {
+			if _, err := api.UnmarshalMaintenanceWindow(*v); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+		if v := environmentPatch.TaskRetryPolicy; v != nil {
+			if _, err := api.UnmarshalTaskRetryPolicy(*v); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+		if v := environmentPatch.BackupStorage; v != nil {
+			if _, err := api.UnmarshalBackupStorage(*v); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+
 		environment, err := s.EnvironmentService.PatchEnvironment(context.Background(), environmentPatch)
 		if err != nil {
 			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
@@ -22,6 +22,12 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		}
 
 		instanceCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
+		if instanceCreate.BinlogArchiveEnabled && instanceCreate.Engine != db.Mysql {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Binlog archive is only supported for %s instance", db.Mysql))
+		}
+		if err := validateSSLMode(instanceCreate.Engine, instanceCreate.SSLMode); err != nil {
+			return err
+		}
 
 		instance, err := s.InstanceService.CreateInstance(context.Background(), instanceCreate)
 		if err != nil {
@@ -40,12 +46,13 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		// thus it's OK if it fails. Frontend will surface relavant info suggesting the "bytebase" db hasn't created yet.
 		db, err := db.Open(
 			instance.Engine,
-			db.DriverConfig{Logger: s.l},
+			db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
 			db.ConnectionConfig{
 				Username: instance.Username,
 				Password: instance.Password,
 				Host:     instance.Host,
 				Port:     instance.Port,
+				SSLMode:  instance.SSLMode,
 			},
 			db.ConnectionContext{
 				EnvironmentName: instance.Environment.Name,
@@ -126,8 +133,26 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted patch instance request").SetInternal(err)
 		}
 
+		if (instancePatch.BinlogArchiveEnabled != nil && *instancePatch.BinlogArchiveEnabled) || instancePatch.SSLMode != nil {
+			instance, err := s.InstanceService.FindInstance(context.Background(), &api.InstanceFind{ID: &id})
+			if err != nil {
+				if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+					return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Instance ID not found: %d", id))
+				}
+				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch instance ID: %v", id)).SetInternal(err)
+			}
+			if instancePatch.BinlogArchiveEnabled != nil && *instancePatch.BinlogArchiveEnabled && instance.Engine != db.Mysql {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Binlog archive is only supported for %s instance", db.Mysql))
+			}
+			if instancePatch.SSLMode != nil {
+				if err := validateSSLMode(instance.Engine, *instancePatch.SSLMode); err != nil {
+					return err
+				}
+			}
+		}
+
 		var instance *api.Instance
-		if instancePatch.RowStatus != nil || instancePatch.Name != nil || instancePatch.ExternalLink != nil || instancePatch.Host != nil || instancePatch.Port != nil {
+		if instancePatch.RowStatus != nil || instancePatch.Name != nil || instancePatch.ExternalLink != nil || instancePatch.Host != nil || instancePatch.Port != nil || instancePatch.BinlogArchiveEnabled != nil || instancePatch.SSLMode != nil {
 			instance, err = s.InstanceService.PatchInstance(context.Background(), instancePatch)
 			if err != nil {
 				if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
@@ -220,12 +245,13 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		resultSet := &api.SqlResultSet{}
 		db, err := db.Open(
 			instance.Engine,
-			db.DriverConfig{Logger: s.l},
+			db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
 			db.ConnectionConfig{
 				Username: instance.Username,
 				Password: instance.Password,
 				Host:     instance.Host,
 				Port:     instance.Port,
+				SSLMode:  instance.SSLMode,
 			},
 			db.ConnectionContext{
 				EnvironmentName: instance.Environment.Name,
@@ -265,12 +291,13 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		instanceMigration := &api.InstanceMigration{}
 		db, err := db.Open(
 			instance.Engine,
-			db.DriverConfig{Logger: s.l},
+			db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
 			db.ConnectionConfig{
 				Username: instance.Username,
 				Password: instance.Password,
 				Host:     instance.Host,
 				Port:     instance.Port,
+				SSLMode:  instance.SSLMode,
 			},
 			db.ConnectionContext{
 				EnvironmentName: instance.Environment.Name,
@@ -330,12 +357,13 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 		historyList := []*api.MigrationHistory{}
 		driver, err := db.Open(
 			instance.Engine,
-			db.DriverConfig{Logger: s.l},
+			db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
 			db.ConnectionConfig{
 				Username: instance.Username,
 				Password: instance.Password,
 				Host:     instance.Host,
 				Port:     instance.Port,
+				SSLMode:  instance.SSLMode,
 			},
 			db.ConnectionContext{
 				EnvironmentName: instance.Environment.Name,
@@ -365,6 +393,7 @@ func (s *Server) registerInstanceRoutes(g *echo.Group) {
 					ExecutionDuration: entry.ExecutionDuration,
 					IssueId:           entry.IssueId,
 					Payload:           entry.Payload,
+					Schema:            entry.Schema,
 				})
 			}
 		}
@@ -447,3 +476,18 @@ func (s *Server) FindInstanceAdminPasswordById(ctx context.Context, instanceId i
 	}
 	return "", &bytebase.Error{Code: bytebase.ENOTFOUND, Message: fmt.Sprintf("missing admin password for instance: %d", instanceId)}
 }
+
+// validateSSLMode returns the bad request error if the sslmode is invalid, or set for the instance other than
+// PostgreSQL.
+func validateSSLMode(engine db.Type, sslMode string) error {
+	switch sslMode {
+	case "", "disable":
+		return nil
+	case "require", "verify-ca", "verify-full":
+		if engine != db.Postgres {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("SSL mode is only supported for %s instance", db.Postgres))
+		}
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid SSL mode %q, must be one of disable, require, verify-ca and verify-full", sslMode))
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/plugin/advisor"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 )
@@ -321,6 +322,7 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return nil, fmt.Errorf("failed to create pipeline for issue. Error %w", err)
 	}
 
+	var reviewedTaskList []*api.Task
 	for _, stageCreate := range issueCreate.Pipeline.StageList {
 		stageCreate.CreatorId = creatorId
 		stageCreate.PipelineId = createdPipeline.ID
@@ -369,16 +371,34 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 				if taskCreate.VCSPushEvent != nil {
 					payload.VCSPushEvent = taskCreate.VCSPushEvent
 				}
+				payload.DryRun = taskCreate.DryRun
+
+				adviceList, err := s.reviewSQL(ctx, taskCreate.InstanceId, taskCreate.Statement)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create schema update task, unable to review SQL statement %w", err)
+				}
+				payload.AdviceList = adviceList
+				if taskCreate.DryRun {
+					// The dry run never changes the database, so it starts right away to find the problems before the
+					// real migration is approved.
+					taskCreate.Status = api.TaskPending
+				} else if hasSQLReviewError(adviceList) && taskCreate.Status == api.TaskPending {
+					// The task with ERROR advice must be approved after fixing the statement, so we never start it automatically.
+					taskCreate.Status = api.TaskPendingApproval
+				}
 				bytes, err := json.Marshal(payload)
 				if err != nil {
 					return nil, fmt.Errorf("failed to create schema update task, unable to marshal payload %w", err)
 				}
 				taskCreate.Payload = string(bytes)
 			}
-			_, err := s.TaskService.CreateTask(context.Background(), &taskCreate)
+			task, err := s.TaskService.CreateTask(context.Background(), &taskCreate)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create task for issue. Error %w", err)
 			}
+			if task.Type == api.TaskDatabaseSchemaUpdate {
+				reviewedTaskList = append(reviewedTaskList, task)
+			}
 		}
 	}
 
@@ -409,6 +429,12 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 		return nil, fmt.Errorf("failed to create activity after creating the issue: %v. Error %w", issue.Name, err)
 	}
 
+	for _, task := range reviewedTaskList {
+		if err := s.createSQLReviewActivity(ctx, issue, task, creatorId); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := s.ComposeIssueRelationship(context.Background(), issue); err != nil {
 		return nil, err
 	}
@@ -416,10 +442,88 @@ func (s *Server) CreateIssue(ctx context.Context, issueCreate *api.IssueCreate,
 	if err := s.ScheduleNextTaskIfNeeded(context.Background(), issue.Pipeline); err != nil {
 		return nil, fmt.Errorf("failed to schedule task after creating the issue: %v. Error %w", issue.Name, err)
 	}
+	s.TaskScheduler.Notify(createdPipeline.ID)
 
 	return issue, nil
 }
 
+// reviewSQL reviews the statement against the SQL review policy of the environment the instance belongs to.
+func (s *Server) reviewSQL(ctx context.Context, instanceId int, statement string) ([]api.SQLReviewAdvice, error) {
+	instance, err := s.ComposeInstanceById(ctx, instanceId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance %d: %w", instanceId, err)
+	}
+	policy, err := advisor.UnmarshalPolicy(instance.Environment.SQLReviewPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("environment %q has %w", instance.Environment.Name, err)
+	}
+	adviceList := []api.SQLReviewAdvice{}
+	for _, advice := range advisor.Check(instance.Engine, statement, policy) {
+		adviceList = append(adviceList, api.SQLReviewAdvice{
+			Type:    string(advice.Type),
+			Level:   api.SQLReviewAdviceLevel(advice.Level),
+			Title:   advice.Title,
+			Content: advice.Content,
+		})
+	}
+	return adviceList, nil
+}
+
+// hasSQLReviewError returns true if any advice is at the ERROR level.
+func hasSQLReviewError(adviceList []api.SQLReviewAdvice) bool {
+	for _, advice := range adviceList {
+		if advice.Level == api.SQLReviewAdviceError {
+			return true
+		}
+	}
+	return false
+}
+
+// createSQLReviewActivity records the SQL review result of the task as an issue activity if the review finds any problem.
+func (s *Server) createSQLReviewActivity(ctx context.Context, issue *api.Issue, task *api.Task, creatorId int) error {
+	payload := &api.TaskDatabaseSchemaUpdatePayload{}
+	if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+		return fmt.Errorf("invalid database schema update payload: %w", err)
+	}
+	if len(payload.AdviceList) == 0 {
+		return nil
+	}
+
+	level := api.ACTIVITY_WARNING
+	if hasSQLReviewError(payload.AdviceList) {
+		level = api.ACTIVITY_ERROR
+	}
+	bytes, err := json.Marshal(api.ActivityPipelineTaskSQLReviewPayload{
+		TaskId:     task.ID,
+		AdviceList: payload.AdviceList,
+		IssueName:  issue.Name,
+		TaskName:   task.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create SQL review activity for task: %v. Error %w", task.Name, err)
+	}
+
+	var commentList []string
+	for _, advice := range payload.AdviceList {
+		commentList = append(commentList, fmt.Sprintf("[%s] %s: %s", advice.Level, advice.Title, advice.Content))
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   creatorId,
+		ContainerId: issue.ID,
+		Type:        api.ActivityPipelineTaskSQLReview,
+		Level:       level,
+		Comment:     strings.Join(commentList, "\n"),
+		Payload:     string(bytes),
+	}
+	_, err = s.ActivityManager.CreateActivity(ctx, activityCreate, &ActivityMeta{
+		issue: issue,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create SQL review activity for task: %v. Error %w", task.Name, err)
+	}
+	return nil
+}
+
 func (s *Server) ChangeIssueStatus(ctx context.Context, issue *api.Issue, newStatus api.IssueStatus, updaterId int, comment string) (*api.Issue, error) {
 	var pipelineStatus api.PipelineStatus
 	switch newStatus {
@@ -1,9 +1,7 @@
 package server
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -12,6 +10,7 @@ import (
 	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/external/gitlab"
+	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
 	"github.com/google/jsonapi"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
@@ -162,40 +161,18 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		repositoryCreate.WebhookURLHost = fmt.Sprintf("%s:%d", s.host, s.port)
 		repositoryCreate.WebhookEndpointId = uuid.New().String()
 		repositoryCreate.WebhookSecretToken = bytebase.RandomString(gitlab.SECRET_TOKEN_LENGTH)
-		switch vcs.Type {
-		case "GITLAB_SELF_HOST":
-			webhookPost := gitlab.WebhookPost{
-				URL:                    fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, gitLabWebhookPath, repositoryCreate.WebhookEndpointId),
-				SecretToken:            repositoryCreate.WebhookSecretToken,
-				PushEvents:             true,
-				PushEventsBranchFilter: repositoryCreate.BranchFilter,
-				EnableSSLVerification:  false,
-			}
-			body, err := json.Marshal(webhookPost)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal post request for creating webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
-			}
-			resourcePath := fmt.Sprintf("projects/%s/hooks", repositoryCreate.ExternalId)
-			resp, err := gitlab.POST(vcs.InstanceURL, resourcePath, repositoryCreate.AccessToken, bytes.NewBuffer(body))
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode >= 300 {
-				return echo.NewHTTPError(http.StatusInternalServerError,
-					fmt.Sprintf("Failed to create webhook for project ID: %v, status code: %d, status: %s",
-						repositoryCreate.ProjectId,
-						resp.StatusCode,
-						resp.Status,
-					))
-			}
-
-			webhookInfo := &gitlab.WebhookInfo{}
-			if err := json.NewDecoder(resp.Body).Decode(webhookInfo); err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to unmarshal create webhook response for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
-			}
-			repositoryCreate.ExternalWebhookId = strconv.Itoa(webhookInfo.ID)
+		provider, err := s.getVCSProvider(vcs.Type)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
+		}
+		externalRepository := vcsPlugin.Repository{
+			ID:       repositoryCreate.ExternalId,
+			FullPath: repositoryCreate.FullPath,
+		}
+		webhookConfig := s.getWebhookConfig(vcs.Type, repositoryCreate.WebhookEndpointId, repositoryCreate.WebhookSecretToken, repositoryCreate.BranchFilter, repositoryCreate.TagFilter)
+		repositoryCreate.ExternalWebhookId, err = provider.CreateWebhook(vcs.InstanceURL, externalRepository, webhookConfig, repositoryCreate.AccessToken)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to create webhook for project ID: %v", repositoryCreate.ProjectId)).SetInternal(err)
 		}
 
 		repositoryCreate.CreatorId = c.Get(GetPrincipalIdContextKey()).(int)
@@ -296,7 +273,7 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update repository for project ID: %d", projectId)).SetInternal(err)
 		}
 
-		if repositoryPatch.BranchFilter != nil {
+		if repositoryPatch.BranchFilter != nil || repositoryPatch.TagFilter != nil {
 			vcsFind := &api.VCSFind{
 				ID: &repository.VCSId,
 			}
@@ -307,34 +284,16 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			// Updates the webhook after we successfully update the repository.
 			// This is because in case the webhook update fails, we can still have a reconcile process to reconcile the webhook state.
 			// If we update it before we update the repository, then if the repository update fails, then the reconcile process will reconcile the webhook to the pre-update state which is likely not intended.
-			switch vcs.Type {
-			case "GITLAB_SELF_HOST":
-				webhookPut := gitlab.WebhookPut{
-					URL:                    fmt.Sprintf("%s:%d/%s/%s", s.host, s.port, gitLabWebhookPath, updatedRepository.WebhookEndpointId),
-					PushEventsBranchFilter: *repositoryPatch.BranchFilter,
-				}
-				json, err := json.Marshal(webhookPut)
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal put request for updating webhook %s for project ID: %v", repository.ExternalWebhookId, projectId)).SetInternal(err)
-				}
-				resourcePath := fmt.Sprintf("projects/%s/hooks/%s", repository.ExternalId, repository.ExternalWebhookId)
-				resp, err := gitlab.PUT(vcs.InstanceURL, resourcePath, repository.AccessToken, bytes.NewBuffer(json))
-				if err != nil {
-					return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update webhook ID %s for project ID: %v", repository.ExternalWebhookId, projectId)).SetInternal(err)
-				}
-				defer resp.Body.Close()
-
-				// Just emits a warning since we have already updated the repository entry. We will have a separate process to reconcile the state.
-				if resp.StatusCode >= 300 {
-					s.l.Error(("Failed to update gitlab webhook when updating repository for project"),
-						zap.Int("status_code", resp.StatusCode),
-						zap.String("status", resp.Status),
-						zap.Int("project_id", projectId),
-						zap.Int("repository_id", repository.ID),
-						zap.String("resource_path", resourcePath),
-						zap.String("body", string(json)),
-					)
-				}
+			err = s.patchRepositoryWebhook(context.Background(), updatedRepository, vcs)
+			// Just emits a warning since we have already updated the repository entry. We will have a separate process to reconcile the state.
+			if err != nil {
+				s.l.Error("Failed to update webhook when updating repository for project",
+					zap.Int("project_id", projectId),
+					zap.Int("repository_id", repository.ID),
+					zap.String("vcs_type", vcs.Type.String()),
+					zap.String("webhook_id", repository.ExternalWebhookId),
+					zap.Error(err),
+				)
 			}
 		}
 
@@ -380,6 +339,20 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete repository for project ID: %d", projectId)).SetInternal(err)
 		}
 
+		provider, err := s.getVCSProvider(vcs.Type)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete repository for project ID: %d", projectId)).SetInternal(err)
+		}
+		// Refreshes the OAuth token before the repository entry is deleted, since the token is saved to it. Just emits a
+		// warning on failure, the repository can still be unlinked with the webhook orphaned.
+		if err := s.refreshRepositoryToken(context.Background(), repository, vcs, provider); err != nil {
+			s.l.Warn("Failed to refresh OAuth token when unlinking repository from project",
+				zap.Int("project_id", projectId),
+				zap.Int("repository_id", repository.ID),
+				zap.Error(err),
+			)
+		}
+
 		repositoryDelete := &api.RepositoryDelete{
 			ProjectId: projectId,
 			DeleterId: c.Get(GetPrincipalIdContextKey()).(int),
@@ -391,23 +364,20 @@ func (s *Server) registerProjectRoutes(g *echo.Group) {
 		// Deletes the webhook after we successfully delete the repository.
 		// This is because in case the webhook deletion fails, we can still have a cleanup process to cleanup the orphaned webhook.
 		// If we delete it before we delete the repository, then if the repository deletion fails, we will have a broken repository with no webhook.
-		switch vcs.Type {
-		case "GITLAB_SELF_HOST":
-			resp, err := gitlab.DELETE(vcs.InstanceURL, fmt.Sprintf("projects/%s/hooks/%s", repository.ExternalId, repository.ExternalWebhookId), repository.AccessToken)
-			if err != nil {
-				return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to delete webhook ID %s for project ID: %v", repository.ExternalWebhookId, projectId)).SetInternal(err)
-			}
-			defer resp.Body.Close()
-
-			// Just emits a warning since we have already removed the repository entry. We will have a separate process to cleanup the orphaned webhook.
-			if resp.StatusCode >= 300 {
-				s.l.Error(("Failed to delete gitlab webhook when unlinking repository from project"),
-					zap.Int("status_code", resp.StatusCode),
-					zap.Int("project_id", projectId),
-					zap.Int("repository_id", repository.ID),
-					zap.String("gitlab_project_id", repository.ExternalId),
-					zap.String("gitlab_webhook_id", repository.ExternalWebhookId))
-			}
+		externalRepository := vcsPlugin.Repository{
+			ID:       repository.ExternalId,
+			FullPath: repository.FullPath,
+		}
+		// Just emits a warning since we have already removed the repository entry. We will have a separate process to cleanup the orphaned webhook.
+		if err := provider.DeleteWebhook(vcs.InstanceURL, externalRepository, repository.ExternalWebhookId, repository.AccessToken); err != nil {
+			s.l.Error("Failed to delete webhook when unlinking repository from project",
+				zap.Int("project_id", projectId),
+				zap.Int("repository_id", repository.ID),
+				zap.String("vcs_type", vcs.Type.String()),
+				zap.String("external_repository_id", repository.ExternalId),
+				zap.String("webhook_id", repository.ExternalWebhookId),
+				zap.Error(err),
+			)
 		}
 
 		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
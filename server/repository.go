@@ -2,8 +2,11 @@ package server
 
 import (
 	"context"
+	"time"
 
 	"github.com/bytebase/bytebase/api"
+	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
+	"go.uber.org/zap"
 )
 
 func (s *Server) ComposeRepositoryRelationship(ctx context.Context, repository *api.Repository) error {
@@ -31,3 +34,72 @@ func (s *Server) ComposeRepositoryRelationship(ctx context.Context, repository *
 
 	return nil
 }
+
+// refreshRepositoryToken refreshes the OAuth token of the repository if it's about to expire, and saves the new token
+// to the repository.
+func (s *Server) refreshRepositoryToken(ctx context.Context, repository *api.Repository, vcs *api.VCS, provider vcsPlugin.Provider) error {
+	// Refresh a minute ahead, so that the token doesn't expire in the middle of the requests.
+	if repository.ExpiresTs == 0 || repository.RefreshToken == "" || time.Now().Unix() < repository.ExpiresTs-60 {
+		return nil
+	}
+
+	token, err := provider.RefreshOAuthToken(vcs.InstanceURL, s.getOAuthContext(vcs), repository.RefreshToken)
+	if err != nil {
+		return err
+	}
+	repositoryPatch := &api.RepositoryPatch{
+		ID:           repository.ID,
+		UpdaterId:    api.SYSTEM_BOT_ID,
+		AccessToken:  &token.AccessToken,
+		ExpiresTs:    &token.ExpiresTs,
+		RefreshToken: &token.RefreshToken,
+	}
+	if _, err := s.RepositoryService.PatchRepository(ctx, repositoryPatch); err != nil {
+		return err
+	}
+	repository.AccessToken = token.AccessToken
+	repository.ExpiresTs = token.ExpiresTs
+	repository.RefreshToken = token.RefreshToken
+	return nil
+}
+
+// reconcileRepositoryWebhook patches the webhooks of all the repositories to the current webhook config, e.g. the
+// GitLab webhooks created before receiving the merge request events was supported.
+func (s *Server) reconcileRepositoryWebhook(ctx context.Context) error {
+	repositoryList, err := s.RepositoryService.FindRepositoryList(ctx, &api.RepositoryFind{})
+	if err != nil {
+		return err
+	}
+	for _, repository := range repositoryList {
+		vcs, err := s.VCSService.FindVCS(ctx, &api.VCSFind{ID: &repository.VCSId})
+		if err == nil {
+			err = s.patchRepositoryWebhook(ctx, repository, vcs)
+		}
+		// Continue with the other repositories, the webhook will be reconciled again on the next start.
+		if err != nil {
+			s.l.Error("Failed to reconcile repository webhook",
+				zap.Int("project_id", repository.ProjectId),
+				zap.Int("repository_id", repository.ID),
+				zap.String("webhook_id", repository.ExternalWebhookId),
+				zap.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *Server) patchRepositoryWebhook(ctx context.Context, repository *api.Repository, vcs *api.VCS) error {
+	provider, err := s.getVCSProvider(vcs.Type)
+	if err != nil {
+		return err
+	}
+	if err := s.refreshRepositoryToken(ctx, repository, vcs, provider); err != nil {
+		return err
+	}
+	externalRepository := vcsPlugin.Repository{
+		ID:       repository.ExternalId,
+		FullPath: repository.FullPath,
+	}
+	webhookConfig := s.getWebhookConfig(vcs.Type, repository.WebhookEndpointId, repository.WebhookSecretToken, repository.BranchFilter, repository.TagFilter)
+	return provider.PatchWebhook(vcs.InstanceURL, externalRepository, repository.ExternalWebhookId, webhookConfig, repository.AccessToken)
+}
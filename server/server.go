@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -19,9 +20,11 @@ import (
 )
 
 type Server struct {
-	TaskScheduler *TaskScheduler
-	SchemaSyncer  *SchemaSyncer
-	BackupRunner  *BackupRunner
+	TaskScheduler  *TaskScheduler
+	SchemaSyncer   *SchemaSyncer
+	BackupRunner   *BackupRunner
+	BackupPruner   *BackupPruner
+	BinlogArchiver *BinlogArchiver
 
 	ActivityManager *ActivityManager
 
@@ -68,6 +71,10 @@ type Server struct {
 	demo         bool
 	plan         api.PlanType
 	dataDir      string
+	// sqliteDir is the root directory of the SQLite instances.
+	sqliteDir string
+	// backupEncryptionKey is the AES-256 key to encrypt the backups, nil if the encryption is not configured.
+	backupEncryptionKey []byte
 }
 
 //go:embed acl_casbin_model.conf
@@ -82,7 +89,7 @@ var casbinDBAPolicy string
 //go:embed acl_casbin_policy_developer.csv
 var casbinDeveloperPolicy string
 
-func NewServer(logger *zap.Logger, version string, host string, port int, frontendHost string, frontendPort int, mode string, dataDir string, backupRunnerInterval time.Duration, secret string, readonly bool, demo bool, debug bool) *Server {
+func NewServer(logger *zap.Logger, version string, host string, port int, frontendHost string, frontendPort int, mode string, dataDir string, backupRunnerInterval time.Duration, taskConcurrency int, taskInstanceConcurrency int, secret string, backupEncryptionKey []byte, readonly bool, demo bool, debug bool) *Server {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
@@ -90,25 +97,27 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 	embedFrontend(logger, e)
 
 	s := &Server{
-		l:            logger,
-		CacheService: NewCacheService(logger),
-		e:            e,
-		version:      version,
-		mode:         mode,
-		host:         host,
-		port:         port,
-		frontendHost: frontendHost,
-		frontendPort: frontendPort,
-		startedTs:    time.Now().Unix(),
-		secret:       secret,
-		readonly:     readonly,
-		demo:         demo,
-		plan:         api.TEAM,
-		dataDir:      dataDir,
+		l:                   logger,
+		CacheService:        NewCacheService(logger),
+		e:                   e,
+		version:             version,
+		mode:                mode,
+		host:                host,
+		port:                port,
+		frontendHost:        frontendHost,
+		frontendPort:        frontendPort,
+		startedTs:           time.Now().Unix(),
+		secret:              secret,
+		backupEncryptionKey: backupEncryptionKey,
+		readonly:            readonly,
+		demo:                demo,
+		plan:                api.TEAM,
+		dataDir:             dataDir,
+		sqliteDir:           filepath.Join(dataDir, "sqlite"),
 	}
 
 	if !readonly {
-		scheduler := NewTaskScheduler(logger, s)
+		scheduler := NewTaskScheduler(logger, s, taskConcurrency, taskInstanceConcurrency)
 		defaultExecutor := NewDefaultTaskExecutor(logger)
 		createDBExecutor := NewDatabaseCreateTaskExecutor(logger)
 		sqlExecutor := NewSchemaUpdateTaskExecutor(logger)
@@ -124,6 +133,8 @@ func NewServer(logger *zap.Logger, version string, host string, port int, fronte
 		schemaSyncer := NewSchemaSyncer(logger, s)
 		s.SchemaSyncer = schemaSyncer
 		s.BackupRunner = NewBackupRunner(logger, s, backupRunnerInterval)
+		s.BackupPruner = NewBackupPruner(logger, s)
+		s.BinlogArchiver = NewBinlogArchiver(logger, s)
 	}
 
 	// Middleware
@@ -206,6 +217,21 @@ func (server *Server) Run() error {
 		if err := server.BackupRunner.Run(); err != nil {
 			return err
 		}
+
+		if err := server.BackupPruner.Run(); err != nil {
+			return err
+		}
+
+		if err := server.BinlogArchiver.Run(); err != nil {
+			return err
+		}
+
+		// Reconcile in the background, since it calls the VCS API for every repository.
+		go func() {
+			if err := server.reconcileRepositoryWebhook(context.Background()); err != nil {
+				server.l.Error("Failed to reconcile repository webhook", zap.Error(err))
+			}
+		}()
 	}
 
 	// Sleep for 1 sec to make sure port is released between runs.
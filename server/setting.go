@@ -26,11 +26,8 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 
 		filteredList := []*api.Setting{}
 		for _, setting := range list {
-			for _, whitelist := range whitelistSettings {
-				if setting.Name == whitelist {
-					filteredList = append(filteredList, setting)
-					break
-				}
+			if isWhitelistSetting(setting.Name) {
+				filteredList = append(filteredList, setting)
 			}
 		}
 
@@ -55,6 +52,10 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 		if err := jsonapi.UnmarshalPayload(c.Request().Body, settingPatch); err != nil {
 			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted update setting request").SetInternal(err)
 		}
+		// Only the settings visible to the client can be updated, since the response contains the value.
+		if !isWhitelistSetting(settingPatch.Name) {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Setting name not found: %s", settingPatch.Name))
+		}
 
 		setting, err := s.SettingService.PatchSetting(context.Background(), settingPatch)
 		if err != nil {
@@ -72,6 +73,15 @@ func (s *Server) registerSettingRoutes(g *echo.Group) {
 	})
 }
 
+func isWhitelistSetting(name api.SettingName) bool {
+	for _, whitelist := range whitelistSettings {
+		if name == whitelist {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) ComposeSettingRelationship(ctx context.Context, setting *api.Setting) error {
 	var err error
 
@@ -2,8 +2,10 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/bytebase/bytebase"
@@ -35,12 +37,13 @@ func (s *Server) registerSqlRoutes(g *echo.Group) {
 
 		db, err := db.Open(
 			connectionInfo.DBType,
-			db.DriverConfig{Logger: s.l},
+			db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
 			db.ConnectionConfig{
 				Username: connectionInfo.Username,
 				Password: password,
 				Host:     connectionInfo.Host,
 				Port:     connectionInfo.Port,
+				SSLMode:  connectionInfo.SSLMode,
 			},
 			db.ConnectionContext{},
 		)
@@ -98,13 +101,14 @@ func (s *Server) SyncSchema(instance *api.Instance) (rs *api.SqlResultSet) {
 	resultSet := &api.SqlResultSet{}
 	err := func() error {
 		driver, err := db.Open(
-			db.Mysql,
-			db.DriverConfig{Logger: s.l},
+			instance.Engine,
+			db.DriverConfig{Logger: s.l, SqliteDir: s.sqliteDir},
 			db.ConnectionConfig{
 				Username: instance.Username,
 				Password: instance.Password,
 				Host:     instance.Host,
 				Port:     instance.Port,
+				SSLMode:  instance.SSLMode,
 			},
 			db.ConnectionContext{
 				EnvironmentName: instance.Environment.Name,
@@ -121,6 +125,10 @@ func (s *Server) SyncSchema(instance *api.Instance) (rs *api.SqlResultSet) {
 		if err != nil {
 			resultSet.Error = err.Error()
 		} else {
+			// We can only detect the schema drift if the instance has the up-to-date migration schema.
+			setup, err := driver.NeedsSetupMigration(context.Background())
+			detectDrift := err == nil && !setup
+
 			var createTable = func(database *api.Database, tableCreate *api.TableCreate) (*api.Table, error) {
 				createTable, err := s.TableService.CreateTable(context.Background(), tableCreate)
 				if err != nil {
@@ -224,18 +232,36 @@ func (s *Server) SyncSchema(instance *api.Instance) (rs *api.SqlResultSet) {
 					// Case 1
 					syncStatus := api.OK
 					ts := time.Now().Unix()
+					databaseSyncStatus := api.OK
+					var drift *api.ActivityDatabaseSchemaDriftPayload
+					if detectDrift {
+						drift, err = s.detectSchemaDrift(driver, matchedDb, schema)
+						if err != nil {
+							return fmt.Errorf("failed to sync database for instance: %s. Failed to detect schema drift for database: %s. Error %w", instance.Name, matchedDb.Name, err)
+						}
+						if drift != nil {
+							databaseSyncStatus = api.Drifted
+						}
+					}
 					databasePatch := &api.DatabasePatch{
 						ID:                   matchedDb.ID,
 						UpdaterId:            api.SYSTEM_BOT_ID,
-						SyncStatus:           &syncStatus,
+						SyncStatus:           &databaseSyncStatus,
 						LastSuccessfulSyncTs: &ts,
 					}
 					database, err := s.DatabaseService.PatchDatabase(context.Background(), databasePatch)
 					if err != nil {
 						if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
-							return fmt.Errorf("failed to sync database for instance: %s. Database not found: %s", instance.Name, database.Name)
+							return fmt.Errorf("failed to sync database for instance: %s. Database not found: %s", instance.Name, matchedDb.Name)
+						}
+						return fmt.Errorf("failed to sync database for instance: %s. Failed to update database: %s. Error %w", instance.Name, matchedDb.Name, err)
+					}
+
+					// Only raise the activity when the database starts drifting to avoid the noise from each sync.
+					if drift != nil && matchedDb.SyncStatus != api.Drifted {
+						if err := s.createSchemaDriftActivity(drift); err != nil {
+							return fmt.Errorf("failed to sync database for instance: %s. Error %w", instance.Name, err)
 						}
-						return fmt.Errorf("failed to sync database for instance: %s. Failed to update database: %s. Error %w", instance.Name, database.Name, err)
 					}
 
 					for _, table := range schema.TableList {
@@ -523,3 +549,52 @@ func (s *Server) SyncSchema(instance *api.Instance) (rs *api.SqlResultSet) {
 
 	return resultSet
 }
+
+// detectSchemaDrift compares the live schema with the schema snapshot recorded by the latest migration.
+// Returns nil if the schema matches the snapshot or there is no snapshot to compare.
+func (s *Server) detectSchemaDrift(driver db.Driver, database *api.Database, schema *db.DBSchema) (*api.ActivityDatabaseSchemaDriftPayload, error) {
+	limit := 1
+	find := &db.MigrationHistoryFind{
+		Database: &database.Name,
+		Limit:    &limit,
+	}
+	list, err := driver.FindMigrationHistoryList(context.Background(), find)
+	if err != nil {
+		return nil, err
+	}
+	// The migration applied before recording snapshot was supported doesn't have the snapshot.
+	if len(list) == 0 || list[0].Schema == "" {
+		return nil, nil
+	}
+
+	diffList := db.DiffSchemaSnapshot(list[0].Schema, db.SchemaSnapshot(schema))
+	if len(diffList) == 0 {
+		return nil, nil
+	}
+	return &api.ActivityDatabaseSchemaDriftPayload{
+		DatabaseId:   database.ID,
+		DatabaseName: database.Name,
+		Version:      list[0].Version,
+		DiffList:     diffList,
+	}, nil
+}
+
+func (s *Server) createSchemaDriftActivity(drift *api.ActivityDatabaseSchemaDriftPayload) error {
+	bytes, err := json.Marshal(drift)
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema drift activity payload for database: %s. Error %w", drift.DatabaseName, err)
+	}
+	activityCreate := &api.ActivityCreate{
+		CreatorId:   api.SYSTEM_BOT_ID,
+		ContainerId: drift.DatabaseId,
+		Type:        api.ActivityDatabaseSchemaDrift,
+		Level:       api.ACTIVITY_WARNING,
+		Comment: fmt.Sprintf("Database %q schema has been changed outside of the migration since version %s:\n%s",
+			drift.DatabaseName, drift.Version, strings.Join(drift.DiffList, "\n")),
+		Payload: string(bytes),
+	}
+	if _, err := s.ActivityManager.CreateActivity(context.Background(), activityCreate, &ActivityMeta{}); err != nil {
+		return fmt.Errorf("failed to create schema drift activity for database: %s. Error %w", drift.DatabaseName, err)
+	}
+	return nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bytebase/bytebase"
@@ -15,6 +17,11 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// TASK_RUN_LOG_POLL_INTERVAL is the interval to check for the new task run logs when following the logs.
+	TASK_RUN_LOG_POLL_INTERVAL = time.Duration(1) * time.Second
+)
+
 var (
 	applicableTaskStatusTransition = map[api.TaskStatus][]api.TaskStatus{
 		api.TaskPending:         {api.TaskRunning},
@@ -27,6 +34,50 @@ var (
 )
 
 func (s *Server) registerTaskRoutes(g *echo.Group) {
+	g.PATCH("/pipeline/:pipelineId/task/:taskId", func(c echo.Context) error {
+		taskId, err := strconv.Atoi(c.Param("taskId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskId"))).SetInternal(err)
+		}
+
+		taskPatch := &api.TaskPatch{
+			ID:        taskId,
+			UpdaterId: c.Get(GetPrincipalIdContextKey()).(int),
+		}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, taskPatch); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted update task request").SetInternal(err)
+		}
+
+		taskFind := &api.TaskFind{
+			ID: &taskId,
+		}
+		task, err := s.TaskService.FindTask(context.Background(), taskFind)
+		if err != nil {
+			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task ID not found: %d", taskId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update task").SetInternal(err)
+		}
+		if taskPatch.EarliestAllowedTs != nil && task.Status != api.TaskPending && task.Status != api.TaskPendingApproval {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Cannot change the earliest allowed time of task \"%v\" in %s status", task.Name, task.Status))
+		}
+
+		updatedTask, err := s.TaskService.PatchTask(context.Background(), taskPatch)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to update task \"%v\"", task.Name)).SetInternal(err)
+		}
+
+		if err := s.ComposeTaskRelationship(context.Background(), updatedTask); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch updated task \"%v\" relationship", updatedTask.Name)).SetInternal(err)
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, updatedTask); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal update task \"%v\" response", updatedTask.Name)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.PATCH("/pipeline/:pipelineId/task/:taskId/status", func(c echo.Context) error {
 		taskId, err := strconv.Atoi(c.Param("taskId"))
 		if err != nil {
@@ -70,6 +121,143 @@ func (s *Server) registerTaskRoutes(g *echo.Group) {
 		}
 		return nil
 	})
+
+	// Stream the logs of the task run as server-sent events. Use taskRunId to choose the task run, which defaults to
+	// the latest one. The stream ends after sending the existing logs unless follow is true, in which case it keeps
+	// sending the new logs until the task run is no longer RUNNING.
+	g.GET("/pipeline/:pipelineId/task/:taskId/log", func(c echo.Context) error {
+		taskId, err := strconv.Atoi(c.Param("taskId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task ID is not a number: %s", c.Param("taskId"))).SetInternal(err)
+		}
+
+		taskFind := &api.TaskFind{
+			ID: &taskId,
+		}
+		task, err := s.TaskService.FindTask(context.Background(), taskFind)
+		if err != nil {
+			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task ID not found: %d", taskId))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch task").SetInternal(err)
+		}
+
+		var taskRun *api.TaskRun
+		if taskRunIdStr := c.QueryParam("taskRunId"); taskRunIdStr != "" {
+			taskRunId, err := strconv.Atoi(taskRunIdStr)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Task run ID is not a number: %s", taskRunIdStr)).SetInternal(err)
+			}
+			for _, run := range task.TaskRunList {
+				if run.ID == taskRunId {
+					taskRun = run
+				}
+			}
+		} else {
+			for _, run := range task.TaskRunList {
+				if taskRun == nil || run.ID > taskRun.ID {
+					taskRun = run
+				}
+			}
+		}
+		if taskRun == nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Task run not found for task \"%v\"", task.Name))
+		}
+		follow := c.QueryParam("follow") == "true"
+
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().Header().Set("Cache-Control", "no-cache")
+		c.Response().Header().Set("Connection", "keep-alive")
+		c.Response().WriteHeader(http.StatusOK)
+
+		ticker := time.NewTicker(TASK_RUN_LOG_POLL_INTERVAL)
+		defer ticker.Stop()
+		taskRunLogFind := &api.TaskRunLogFind{
+			TaskRunId: &taskRun.ID,
+		}
+		for {
+			// Check the status before fetching the logs, so we won't miss the logs written right before the task run ends.
+			running := follow && s.isTaskRunRunning(taskId, taskRun.ID)
+
+			taskRunLogList, err := s.TaskService.FindTaskRunLogList(context.Background(), taskRunLogFind)
+			if err != nil {
+				// The response has been started, so we can only end the stream.
+				s.l.Error("Failed to fetch task run logs", zap.Int("task_run_id", taskRun.ID), zap.Error(err))
+				return nil
+			}
+			for _, taskRunLog := range taskRunLogList {
+				if err := writeTaskRunLogEvent(c.Response(), taskRunLog); err != nil {
+					return nil
+				}
+				taskRunLogFind.AfterId = &taskRunLog.ID
+			}
+			c.Response().Flush()
+
+			if !running {
+				return nil
+			}
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// isTaskRunRunning returns true if the task run of the task is still RUNNING.
+func (s *Server) isTaskRunRunning(taskId int, taskRunId int) bool {
+	taskFind := &api.TaskFind{
+		ID: &taskId,
+	}
+	task, err := s.TaskService.FindTask(context.Background(), taskFind)
+	if err != nil {
+		return false
+	}
+	for _, taskRun := range task.TaskRunList {
+		if taskRun.ID == taskRunId {
+			return taskRun.Status == api.TaskRunRunning
+		}
+	}
+	return false
+}
+
+// writeTaskRunLogEvent writes the task run log as a server-sent event, the data is the jsonapi payload of the log.
+func writeTaskRunLogEvent(w io.Writer, taskRunLog *api.TaskRunLog) error {
+	var buf strings.Builder
+	if err := jsonapi.MarshalPayload(&buf, taskRunLog); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "id: %d\ndata: %s\n\n", taskRunLog.ID, strings.TrimSpace(buf.String()))
+	return err
+}
+
+// createTaskRunLog records the structured log for the RUNNING task run of the task. It's best effort, and a failure
+// doesn't affect the task.
+func (s *Server) createTaskRunLog(task *api.Task, logType api.TaskRunLogType, payload interface{}) {
+	var taskRun *api.TaskRun
+	for _, run := range task.TaskRunList {
+		if run.Status == api.TaskRunRunning {
+			taskRun = run
+		}
+	}
+	if taskRun == nil {
+		return
+	}
+
+	bytes, err := json.Marshal(payload)
+	if err != nil {
+		s.l.Error("Failed to marshal task run log payload", zap.Int("task_id", task.ID), zap.Error(err))
+		return
+	}
+	// Use a new context since the log is still useful after the task is canceled.
+	if _, err := s.TaskService.CreateTaskRunLog(context.Background(), &api.TaskRunLogCreate{
+		TaskRunId: taskRun.ID,
+		Type:      logType,
+		Payload:   string(bytes),
+	}); err != nil {
+		s.l.Error("Failed to create task run log", zap.Int("task_id", task.ID), zap.Error(err))
+	}
 }
 
 func (s *Server) ComposeTaskListByPipelineAndStageId(ctx context.Context, pipelineId int, stageId int) ([]*api.Task, error) {
@@ -184,11 +372,33 @@ func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 			Message: fmt.Sprintf("Invalid task status transition from %v to %v. Applicable transition(s) %v", task.Status, taskStatusPatch.Status, applicableTaskStatusTransition[task.Status])}
 	}
 
+	// Moving the task out of PENDING_APPROVAL means approving it, which is blocked by the ERROR advice from SQL review.
+	if task.Status == api.TaskPendingApproval && task.Type == api.TaskDatabaseSchemaUpdate {
+		payload := &api.TaskDatabaseSchemaUpdatePayload{}
+		if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+			return nil, fmt.Errorf("invalid database schema update payload: %w", err)
+		}
+		if hasSQLReviewError(payload.AdviceList) {
+			return nil, &bytebase.Error{
+				Code:    bytebase.EINVALID,
+				Message: fmt.Sprintf("Task %v can't be approved because SQL review reports error(s)", task.Name)}
+		}
+	}
+
+	// The task may be stale, e.g. the worker finishing the task holds the task fetched before it's canceled.
+	taskStatusPatch.OldStatus = task.Status
 	updatedTask, err := s.TaskService.PatchTaskStatus(ctx, taskStatusPatch)
 	if err != nil {
 		return nil, fmt.Errorf("failed to change task %v(%v) status: %w", task.ID, task.Name, err)
 	}
 
+	// Stop the running task after it's marked as CANCELED, so the worker won't change its status afterwards.
+	if task.Status == api.TaskRunning && updatedTask.Status == api.TaskCanceled {
+		s.TaskScheduler.Cancel(task.ID)
+	}
+	// The status change may unblock the next task or start running this one.
+	s.TaskScheduler.Notify(task.PipelineId)
+
 	// Update the issue and activity for database create and database schema update tasks.
 	// TODO(tianzhou): This indiciates a coupling that pipeline belongs to an issue.
 	// A better way is to implement this as an onTaskStatusChange callback
@@ -216,6 +426,8 @@ func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 		level := api.ACTIVITY_INFO
 		if updatedTask.Status == api.TaskFailed {
 			level = api.ACTIVITY_ERROR
+		} else if updatedTask.Status == api.TaskCanceled {
+			level = api.ACTIVITY_WARNING
 		}
 		activityCreate := &api.ActivityCreate{
 			CreatorId:   taskStatusPatch.UpdaterId,
@@ -286,6 +498,28 @@ func (s *Server) ChangeTaskStatusWithPatch(ctx context.Context, task *api.Task,
 				}
 			}
 		}
+	} else if updatedTask.Status == api.TaskCanceled && task.DatabaseId != nil {
+		// Other tasks, e.g. backup and restore, don't belong to an issue, so we record the cancellation on the database.
+		payload, err := json.Marshal(api.ActivityDatabaseTaskCancelPayload{
+			TaskId:    task.ID,
+			TaskType:  task.Type,
+			OldStatus: task.Status,
+			TaskName:  task.Name,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal activity after canceling the task: %v, err: %w", task.Name, err)
+		}
+		activityCreate := &api.ActivityCreate{
+			CreatorId:   taskStatusPatch.UpdaterId,
+			ContainerId: *task.DatabaseId,
+			Type:        api.ActivityDatabaseTaskCancel,
+			Comment:     taskStatusPatch.Comment,
+			Level:       api.ACTIVITY_WARNING,
+			Payload:     string(payload),
+		}
+		if _, err := s.ActivityManager.CreateActivity(context.Background(), activityCreate, &ActivityMeta{}); err != nil {
+			return nil, err
+		}
 	}
 
 	return updatedTask, nil
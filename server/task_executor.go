@@ -2,13 +2,38 @@ package server
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"syscall"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/go-sql-driver/mysql"
 )
 
 type TaskExecutor interface {
 	// RunOnce will be called periodically by the scheduler until terminated is true.
 	// Note, it's possible that err could be non-nil while terminated is false, which
-	// usually indicates a transient error and will make scheduler retry later.
+	// usually indicates a transient error and will make scheduler retry later according
+	// to the task retry policy. Only return such error if it's safe to run the task again.
+	// ctx is canceled when the task is canceled, and the executor should stop the ongoing work as soon as possible.
 	RunOnce(ctx context.Context, server *Server, task *api.Task) (terminated bool, detail string, err error)
 }
+
+// isRetryableError returns true if err is caused by the connection or timeout, which may go away on retry.
+// The error must be wrapped with %w to be classified.
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	// net.Error covers the dial and read/write errors of the connection, e.g. *net.OpError and the timeout.
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -4,12 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/bin/bb/connect"
 	"github.com/bytebase/bytebase/bin/bb/dump/mysqldump"
+	"github.com/bytebase/bytebase/bin/bb/dump/pgdump"
+	"github.com/bytebase/bytebase/db"
+	"github.com/bytebase/bytebase/external/s3"
 	"go.uber.org/zap"
 )
 
@@ -39,15 +46,22 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 		}
 	}()
 	// Close the pipeline when the backup task is completed regardless its status.
+	// The pipeline stays open while the task is waiting to be retried, so the scheduler still picks it up.
 	defer func() {
+		if !terminated {
+			return
+		}
 		status := api.Pipeline_Done
 		pipelinePatch := &api.PipelinePatch{
 			ID:        task.PipelineId,
 			UpdaterId: api.SYSTEM_BOT_ID,
 			Status:    &status,
 		}
-		if _, err := server.PipelineService.PatchPipeline(context.Background(), pipelinePatch); err != nil {
-			err = fmt.Errorf("failed to update pipeline status: %w", err)
+		if _, patchErr := server.PipelineService.PatchPipeline(context.Background(), pipelinePatch); patchErr != nil {
+			exec.l.Error("Failed to close the backup pipeline",
+				zap.Int("pipeline_id", task.PipelineId),
+				zap.Error(patchErr),
+			)
 		}
 	}()
 
@@ -70,47 +84,341 @@ func (exec *DatabaseBackupTaskExecutor) RunOnce(ctx context.Context, server *Ser
 		zap.String("backup", backup.Name),
 	)
 
-	backupErr := backupDatabase(task.Instance, task.Database, backup, server.dataDir)
+	progress := func(table string, dumped, total int) {
+		server.createTaskRunLog(task, api.TaskRunLogBackupProgress, api.TaskRunLogBackupProgressPayload{
+			Table:            table,
+			DumpedTableCount: dumped,
+			TotalTableCount:  total,
+		})
+	}
+	var key []byte
+	var backupPayload *api.BackupPayload
+	var backupErr error
+	// The backup fails without the key, e.g. the encrypted automatic backup after the key is removed from the server.
+	if backup.Encrypted {
+		key, backupErr = server.getBackupEncryptionKey()
+	}
+	if backupErr == nil {
+		backupPayload, backupErr = backupDatabase(ctx, task.Instance, task.Database, backup, server.dataDir, key, progress)
+	}
 	// Update the status of the backup.
 	newBackupStatus := string(api.BackupStatusDone)
 	if backupErr != nil {
 		newBackupStatus = string(api.BackupStatusFailed)
 	}
-	if _, err = server.BackupService.PatchBackup(ctx, &api.BackupPatch{
+	backupPatch := &api.BackupPatch{
 		ID:        backup.ID,
-		Status:    newBackupStatus,
+		Status:    &newBackupStatus,
 		UpdaterId: api.SYSTEM_BOT_ID,
-	}); err != nil {
+	}
+	if backupPayload != nil {
+		bytes, err := json.Marshal(backupPayload)
+		if err != nil {
+			return true, "", fmt.Errorf("failed to marshal backup payload: %w", err)
+		}
+		payload := string(bytes)
+		backupPatch.Payload = &payload
+	}
+	// Use a new context since ctx is canceled if the backup is canceled.
+	if _, err = server.BackupService.PatchBackup(context.Background(), backupPatch); err != nil {
 		return true, "", fmt.Errorf("failed to patch backup: %w", err)
 	}
 
+	// Backup only reads the database and overwrites the backup file, so it's safe to retry. But only the connection
+	// and timeout errors are likely to go away, the others such as the permission error fail the task right away.
 	if backupErr != nil {
-		return true, "", backupErr
+		return !isRetryableError(backupErr), "", backupErr
 	}
 
-	return true, fmt.Sprintf("Backup database '%s'", task.Database.Name), nil
+	detail = fmt.Sprintf("Backup database '%s'", task.Database.Name)
+	if backup.VerificationStatus == api.BackupVerificationStatusPending {
+		// The backup is taken anyway, so the verification failure is only recorded in its status.
+		verificationStatus := string(api.BackupVerificationStatusPassed)
+		if err := exec.verifyBackup(ctx, server, task, payload.VerificationInstanceID, backup, backupPayload.TableRowCount); err != nil {
+			exec.l.Warn("Failed to verify backup",
+				zap.String("database", task.Database.Name),
+				zap.String("backup", backup.Name),
+				zap.Error(err),
+			)
+			verificationStatus = string(api.BackupVerificationStatusFailed)
+			detail = fmt.Sprintf("%s, verification failed: %v", detail, err)
+		} else {
+			detail = fmt.Sprintf("%s, verification passed", detail)
+		}
+		backupPatch := &api.BackupPatch{
+			ID:                 backup.ID,
+			VerificationStatus: &verificationStatus,
+			UpdaterId:          api.SYSTEM_BOT_ID,
+		}
+		if _, err = server.BackupService.PatchBackup(context.Background(), backupPatch); err != nil {
+			return true, "", fmt.Errorf("failed to patch backup verification status: %w", err)
+		}
+	}
+
+	return true, detail, nil
 }
 
-// backupDatabase will take a backup of a database.
-func backupDatabase(instance *api.Instance, database *api.Database, backup *api.Backup, dataDir string) error {
-	conn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, database.Name, nil /* tlsConfig */)
+// verifyBackup restores the backup into a scratch database on the instance instanceID, or the task instance if it's
+// 0, and compares the number of rows of each table with tableRowCount dumped. The scratch database is dropped
+// afterwards.
+func (exec *DatabaseBackupTaskExecutor) verifyBackup(ctx context.Context, server *Server, task *api.Task, instanceID int, backup *api.Backup, tableRowCount map[string]int64) error {
+	instance := task.Instance
+	if instanceID != 0 && instanceID != instance.ID {
+		var err error
+		instance, err = server.ComposeInstanceById(ctx, instanceID)
+		if err != nil {
+			return fmt.Errorf("failed to find verification instance ID %d: %w", instanceID, err)
+		}
+		if instance.Engine != task.Instance.Engine {
+			return fmt.Errorf("cannot verify %s backup on %s instance %q", task.Instance.Engine, instance.Engine, instance.Name)
+		}
+	}
+
+	databaseName := fmt.Sprintf("bb_verify_backup_%d", backup.ID)
+	// Drop the scratch database left by a previous attempt.
+	if err := executeOnInstance(ctx, exec.l, server.sqliteDir, instance, getDropDatabaseStatement(instance.Engine, databaseName)); err != nil {
+		return fmt.Errorf("failed to drop scratch database %q: %w", databaseName, err)
+	}
+	if err := executeOnInstance(ctx, exec.l, server.sqliteDir, instance, getCreateDatabaseStatement(instance.Engine, databaseName, task.Database.CharacterSet, task.Database.Collation)); err != nil {
+		return fmt.Errorf("failed to create scratch database %q: %w", databaseName, err)
+	}
+	defer func() {
+		// Use a new context since ctx is canceled if the backup is canceled.
+		if err := executeOnInstance(context.Background(), exec.l, server.sqliteDir, instance, getDropDatabaseStatement(instance.Engine, databaseName)); err != nil {
+			exec.l.Error("Failed to drop scratch database after verifying backup",
+				zap.String("instance", instance.Name),
+				zap.String("database", databaseName),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	if err := restoreDatabase(ctx, instance, databaseName, task.Database, backup, server.dataDir, server.getBackupEncryptionKey); err != nil {
+		return err
+	}
+	restoredRowCount, err := countTableRows(ctx, instance, databaseName)
 	if err != nil {
-		return fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		return fmt.Errorf("failed to count rows of scratch database %q: %w", databaseName, err)
+	}
+	return compareTableRowCount(tableRowCount, restoredRowCount)
+}
+
+// countTableRows returns the number of rows of each table in the database on the instance, keyed the same as the
+// row counts of the dump.
+func countTableRows(ctx context.Context, instance *api.Instance, databaseName string) (map[string]int64, error) {
+	switch instance.Engine {
+	case db.Mysql:
+		conn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, databaseName, nil /* tlsConfig */)
+		if err != nil {
+			return nil, fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %w", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer conn.Close()
+		return mysqldump.New(conn).CountTableRows(ctx, databaseName)
+	case db.Postgres:
+		conn, err := connect.NewPostgres(instance.Username, instance.Password, instance.Host, instance.Port, databaseName, instance.SSLMode, "" /* sslCA */, "" /* sslCert */, "" /* sslKey */)
+		if err != nil {
+			return nil, fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %w", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer conn.Close()
+		return pgdump.New(conn).CountTableRows(databaseName)
+	}
+	return nil, fmt.Errorf("backup is not supported for %s instance", instance.Engine)
+}
+
+// compareTableRowCount returns an error describing the first few differences if the tables or their row counts
+// restored differ from the ones dumped.
+func compareTableRowCount(dumped map[string]int64, restored map[string]int64) error {
+	var diffList []string
+	for table, count := range dumped {
+		restoredCount, ok := restored[table]
+		if !ok {
+			diffList = append(diffList, fmt.Sprintf("table %s is missing", table))
+		} else if restoredCount != count {
+			diffList = append(diffList, fmt.Sprintf("table %s has %d rows instead of %d", table, restoredCount, count))
+		}
+	}
+	for table := range restored {
+		if _, ok := dumped[table]; !ok {
+			diffList = append(diffList, fmt.Sprintf("table %s is unexpected", table))
+		}
+	}
+	if len(diffList) == 0 {
+		return nil
+	}
+	sort.Strings(diffList)
+	const maxDiffCount = 5
+	if len(diffList) > maxDiffCount {
+		diffList = append(diffList[:maxDiffCount], fmt.Sprintf("and %d more", len(diffList)-maxDiffCount))
+	}
+	return fmt.Errorf("restored database differs from the backup: %s", strings.Join(diffList, ", "))
+}
+
+// backupDatabase will take a backup of a database.
+// The backup is compressed according to backup.Compression, and then encrypted with key if backup.Encrypted is set.
+// If the binlog of the MySQL instance is archived, the backup is taken in a consistent snapshot, whose binlog
+// coordinates are returned in the payload for the point-in-time recovery.
+// The number of rows dumped for each table is also returned in the payload to verify the backup.
+// progress is called after dumping each table of the MySQL database.
+func backupDatabase(ctx context.Context, instance *api.Instance, database *api.Database, backup *api.Backup, dataDir string, key []byte, progress mysqldump.TableProgressFunc) (*api.BackupPayload, error) {
+	payload := &api.BackupPayload{}
+	var dumpDatabase func(w io.Writer) error
+	switch instance.Engine {
+	case db.Mysql:
+		conn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, database.Name, nil /* tlsConfig */)
+		if err != nil {
+			return nil, fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %w", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer conn.Close()
+		dp := mysqldump.New(conn)
+		dp.SetTableProgressFunc(progress)
+
+		dumpDatabase = func(w io.Writer) error {
+			if !instance.BinlogArchiveEnabled {
+				if err := dp.Dump(ctx, database.Name, w, false /* schemaOnly */, false /* dumpAll */); err != nil {
+					return err
+				}
+				payload.TableRowCount = dp.TableRowCount()
+				return nil
+			}
+			snapshot, err := dp.DumpConsistent(ctx, database.Name, w, false /* schemaOnly */)
+			if err != nil {
+				return err
+			}
+			payload.BinlogFile = snapshot.BinlogFile
+			payload.BinlogPosition = snapshot.BinlogPosition
+			payload.SnapshotTs = snapshot.Ts
+			payload.TableRowCount = dp.TableRowCount()
+			return nil
+		}
+	case db.Postgres:
+		conn, err := connect.NewPostgres(instance.Username, instance.Password, instance.Host, instance.Port, database.Name, instance.SSLMode, "" /* sslCA */, "" /* sslCert */, "" /* sslKey */)
+		if err != nil {
+			return nil, fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %w", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer conn.Close()
+		dp := pgdump.New(conn)
+
+		dumpDatabase = func(w io.Writer) error {
+			if err := dp.Dump(database.Name, w, false /* schemaOnly */); err != nil {
+				return err
+			}
+			payload.TableRowCount = dp.TableRowCount()
+			return nil
+		}
+	default:
+		return nil, fmt.Errorf("backup is not supported for %s instance", instance.Engine)
+	}
+
+	if !backup.Encrypted {
+		key = nil
+	}
+	dump := func(w io.Writer) error {
+		encoder, err := newBackupEncoder(w, backup.Compression, key)
+		if err != nil {
+			return err
+		}
+		if err := dumpDatabase(encoder); err != nil {
+			return err
+		}
+		return encoder.Close()
+	}
+
+	if backup.StorageBackend == api.BackupStorageBackendS3 {
+		client, err := getBackupS3Client(database, backup)
+		if err != nil {
+			return nil, err
+		}
+		// Stream the dump to the object storage so that it never lands on the local disk.
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(dump(pw))
+		}()
+		if err := client.Upload(ctx, backup.Path, pr); err != nil {
+			// Unblock the dump if the upload fails before reading all of it.
+			pr.CloseWithError(err)
+			return nil, fmt.Errorf("failed to upload backup to %q: %w", backup.Path, err)
+		}
+		return payload, nil
 	}
-	defer conn.Close()
-	dp := mysqldump.New(conn)
 
 	f, err := os.Create(filepath.Join(dataDir, backup.Path))
 	if err != nil {
-		return fmt.Errorf("failed to open backup path: %s", backup.Path)
+		return nil, fmt.Errorf("failed to open backup path: %s", backup.Path)
 	}
 	defer f.Close()
 
-	if err := dp.Dump(database.Name, f, false /* schemaOnly */, false /* dumpAll */); err != nil {
-		return err
+	if err := dump(f); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// isBackupSupported returns true if the databases of the engine can be backed up and restored.
+func isBackupSupported(engine db.Type) bool {
+	return engine == db.Mysql || engine == db.Postgres
+}
+
+// getBackupS3Client returns the client of the S3 storage where the backup of the database is stored. The location
+// is recorded on the backup, while the credentials are taken from the current backup storage of the database environment.
+func getBackupS3Client(database *api.Database, backup *api.Backup) (*s3.Client, error) {
+	storage, err := api.UnmarshalBackupStorage(database.Instance.Environment.BackupStorage)
+	if err != nil {
+		return nil, err
+	}
+	if storage.Backend != api.BackupStorageBackendS3 {
+		return nil, fmt.Errorf("backup storage of environment %q is %s, no credentials for backup %q in %s", database.Instance.Environment.Name, storage.Backend, backup.Name, api.BackupStorageBackendS3)
 	}
+	// The backup created before recording the location is in the current backup storage.
+	location := &api.BackupStorageLocation{
+		Endpoint:     storage.Endpoint,
+		Region:       storage.Region,
+		Bucket:       storage.Bucket,
+		Prefix:       storage.Prefix,
+		UsePathStyle: storage.UsePathStyle,
+	}
+	if backup.StorageLocation != "" {
+		if err := json.Unmarshal([]byte(backup.StorageLocation), location); err != nil {
+			return nil, fmt.Errorf("invalid storage location of backup %q: %w", backup.Name, err)
+		}
+	}
+	return s3.NewClient(s3.Config{
+		Endpoint:        location.Endpoint,
+		Region:          location.Region,
+		Bucket:          location.Bucket,
+		AccessKeyID:     storage.AccessKeyID,
+		SecretAccessKey: storage.SecretAccessKey,
+		UsePathStyle:    location.UsePathStyle,
+	})
+}
 
-	return nil
+// getBackupStorageAndPath returns the storage backend, the path and the JSON encoded api.BackupStorageLocation of a
+// new backup according to the backup storage of the database environment. The path is the object key for S3, and
+// it's relative to dataDir for the local storage, in which case its directory is created and the location is empty.
+func getBackupStorageAndPath(dataDir string, database *api.Database, name string, compression api.BackupCompression, encrypted bool) (api.BackupStorageBackend, string, string, error) {
+	storage, err := api.UnmarshalBackupStorage(database.Instance.Environment.BackupStorage)
+	if err != nil {
+		return "", "", "", err
+	}
+	if storage.Backend == api.BackupStorageBackendS3 {
+		location, err := json.Marshal(api.BackupStorageLocation{
+			Endpoint:     storage.Endpoint,
+			Region:       storage.Region,
+			Bucket:       storage.Bucket,
+			Prefix:       storage.Prefix,
+			UsePathStyle: storage.UsePathStyle,
+		})
+		if err != nil {
+			return "", "", "", err
+		}
+		return storage.Backend, storage.Prefix + path.Join("backup", "db", fmt.Sprintf("%d", database.ID), getBackupFileName(database, name, compression, encrypted)), string(location), nil
+	}
+
+	backupPath, err := getAndCreateBackupPath(dataDir, database, name, compression, encrypted)
+	if err != nil {
+		return "", "", "", err
+	}
+	return api.BackupStorageBackendLocal, backupPath, "", nil
 }
 
 // getAndCreateBackupDirectory returns the path of a database backup.
@@ -125,10 +433,22 @@ func getAndCreateBackupDirectory(dataDir string, database *api.Database) (string
 }
 
 // getAndCreateBackupPath returns the path of a database backup.
-func getAndCreateBackupPath(dataDir string, database *api.Database, name string) (string, error) {
+func getAndCreateBackupPath(dataDir string, database *api.Database, name string, compression api.BackupCompression, encrypted bool) (string, error) {
 	dir, err := getAndCreateBackupDirectory(dataDir, database)
 	if err != nil {
 		return "", err
 	}
-	return filepath.Join(dir, fmt.Sprintf("%s-%s-%s.sql", api.ProjectSlug(database.Project), database.Instance.Environment.Name, name)), nil
+	return filepath.Join(dir, getBackupFileName(database, name, compression, encrypted)), nil
+}
+
+// getBackupFileName returns the file name of a database backup, the extension reflects its encoding.
+func getBackupFileName(database *api.Database, name string, compression api.BackupCompression, encrypted bool) string {
+	ext := ".sql"
+	if compression == api.BackupCompressionGzip {
+		ext += ".gz"
+	}
+	if encrypted {
+		ext += ".enc"
+	}
+	return fmt.Sprintf("%s-%s-%s%s", api.ProjectSlug(database.Project), database.Instance.Environment.Name, name, ext)
 }
@@ -45,12 +45,13 @@ func (exec *DatabaseCreateTaskExecutor) RunOnce(ctx context.Context, server *Ser
 	instance := task.Instance
 	driver, err := db.Open(
 		instance.Engine,
-		db.DriverConfig{Logger: exec.l},
+		db.DriverConfig{Logger: exec.l, SqliteDir: server.sqliteDir},
 		db.ConnectionConfig{
 			Username: instance.Username,
 			Password: instance.Password,
 			Host:     instance.Host,
 			Port:     instance.Port,
+			SSLMode:  instance.SSLMode,
 		},
 		db.ConnectionContext{
 			EnvironmentName: instance.Environment.Name,
@@ -2,14 +2,24 @@ package server
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/bin/bb/connect"
 	"github.com/bytebase/bytebase/bin/bb/restore/mysqlrestore"
+	"github.com/bytebase/bytebase/bin/bb/restore/pgrestore"
+	"github.com/bytebase/bytebase/db"
 	"go.uber.org/zap"
 )
 
@@ -64,35 +74,305 @@ func (exec *DatabaseRestoreTaskExecutor) RunOnce(ctx context.Context, server *Se
 	if err != nil {
 		return true, "", fmt.Errorf("failed to find backup: %w", err)
 	}
+	// The backup may be restored into another database, whose environment may have a different backup storage.
+	backupDatabase, err := server.ComposeDatabaseByFind(ctx, &api.DatabaseFind{ID: &backup.DatabaseId})
+	if err != nil {
+		return true, "", fmt.Errorf("failed to find database for backup: %w", err)
+	}
+
+	databaseName := payload.DatabaseName
+	if databaseName == "" {
+		databaseName = task.Database.Name
+	}
 	exec.l.Debug("Start database restore...",
 		zap.String("instance", task.Instance.Name),
-		zap.String("database", task.Database.Name),
+		zap.String("database", databaseName),
 		zap.String("backup", backup.Name),
 	)
 
-	if err := restoreDatabase(task.Database, backup); err != nil {
+	if payload.DatabaseName != "" {
+		if err := exec.createDatabase(ctx, server, task, backupDatabase, payload.DatabaseName); err != nil {
+			return true, "", err
+		}
+	}
+
+	if err := restoreDatabase(ctx, task.Instance, databaseName, backupDatabase, backup, server.dataDir, server.getBackupEncryptionKey); err != nil {
 		return true, "", err
 	}
 
-	return true, fmt.Sprintf("Restore database '%s'", task.Database.Name), nil
+	if payload.PointInTimeTs != 0 {
+		if err := replayBinlog(ctx, server, backupDatabase, backup, task.Instance, databaseName, payload.PointInTimeTs); err != nil {
+			return true, "", err
+		}
+		return true, fmt.Sprintf("Recover database '%s' to %s", databaseName, time.Unix(payload.PointInTimeTs, 0).UTC().Format(time.RFC3339)), nil
+	}
+
+	return true, fmt.Sprintf("Restore database '%s'", databaseName), nil
 }
 
-// restoreDatabase will restore the database from a backup
-func restoreDatabase(database *api.Database, backup *api.Backup) error {
-	instance := database.Instance
-	conn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, database.Name, nil /* tlsConfig */)
+// replayBinlog replays the archived binlog of the backupDatabase instance, from the snapshot of backup up to and
+// including the second ts, into the database databaseName on instance. The events of other databases are skipped.
+func replayBinlog(ctx context.Context, server *Server, backupDatabase *api.Database, backup *api.Backup, instance *api.Instance, databaseName string, ts int64) error {
+	backupPayload, err := getBackupPayload(backup)
 	if err != nil {
-		return fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		return err
+	}
+	if backupPayload.BinlogFile == "" {
+		return fmt.Errorf("backup %q has no binlog position to recover from", backup.Name)
 	}
-	defer conn.Close()
-	f, err := os.OpenFile(backup.Path, os.O_RDONLY, os.ModePerm)
+
+	// The latest changes may not have been archived yet, including the ones in the binlog file being written.
+	if err := server.BinlogArchiver.ArchiveInstance(ctx, backupDatabase.Instance, true /* includeActive */); err != nil {
+		return fmt.Errorf("failed to archive binlog: %w", err)
+	}
+	dir := filepath.Join(server.dataDir, getBinlogDirectory(backupDatabase.Instance))
+	fileList, err := getArchivedBinlogFileList(dir)
 	if err != nil {
-		return fmt.Errorf("os.OpenFile(%q) error: %v", backup.Path, err)
+		return err
+	}
+	var replayList []string
+	for _, name := range fileList {
+		if name >= backupPayload.BinlogFile {
+			replayList = append(replayList, name)
+		}
+	}
+	if err := checkBinlogSequence(backupPayload.BinlogFile, replayList); err != nil {
+		return err
+	}
+
+	// --start-position only applies to the first file. --database filters the events after --rewrite-db, and
+	// --skip-gtids allows replaying the events into the instance which has executed them.
+	args := []string{
+		"--skip-gtids",
+		"--rewrite-db", fmt.Sprintf("%s->%s", backupDatabase.Name, databaseName),
+		"--database", databaseName,
+		"--start-position", strconv.FormatInt(backupPayload.BinlogPosition, 10),
+		// mysqlbinlog interprets the datetime in the local time zone.
+		"--stop-datetime", time.Unix(ts+1, 0).Format("2006-01-02 15:04:05"),
+	}
+	for _, name := range replayList {
+		args = append(args, filepath.Join(dir, name))
+	}
+	binlogCmd := exec.CommandContext(ctx, "mysqlbinlog", args...)
+	mysqlCmd := exec.CommandContext(ctx, "mysql", append(mysqlClientArgs(instance), databaseName)...)
+	mysqlCmd.Env = append(os.Environ(), "MYSQL_PWD="+instance.Password)
+	var binlogStderr, mysqlStderr bytes.Buffer
+	binlogCmd.Stderr = &binlogStderr
+	mysqlCmd.Stderr = &mysqlStderr
+	if mysqlCmd.Stdin, err = binlogCmd.StdoutPipe(); err != nil {
+		return err
+	}
+
+	if err := binlogCmd.Start(); err != nil {
+		return fmt.Errorf("failed to start mysqlbinlog: %w", err)
+	}
+	mysqlErr := mysqlCmd.Run()
+	binlogErr := binlogCmd.Wait()
+	if binlogErr != nil {
+		return fmt.Errorf("failed to read binlog: %w, output: %s", binlogErr, strings.TrimSpace(binlogStderr.String()))
+	}
+	if mysqlErr != nil {
+		return fmt.Errorf("failed to replay binlog: %w, output: %s", mysqlErr, strings.TrimSpace(mysqlStderr.String()))
+	}
+	return nil
+}
+
+// checkBinlogSequence checks that fileList is the complete sequence of the binlog files starting from first.
+// A gap means some binlog files were purged on the instance before being archived.
+func checkBinlogSequence(first string, fileList []string) error {
+	if len(fileList) == 0 || fileList[0] != first {
+		return fmt.Errorf("binlog file %q is not archived", first)
+	}
+	for i := 1; i < len(fileList); i++ {
+		prevBase, prevSeq, err := parseBinlogFileName(fileList[i-1])
+		if err != nil {
+			return err
+		}
+		base, seq, err := parseBinlogFileName(fileList[i])
+		if err != nil {
+			return err
+		}
+		if base != prevBase || seq != prevSeq+1 {
+			return fmt.Errorf("binlog is missing between %q and %q", fileList[i-1], fileList[i])
+		}
+	}
+	return nil
+}
+
+// parseBinlogFileName returns the basename and the sequence number of the binlog file name, e.g. binlog.000001.
+func parseBinlogFileName(name string) (string, int64, error) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", 0, fmt.Errorf("invalid binlog file name %q", name)
+	}
+	seq, err := strconv.ParseInt(name[i+1:], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid binlog file name %q", name)
+	}
+	return name[:i], seq, nil
+}
+
+// createDatabase creates the database to restore the backup of backupDatabase into, with the same character set
+// and collation, and registers it in the project of backupDatabase. The task creator is checked again since the
+// role or the project membership may have changed after the task is created.
+func (exec *DatabaseRestoreTaskExecutor) createDatabase(ctx context.Context, server *Server, task *api.Task, backupDatabase *api.Database, databaseName string) error {
+	instance := task.Instance
+	if err := server.checkRestoreTarget(ctx, task.CreatorId, backupDatabase, instance); err != nil {
+		if code := bytebase.ErrorCode(err); code == bytebase.EUNAUTHORIZED || code == bytebase.EINVALID {
+			return fmt.Errorf("cannot restore into instance %q: %s", instance.Name, bytebase.ErrorMessage(err))
+		}
+		return err
+	}
+	if err := executeOnInstance(ctx, exec.l, server.sqliteDir, instance, getCreateDatabaseStatement(instance.Engine, databaseName, backupDatabase.CharacterSet, backupDatabase.Collation)); err != nil {
+		return fmt.Errorf("failed to create database %q: %w", databaseName, err)
 	}
-	defer f.Close()
-	sc := bufio.NewScanner(f)
-	if err := mysqlrestore.Restore(conn, sc); err != nil {
-		return fmt.Errorf("mysqlrestore.Restore() got error: %v", err)
+
+	z, offset := time.Now().Zone()
+	databaseCreate := &api.DatabaseCreate{
+		CreatorId:      task.CreatorId,
+		ProjectId:      backupDatabase.ProjectId,
+		InstanceId:     instance.ID,
+		Name:           databaseName,
+		CharacterSet:   backupDatabase.CharacterSet,
+		Collation:      backupDatabase.Collation,
+		TimezoneName:   z,
+		TimezoneOffset: offset,
+	}
+	if _, err := server.DatabaseService.CreateDatabase(ctx, databaseCreate); err != nil {
+		// Just emits an error instead of failing, since we have another periodic job to sync db info.
+		// Though the db will be assigned to the default project instead of the desired project in that case.
+		exec.l.Error("failed to record database after creating database for restore",
+			zap.String("database_name", databaseName),
+			zap.Int("instance_id", instance.ID),
+			zap.Error(err),
+		)
 	}
 	return nil
 }
+
+// executeOnInstance executes the statement on the instance without selecting a database.
+func executeOnInstance(ctx context.Context, logger *zap.Logger, sqliteDir string, instance *api.Instance, statement string) error {
+	driver, err := db.Open(
+		instance.Engine,
+		db.DriverConfig{Logger: logger, SqliteDir: sqliteDir},
+		db.ConnectionConfig{
+			Username: instance.Username,
+			Password: instance.Password,
+			Host:     instance.Host,
+			Port:     instance.Port,
+			SSLMode:  instance.SSLMode,
+		},
+		db.ConnectionContext{
+			EnvironmentName: instance.Environment.Name,
+			InstanceName:    instance.Name,
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect instance: %v with user: %v. %w", instance.Name, instance.Username, err)
+	}
+	defer driver.Close(context.Background())
+
+	return driver.Execute(ctx, statement)
+}
+
+// getCreateDatabaseStatement returns the statement to create the database with the character set and collation.
+func getCreateDatabaseStatement(engine db.Type, databaseName string, characterSet string, collation string) string {
+	if engine == db.Postgres {
+		// Use template0 since the encoding and the collation may differ from template1.
+		statement := fmt.Sprintf("CREATE DATABASE \"%s\" TEMPLATE template0", databaseName)
+		if characterSet != "" {
+			statement += fmt.Sprintf(" ENCODING '%s'", characterSet)
+		}
+		if collation != "" {
+			statement += fmt.Sprintf(" LC_COLLATE '%s'", collation)
+		}
+		return statement
+	}
+
+	statement := fmt.Sprintf("CREATE DATABASE `%s`", databaseName)
+	if characterSet != "" {
+		statement += fmt.Sprintf(" CHARACTER SET %s", characterSet)
+	}
+	if collation != "" {
+		statement += fmt.Sprintf(" COLLATE %s", collation)
+	}
+	return statement
+}
+
+// getDropDatabaseStatement returns the statement to drop the database if it exists.
+func getDropDatabaseStatement(engine db.Type, databaseName string) string {
+	if engine == db.Postgres {
+		return fmt.Sprintf("DROP DATABASE IF EXISTS \"%s\"", databaseName)
+	}
+	return fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", databaseName)
+}
+
+// openBackup returns the reader of the backup file of database, and the caller should close it after reading.
+func openBackup(ctx context.Context, database *api.Database, backup *api.Backup, dataDir string) (io.ReadCloser, error) {
+	if backup.StorageBackend == api.BackupStorageBackendS3 {
+		client, err := getBackupS3Client(database, backup)
+		if err != nil {
+			return nil, err
+		}
+		// Stream the backup from the object storage instead of downloading it to the local disk first.
+		r, err := client.Download(ctx, backup.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download backup %q: %w", backup.Path, err)
+		}
+		return r, nil
+	}
+	r, err := os.OpenFile(filepath.Join(dataDir, backup.Path), os.O_RDONLY, os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("os.OpenFile(%q) error: %v", backup.Path, err)
+	}
+	return r, nil
+}
+
+// restoreDatabase will restore the backup of backupDatabase into the database databaseName on instance.
+// getKey returns the key to decrypt the backup, and it's only called if the backup is encrypted.
+func restoreDatabase(ctx context.Context, instance *api.Instance, databaseName string, backupDatabase *api.Database, backup *api.Backup, dataDir string, getKey func() ([]byte, error)) error {
+	var restore func(sc *bufio.Scanner) error
+	switch instance.Engine {
+	case db.Mysql:
+		conn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, databaseName, nil /* tlsConfig */)
+		if err != nil {
+			return fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer conn.Close()
+
+		restore = func(sc *bufio.Scanner) error {
+			if err := mysqlrestore.Restore(ctx, conn, sc); err != nil {
+				return fmt.Errorf("mysqlrestore.Restore() got error: %v", err)
+			}
+			return nil
+		}
+	case db.Postgres:
+		conn, err := connect.NewPostgres(instance.Username, instance.Password, instance.Host, instance.Port, databaseName, instance.SSLMode, "" /* sslCA */, "" /* sslCert */, "" /* sslKey */)
+		if err != nil {
+			return fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer conn.Close()
+
+		restore = func(sc *bufio.Scanner) error {
+			if err := pgrestore.Restore(conn, sc); err != nil {
+				return fmt.Errorf("pgrestore.Restore() got error: %v", err)
+			}
+			return nil
+		}
+	default:
+		return fmt.Errorf("restore is not supported for %s instance", instance.Engine)
+	}
+
+	r, err := openBackup(ctx, backupDatabase, backup, dataDir)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	// The format is detected from the content, so the key is only needed for the encrypted backups.
+	dr, err := newBackupDecoder(r, getKey)
+	if err != nil {
+		return fmt.Errorf("failed to decode backup %q: %w", backup.Path, err)
+	}
+	return restore(bufio.NewScanner(dr))
+}
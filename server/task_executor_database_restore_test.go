@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestCheckBinlogSequence(t *testing.T) {
+	tests := []struct {
+		first    string
+		fileList []string
+		wantErr  bool
+	}{
+		{
+			first:    "binlog.000001",
+			fileList: []string{"binlog.000001"},
+		},
+		{
+			first:    "binlog.000008",
+			fileList: []string{"binlog.000008", "binlog.000009", "binlog.000010"},
+		},
+		{
+			first:    "binlog.000001",
+			fileList: nil,
+			wantErr:  true,
+		},
+		// The first binlog file has been purged before being archived.
+		{
+			first:    "binlog.000001",
+			fileList: []string{"binlog.000002", "binlog.000003"},
+			wantErr:  true,
+		},
+		{
+			first:    "binlog.000001",
+			fileList: []string{"binlog.000001", "binlog.000003"},
+			wantErr:  true,
+		},
+		{
+			first:    "binlog.000001",
+			fileList: []string{"binlog.000001", "binlog.000002", "binlog.000002"},
+			wantErr:  true,
+		},
+		// The binlog basename has changed.
+		{
+			first:    "binlog.000001",
+			fileList: []string{"binlog.000001", "mysql-bin.000002"},
+			wantErr:  true,
+		},
+		{
+			first:    "binlog.000001",
+			fileList: []string{"binlog.000001", "binlog.index"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		err := checkBinlogSequence(tc.first, tc.fileList)
+		if tc.wantErr != (err != nil) {
+			t.Errorf("first=%q fileList=%v: expected error %v, got %v", tc.first, tc.fileList, tc.wantErr, err)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,6 +11,11 @@ import (
 	"time"
 
 	"github.com/bytebase/bytebase/api"
+	"github.com/bytebase/bytebase/bin/bb/connect"
+	"github.com/bytebase/bytebase/bin/bb/dump/mysqldump"
+	"github.com/bytebase/bytebase/bin/bb/dump/pgdump"
+	"github.com/bytebase/bytebase/bin/bb/restore/mysqlrestore"
+	"github.com/bytebase/bytebase/bin/bb/restore/pgrestore"
 	"github.com/bytebase/bytebase/db"
 	"go.uber.org/zap"
 )
@@ -113,12 +120,13 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 	instance := task.Instance
 	driver, err := db.Open(
 		instance.Engine,
-		db.DriverConfig{Logger: exec.l},
+		db.DriverConfig{Logger: exec.l, SqliteDir: server.sqliteDir},
 		db.ConnectionConfig{
 			Username: instance.Username,
 			Password: instance.Password,
 			Host:     instance.Host,
 			Port:     instance.Port,
+			SSLMode:  instance.SSLMode,
 			Database: databaseName,
 		},
 		db.ConnectionContext{
@@ -126,8 +134,9 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 			InstanceName:    instance.Name,
 		},
 	)
+	// Nothing has been applied yet, so it's safe to retry on the connection error.
 	if err != nil {
-		return true, "", fmt.Errorf("failed to connect instance: %v with user: %v. %w", instance.Name, instance.Username, err)
+		return false, "", fmt.Errorf("failed to connect instance: %v with user: %v. %w", instance.Name, instance.Username, err)
 	}
 
 	defer driver.Close(context.Background())
@@ -137,21 +146,80 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 		zap.String("database", databaseName),
 		zap.String("engine", mi.Engine.String()),
 		zap.String("type", mi.Type.String()),
+		zap.Bool("dry_run", payload.DryRun),
 		zap.String("sql", sql),
 	)
 
 	setup, err := driver.NeedsSetupMigration(ctx)
 	if err != nil {
-		return true, "", fmt.Errorf("failed to check migration setup for instance: %v, %w", instance.Name, err)
+		return false, "", fmt.Errorf("failed to check migration setup for instance: %v, %w", instance.Name, err)
 	}
 	if setup {
-		return true, "", fmt.Errorf("missing migration schema for instance: %v", instance.Name)
+		// Upgrade the migration schema created by the older bytebase version in place, or create the missing one like
+		// creating the instance does. It's a no-op if the schema is already up to date.
+		if err := driver.SetupMigrationIfNeeded(ctx); err != nil {
+			return true, "", fmt.Errorf("failed to setup migration schema for instance: %v, %w", instance.Name, err)
+		}
+		setup, err = driver.NeedsSetupMigration(ctx)
+		if err != nil {
+			return false, "", fmt.Errorf("failed to check migration setup for instance: %v, %w", instance.Name, err)
+		}
+		if setup {
+			return true, "", fmt.Errorf("missing or outdated migration schema for instance: %v", instance.Name)
+		}
 	}
 
-	if err := driver.ExecuteMigration(ctx, mi, sql); err != nil {
+	if payload.DryRun {
+		result, err := driver.DryRunMigration(ctx, mi, sql)
+		if err != nil {
+			return true, "", err
+		}
+		if mi.Type != db.Baseline && !result.Validated {
+			if err := exec.dryRunOnScratchDatabase(ctx, server, task, sql); err != nil {
+				return true, "", fmt.Errorf("dry run failed: %w", err)
+			}
+		}
+
+		if mi.Type == db.Baseline {
+			return true, fmt.Sprintf("Dry run passed, would establish baseline version %s for database '%s'", mi.Version, databaseName), nil
+		}
+		return true, fmt.Sprintf("Dry run passed, would apply migration version %s (sequence %d) with %d statement(s) to database '%s':\n\n%s",
+			mi.Version, result.Sequence, len(result.StatementList), databaseName, strings.Join(result.StatementList, ";\n")), nil
+	}
+
+	// Record the statement before and after executing it, so the user can follow the progress while the task is running.
+	statementLogger := func(log *db.StatementLog) {
+		if !log.Done {
+			server.createTaskRunLog(task, api.TaskRunLogStatementStart, api.TaskRunLogStatementStartPayload{
+				Statement: log.Statement,
+			})
+			return
+		}
+		logPayload := api.TaskRunLogStatementPayload{
+			Statement:    log.Statement,
+			DurationMs:   log.Duration.Milliseconds(),
+			RowsAffected: log.RowsAffected,
+		}
+		if log.Err != nil {
+			logPayload.Error = log.Err.Error()
+		}
+		server.createTaskRunLog(task, api.TaskRunLogStatement, logPayload)
+	}
+	if err := driver.ExecuteMigration(db.WithStatementLogger(ctx, statementLogger), mi, sql); err != nil {
 		return true, "", err
 	}
 
+	// The migration has been applied, so we only emit the error if we fail to record the schema snapshot. The drift
+	// detection skips the migration without a snapshot.
+	if err := exec.recordSchemaSnapshot(ctx, driver, mi); err != nil {
+		exec.l.Error("Failed to record schema snapshot after migration",
+			zap.Int("task_id", task.ID),
+			zap.String("database", databaseName),
+			zap.String("version", mi.Version),
+			zap.Error(err),
+		)
+	}
+
 	detail = fmt.Sprintf("Applied migration version %s to database '%s'", mi.Version, databaseName)
 	if mi.Type == db.Baseline {
 		detail = fmt.Sprintf("Established baseline version %s for database '%s'", mi.Version, databaseName)
@@ -159,3 +227,114 @@ func (exec *SchemaUpdateTaskExecutor) RunOnce(ctx context.Context, server *Serve
 
 	return true, detail, nil
 }
+
+// recordSchemaSnapshot saves the schema snapshot of the migrated database on the migration history.
+func (exec *SchemaUpdateTaskExecutor) recordSchemaSnapshot(ctx context.Context, driver db.Driver, mi *db.MigrationInfo) error {
+	_, schemaList, err := driver.SyncSchema(ctx)
+	if err != nil {
+		return err
+	}
+	for _, schema := range schemaList {
+		if schema.Name == mi.Database {
+			return driver.UpdateMigrationHistorySchema(ctx, mi, db.SchemaSnapshot(schema))
+		}
+	}
+	return fmt.Errorf("database %q not found", mi.Database)
+}
+
+// dryRunOnScratchDatabase validates the statement by executing it on a scratch database with the same schema as the
+// task database, so that the dry run never locks, rewrites or advances the sequences of the task database. The scratch
+// database is created on the task instance and dropped afterwards.
+func (exec *SchemaUpdateTaskExecutor) dryRunOnScratchDatabase(ctx context.Context, server *Server, task *api.Task, statement string) error {
+	instance := task.Instance
+	if !isBackupSupported(instance.Engine) {
+		return fmt.Errorf("dry run is not supported for %s instance", instance.Engine)
+	}
+
+	databaseName := fmt.Sprintf("bb_dry_run_%d", task.ID)
+	// Drop the scratch database left by a previous attempt.
+	if err := executeOnInstance(ctx, exec.l, server.sqliteDir, instance, getDropDatabaseStatement(instance.Engine, databaseName)); err != nil {
+		return fmt.Errorf("failed to drop scratch database %q: %w", databaseName, err)
+	}
+	if err := executeOnInstance(ctx, exec.l, server.sqliteDir, instance, getCreateDatabaseStatement(instance.Engine, databaseName, task.Database.CharacterSet, task.Database.Collation)); err != nil {
+		return fmt.Errorf("failed to create scratch database %q: %w", databaseName, err)
+	}
+	defer func() {
+		// Use a new context since ctx is canceled if the task is canceled.
+		if err := executeOnInstance(context.Background(), exec.l, server.sqliteDir, instance, getDropDatabaseStatement(instance.Engine, databaseName)); err != nil {
+			exec.l.Error("Failed to drop scratch database after dry run",
+				zap.String("instance", instance.Name),
+				zap.String("database", databaseName),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	if err := copyDatabaseSchema(ctx, instance, task.Database.Name, databaseName); err != nil {
+		return fmt.Errorf("failed to copy schema to scratch database %q: %w", databaseName, err)
+	}
+
+	driver, err := db.Open(
+		instance.Engine,
+		db.DriverConfig{Logger: exec.l, SqliteDir: server.sqliteDir},
+		db.ConnectionConfig{
+			Username: instance.Username,
+			Password: instance.Password,
+			Host:     instance.Host,
+			Port:     instance.Port,
+			SSLMode:  instance.SSLMode,
+			Database: databaseName,
+		},
+		db.ConnectionContext{
+			EnvironmentName: instance.Environment.Name,
+			InstanceName:    instance.Name,
+		},
+	)
+	if err != nil {
+		return err
+	}
+	defer driver.Close(context.Background())
+
+	return driver.Execute(ctx, statement)
+}
+
+// copyDatabaseSchema dumps the schema of the source database without data, and restores it into the target database
+// on the same instance.
+func copyDatabaseSchema(ctx context.Context, instance *api.Instance, sourceDatabaseName string, targetDatabaseName string) error {
+	var buf bytes.Buffer
+	switch instance.Engine {
+	case db.Mysql:
+		sourceConn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, sourceDatabaseName, nil /* tlsConfig */)
+		if err != nil {
+			return fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer sourceConn.Close()
+		if err := mysqldump.New(sourceConn).Dump(ctx, sourceDatabaseName, &buf, true /* schemaOnly */, false /* dumpAll */); err != nil {
+			return err
+		}
+
+		targetConn, err := connect.NewMysql(instance.Username, instance.Password, instance.Host, instance.Port, targetDatabaseName, nil /* tlsConfig */)
+		if err != nil {
+			return fmt.Errorf("connect.NewMysql(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer targetConn.Close()
+		return mysqlrestore.Restore(ctx, targetConn, bufio.NewScanner(&buf))
+	case db.Postgres:
+		sourceConn, err := connect.NewPostgres(instance.Username, instance.Password, instance.Host, instance.Port, sourceDatabaseName, instance.SSLMode, "" /* sslCA */, "" /* sslCert */, "" /* sslKey */)
+		if err != nil {
+			return fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer sourceConn.Close()
+		if err := pgdump.New(sourceConn).Dump(sourceDatabaseName, &buf, true /* schemaOnly */); err != nil {
+			return err
+		}
+
+		targetConn, err := connect.NewPostgres(instance.Username, instance.Password, instance.Host, instance.Port, targetDatabaseName, instance.SSLMode, "" /* sslCA */, "" /* sslCert */, "" /* sslKey */)
+		if err != nil {
+			return fmt.Errorf("connect.NewPostgres(%q, %q, %q, %q) got error: %v", instance.Username, instance.Password, instance.Host, instance.Port, err)
+		}
+		defer targetConn.Close()
+		return pgrestore.Restore(targetConn, bufio.NewScanner(&buf))
+	}
+	return fmt.Errorf("copying schema is not supported for %s instance", instance.Engine)
+}
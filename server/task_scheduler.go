@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/bytebase/bytebase/api"
@@ -10,152 +12,241 @@ import (
 )
 
 const (
-	TASK_SCHEDULE_INTERVAL = time.Duration(1) * time.Second
+	// TASK_RECONCILE_INTERVAL is the interval for the scheduler to inspect all open pipelines and running tasks.
+	// The scheduler is driven by Notify otherwise.
+	TASK_RECONCILE_INTERVAL = time.Duration(1) * time.Minute
+	// TASK_RETRY_MAX_BACKOFF caps the exponential backoff between the task attempts.
+	TASK_RETRY_MAX_BACKOFF = time.Duration(1) * time.Hour
 )
 
-func NewTaskScheduler(logger *zap.Logger, server *Server) *TaskScheduler {
+var (
+	// defaultTaskRetryPolicy is used for the task types not configured by the environment task retry policy.
+	// Only the executors returning non-terminal errors are retried, and they only do so if retrying is safe.
+	defaultTaskRetryPolicy = map[api.TaskType]api.TaskRetryPolicy{
+		api.TaskDatabaseSchemaUpdate: {MaxAttempts: 3, BackoffSeconds: 10},
+		api.TaskDatabaseBackup:       {MaxAttempts: 3, BackoffSeconds: 30},
+	}
+)
+
+func NewTaskScheduler(logger *zap.Logger, server *Server, concurrency int, instanceConcurrency int) *TaskScheduler {
 	return &TaskScheduler{
-		l:         logger,
-		executors: make(map[string]TaskExecutor),
-		server:    server,
+		l:                   logger,
+		executors:           make(map[string]TaskExecutor),
+		server:              server,
+		concurrency:         concurrency,
+		instanceConcurrency: instanceConcurrency,
+		runningTasks:        make(map[int]context.CancelFunc),
+		runningInstances:    make(map[int]int),
+		wake:                make(chan struct{}, 1),
+		pendingPipelines:    make(map[int]bool),
+		blockedPipelines:    make(map[int]bool),
 	}
 }
 
+// TaskScheduler dispatches the RUNNING tasks to a pool of workers, each task runs on its own goroutine.
 type TaskScheduler struct {
 	l         *zap.Logger
 	executors map[string]TaskExecutor
 
 	server *Server
+
+	// concurrency is the maximum number of tasks running at the same time.
+	concurrency int
+	// instanceConcurrency is the maximum number of tasks running on the same instance at the same time,
+	// so a slow instance can not occupy all the workers.
+	instanceConcurrency int
+
+	// mu protects the fields below.
+	mu sync.Mutex
+	// runningTasks maps the id of the task being run by a worker to the function canceling the task context.
+	runningTasks map[int]context.CancelFunc
+	// runningInstances maps the instance id to the number of tasks being run by a worker on the instance.
+	runningInstances map[int]int
+	// pendingPipelines is the set of the pipeline ids queued by Notify.
+	pendingPipelines map[int]bool
+	// blockedPipelines is the set of the pipeline ids having RUNNING tasks waiting for a free worker.
+	blockedPipelines map[int]bool
+
+	// wake is signaled when there are pending pipelines.
+	wake chan struct{}
 }
 
 func (s *TaskScheduler) Run() error {
 	go func() {
+		// Reconcile once on startup to pick up the tasks left by the previous run.
+		s.withRecover(s.reconcile)
+
+		ticker := time.NewTicker(TASK_RECONCILE_INTERVAL)
+		defer ticker.Stop()
 		for {
-			func() {
-				defer func() {
-					if r := recover(); r != nil {
-						err, ok := r.(error)
-						if !ok {
-							err = fmt.Errorf("%v", r)
-						}
-						s.l.Error("Scheduler PANIC RECOVER", zap.Error(err))
-					}
-				}()
+			select {
+			case <-s.wake:
+				s.withRecover(s.processPendingPipelines)
+			case <-ticker.C:
+				s.withRecover(s.reconcile)
+			}
+		}
+	}()
 
-				// Inspect all open pipelines and schedule the next PENDING task if applicable
-				pipelineStatus := api.Pipeline_Open
-				pipelineFind := &api.PipelineFind{
-					Status: &pipelineStatus,
-				}
-				pipelineList, err := s.server.PipelineService.FindPipelineList(context.Background(), pipelineFind)
-				if err != nil {
-					s.l.Error("Failed to retrieve open pipelines", zap.Error(err))
-				}
-				for _, pipeline := range pipelineList {
-					if pipeline.ID == api.ONBOARDING_PIPELINE_ID {
-						continue
-					}
-					if err := s.server.ComposePipelineRelationship(context.Background(), pipeline); err != nil {
-						s.l.Error("Failed to fetch pipeline relationship",
-							zap.Int("id", pipeline.ID),
-							zap.String("name", pipeline.Name),
-							zap.Error(err),
-						)
-						continue
-					}
+	return nil
+}
 
-					for _, stage := range pipeline.StageList {
-						for _, task := range stage.TaskList {
-							if task.Status != api.TaskDone {
-								if task.Status == api.TaskPending {
-									_, err = s.Schedule(context.Background(), task)
-									if err != nil {
-										s.l.Error("Failed to schedule next running task",
-											zap.Int("id", task.ID),
-											zap.String("name", task.Name),
-											zap.Error(err),
-										)
-									}
-								}
-								goto PIPELINE_END
-							}
-						}
-					}
-				PIPELINE_END:
-				}
+// Notify queues the pipeline for the scheduler to schedule its next PENDING task and dispatch its RUNNING tasks.
+// It's called on the events changing what to run, e.g. creating the pipeline and changing the task status.
+func (s *TaskScheduler) Notify(pipelineId int) {
+	s.mu.Lock()
+	s.pendingPipelines[pipelineId] = true
+	s.mu.Unlock()
 
-				// Inspect all running tasks
-				taskStatus := api.TaskRunning
-				taskFind := &api.TaskFind{
-					Status: &taskStatus,
-				}
-				taskList, err := s.server.TaskService.FindTaskList(context.Background(), taskFind)
-				if err != nil {
-					s.l.Error("Failed to retrieve running tasks", zap.Error(err))
-				}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+		// The scheduler has been woken up already, and it will process all the pending pipelines.
+	}
+}
 
-				for _, task := range taskList {
-					if task.ID == api.ONBOARDING_TASK_ID1 || task.ID == api.ONBOARDING_TASK_ID2 {
-						continue
-					}
+func (s *TaskScheduler) withRecover(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = fmt.Errorf("%v", r)
+			}
+			s.l.Error("Scheduler PANIC RECOVER", zap.Error(err))
+		}
+	}()
 
-					executor, ok := s.executors[string(task.Type)]
-					if !ok {
-						s.l.Error("Skip running task with unknown type",
-							zap.Int("id", task.ID),
-							zap.String("name", task.Name),
-							zap.String("type", string(task.Type)),
-						)
-						continue
-					}
+	f()
+}
+
+// processPendingPipelines processes the pipelines queued by Notify.
+func (s *TaskScheduler) processPendingPipelines() {
+	s.mu.Lock()
+	pipelineIdList := make([]int, 0, len(s.pendingPipelines))
+	for pipelineId := range s.pendingPipelines {
+		pipelineIdList = append(pipelineIdList, pipelineId)
+	}
+	s.pendingPipelines = make(map[int]bool)
+	s.mu.Unlock()
+
+	for _, pipelineId := range pipelineIdList {
+		if pipelineId == api.ONBOARDING_PIPELINE_ID {
+			continue
+		}
+		pipelineFind := &api.PipelineFind{
+			ID: &pipelineId,
+		}
+		pipeline, err := s.server.PipelineService.FindPipeline(context.Background(), pipelineFind)
+		if err != nil {
+			s.l.Error("Failed to retrieve pipeline", zap.Int("id", pipelineId), zap.Error(err))
+			continue
+		}
+		if pipeline.Status == api.Pipeline_Open {
+			s.scheduleNextTask(pipeline)
+		}
+
+		// The RUNNING task may belong to the closed pipeline, e.g. the backup task waiting to be retried.
+		taskStatus := api.TaskRunning
+		taskFind := &api.TaskFind{
+			PipelineId: &pipelineId,
+			Status:     &taskStatus,
+		}
+		s.dispatchTaskList(taskFind)
+	}
+}
+
+// reconcile inspects all open pipelines and running tasks. It's a safety net for the missing events,
+// and it also picks up the tasks waiting for their execution window.
+func (s *TaskScheduler) reconcile() {
+	pipelineStatus := api.Pipeline_Open
+	pipelineFind := &api.PipelineFind{
+		Status: &pipelineStatus,
+	}
+	pipelineList, err := s.server.PipelineService.FindPipelineList(context.Background(), pipelineFind)
+	if err != nil {
+		s.l.Error("Failed to retrieve open pipelines", zap.Error(err))
+	}
+	for _, pipeline := range pipelineList {
+		if pipeline.ID == api.ONBOARDING_PIPELINE_ID {
+			continue
+		}
+		s.scheduleNextTask(pipeline)
+	}
 
-					// This fetches quite a bit info and may cause performance issue if we have many ongoing tasks
-					// We may optimize this in the future since only some relationship info is needed by the executor
-					if err := s.server.ComposeTaskRelationship(context.Background(), task); err != nil {
-						s.l.Error("Failed to fetch task relationship",
+	taskStatus := api.TaskRunning
+	taskFind := &api.TaskFind{
+		Status: &taskStatus,
+	}
+	s.dispatchTaskList(taskFind)
+}
+
+// scheduleNextTask schedules the next PENDING task of the open pipeline if applicable.
+func (s *TaskScheduler) scheduleNextTask(pipeline *api.Pipeline) {
+	if err := s.server.ComposePipelineRelationship(context.Background(), pipeline); err != nil {
+		s.l.Error("Failed to fetch pipeline relationship",
+			zap.Int("id", pipeline.ID),
+			zap.String("name", pipeline.Name),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for _, stage := range pipeline.StageList {
+		for _, task := range stage.TaskList {
+			if task.Status != api.TaskDone {
+				if task.Status == api.TaskPending {
+					if _, err := s.Schedule(context.Background(), task); err != nil {
+						s.l.Error("Failed to schedule next running task",
 							zap.Int("id", task.ID),
 							zap.String("name", task.Name),
-							zap.String("type", string(task.Type)),
+							zap.Error(err),
 						)
-						continue
-					}
-
-					done, detail, err := executor.RunOnce(context.Background(), s.server, task)
-					if done {
-						if err != nil {
-							taskStatusPatch := &api.TaskStatusPatch{
-								ID:        task.ID,
-								UpdaterId: api.SYSTEM_BOT_ID,
-								Status:    api.TaskFailed,
-								Comment:   err.Error(),
-							}
-							s.server.ChangeTaskStatusWithPatch(context.Background(), task, taskStatusPatch)
-							continue
-						}
-
-						taskStatusPatch := &api.TaskStatusPatch{
-							ID:        task.ID,
-							UpdaterId: api.SYSTEM_BOT_ID,
-							Status:    api.TaskDone,
-							Comment:   detail,
-						}
-						_, err = s.server.ChangeTaskStatusWithPatch(context.Background(), task, taskStatusPatch)
-						if err != nil {
-							s.l.Error("Failed to mark task as DONE",
-								zap.Int("id", task.ID),
-								zap.String("name", task.Name),
-								zap.Error(err),
-							)
-						}
 					}
 				}
-			}()
+				return
+			}
+		}
+	}
+}
 
-			time.Sleep(TASK_SCHEDULE_INTERVAL)
+// dispatchTaskList dispatches the RUNNING tasks found by find to the workers.
+func (s *TaskScheduler) dispatchTaskList(find *api.TaskFind) {
+	taskList, err := s.server.TaskService.FindTaskList(context.Background(), find)
+	if err != nil {
+		s.l.Error("Failed to retrieve running tasks", zap.Error(err))
+		return
+	}
+
+	for _, task := range taskList {
+		if task.ID == api.ONBOARDING_TASK_ID1 || task.ID == api.ONBOARDING_TASK_ID2 {
+			continue
 		}
-	}()
 
-	return nil
+		executor, ok := s.executors[string(task.Type)]
+		if !ok {
+			s.l.Error("Skip running task with unknown type",
+				zap.Int("id", task.ID),
+				zap.String("name", task.Name),
+				zap.String("type", string(task.Type)),
+			)
+			continue
+		}
+
+		// The task is waiting for the retry backoff, and retryOrFail notifies the scheduler once it elapses.
+		if isWaitingForRetry(task, time.Now()) {
+			continue
+		}
+
+		// The task stays RUNNING if there is no free worker, and release notifies the scheduler once a worker is freed.
+		ctx, ok := s.acquire(task)
+		if !ok {
+			continue
+		}
+		go func(ctx context.Context, task *api.Task) {
+			defer s.release(task)
+			s.runTask(ctx, executor, task)
+		}(ctx, task)
+	}
 }
 
 func (s *TaskScheduler) Register(taskType string, executor TaskExecutor) {
@@ -168,7 +259,24 @@ func (s *TaskScheduler) Register(taskType string, executor TaskExecutor) {
 	s.executors[taskType] = executor
 }
 
+// Schedule changes the PENDING task to RUNNING so a worker picks it up. The task stays PENDING if it's not allowed
+// to start yet, either because of its earliest allowed time or the maintenance window of its environment, and the
+// reconciliation tries again later.
 func (s *TaskScheduler) Schedule(ctx context.Context, task *api.Task) (*api.Task, error) {
+	now := time.Now()
+	if task.EarliestAllowedTs > now.Unix() {
+		return task, nil
+	}
+	if isMaintenanceWindowRequired(task) {
+		allowed, err := s.inMaintenanceWindow(ctx, task, now)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return task, nil
+		}
+	}
+
 	updatedTask, err := s.server.ChangeTaskStatus(ctx, task, api.TaskRunning, api.SYSTEM_BOT_ID)
 	if err != nil {
 		return nil, err
@@ -176,3 +284,247 @@ func (s *TaskScheduler) Schedule(ctx context.Context, task *api.Task) (*api.Task
 
 	return updatedTask, nil
 }
+
+// isMaintenanceWindowRequired returns true if the task changes the database schema and must start within the maintenance
+// window. The dry run never changes the database, so it starts right away like it does before approval.
+func isMaintenanceWindowRequired(task *api.Task) bool {
+	switch task.Type {
+	case api.TaskDatabaseCreate:
+		return true
+	case api.TaskDatabaseSchemaUpdate:
+		payload := &api.TaskDatabaseSchemaUpdatePayload{}
+		if err := json.Unmarshal([]byte(task.Payload), payload); err != nil {
+			// Be conservative with the malformed payload, the executor reports the error anyway.
+			return true
+		}
+		return !payload.DryRun
+	}
+	return false
+}
+
+// inMaintenanceWindow returns true if now is within the maintenance window of the task environment, or if the
+// environment has no maintenance window.
+func (s *TaskScheduler) inMaintenanceWindow(ctx context.Context, task *api.Task, now time.Time) (bool, error) {
+	instance, err := s.server.ComposeInstanceById(ctx, task.InstanceId)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch instance %d for scheduling task %q: %w", task.InstanceId, task.Name, err)
+	}
+	window, err := api.UnmarshalMaintenanceWindow(instance.Environment.MaintenanceWindow)
+	if err != nil {
+		return false, fmt.Errorf("failed to schedule task %q: %w", task.Name, err)
+	}
+	return window == nil || window.Contains(now), nil
+}
+
+// acquire reserves a worker for the task and returns the context for running the task, which is canceled by Cancel.
+// Returns false if the task is already being run by a worker, or if there is no free worker globally or for the task instance.
+func (s *TaskScheduler) acquire(task *api.Task) (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.runningTasks[task.ID]; ok {
+		return nil, false
+	}
+	if len(s.runningTasks) >= s.concurrency || s.runningInstances[task.InstanceId] >= s.instanceConcurrency {
+		s.blockedPipelines[task.PipelineId] = true
+		return nil, false
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.runningTasks[task.ID] = cancel
+	s.runningInstances[task.InstanceId]++
+	return ctx, true
+}
+
+// release frees the worker reserved by acquire, and notifies the scheduler to retry the blocked pipelines.
+func (s *TaskScheduler) release(task *api.Task) {
+	s.mu.Lock()
+	if cancel, ok := s.runningTasks[task.ID]; ok {
+		cancel()
+	}
+	delete(s.runningTasks, task.ID)
+	s.runningInstances[task.InstanceId]--
+	if s.runningInstances[task.InstanceId] <= 0 {
+		delete(s.runningInstances, task.InstanceId)
+	}
+	blockedPipelines := s.blockedPipelines
+	s.blockedPipelines = make(map[int]bool)
+	s.mu.Unlock()
+
+	for pipelineId := range blockedPipelines {
+		s.Notify(pipelineId)
+	}
+}
+
+// Cancel cancels the context of the task if it's being run by a worker, and the executor should stop as soon as possible.
+// The caller is responsible for changing the task status.
+func (s *TaskScheduler) Cancel(taskId int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.runningTasks[taskId]; ok {
+		cancel()
+	}
+}
+
+// runTask runs the task on the worker and updates the task status once the executor terminates.
+func (s *TaskScheduler) runTask(ctx context.Context, executor TaskExecutor, task *api.Task) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(error)
+			if !ok {
+				err = fmt.Errorf("%v", r)
+			}
+			s.l.Error("Task worker PANIC RECOVER",
+				zap.Int("id", task.ID),
+				zap.String("name", task.Name),
+				zap.Error(err),
+			)
+		}
+	}()
+
+	// The task list is fetched before the worker is acquired, so the previous worker of the task may have finished
+	// it in between. We refetch the task to make sure it's still RUNNING.
+	taskFind := &api.TaskFind{
+		ID: &task.ID,
+	}
+	task, err := s.server.TaskService.FindTask(context.Background(), taskFind)
+	if err != nil {
+		s.l.Error("Failed to fetch task before running",
+			zap.Int("id", *taskFind.ID),
+			zap.Error(err),
+		)
+		return
+	}
+	if task.Status != api.TaskRunning || isWaitingForRetry(task, time.Now()) {
+		return
+	}
+
+	// This fetches quite a bit info and may cause performance issue if we have many ongoing tasks
+	// We may optimize this in the future since only some relationship info is needed by the executor
+	if err := s.server.ComposeTaskRelationship(context.Background(), task); err != nil {
+		s.l.Error("Failed to fetch task relationship",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.String("type", string(task.Type)),
+		)
+		return
+	}
+
+	done, detail, err := executor.RunOnce(ctx, s.server, task)
+	if ctx.Err() != nil {
+		// The task has been canceled and its status has been changed to CANCELED by whoever canceled it.
+		s.l.Info("Task canceled",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.String("type", string(task.Type)),
+		)
+		return
+	}
+	if !done {
+		if err != nil {
+			s.retryOrFail(task, err)
+		}
+		return
+	}
+	if err != nil {
+		taskStatusPatch := &api.TaskStatusPatch{
+			ID:        task.ID,
+			UpdaterId: api.SYSTEM_BOT_ID,
+			Status:    api.TaskFailed,
+			Comment:   err.Error(),
+		}
+		s.server.ChangeTaskStatusWithPatch(context.Background(), task, taskStatusPatch)
+		return
+	}
+
+	taskStatusPatch := &api.TaskStatusPatch{
+		ID:        task.ID,
+		UpdaterId: api.SYSTEM_BOT_ID,
+		Status:    api.TaskDone,
+		Comment:   detail,
+	}
+	if _, err := s.server.ChangeTaskStatusWithPatch(context.Background(), task, taskStatusPatch); err != nil {
+		s.l.Error("Failed to mark task as DONE",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.Error(err),
+		)
+	}
+}
+
+// isWaitingForRetry returns true if the RUNNING task is waiting for the backoff before its next attempt.
+func isWaitingForRetry(task *api.Task, now time.Time) bool {
+	for _, taskRun := range task.TaskRunList {
+		if taskRun.Status == api.TaskRunRunning && taskRun.ScheduledTs > now.Unix() {
+			return true
+		}
+	}
+	return false
+}
+
+// retryOrFail retries the task after a transient failure according to the retry policy of its type and environment,
+// and fails the task if it has used up all the attempts.
+func (s *TaskScheduler) retryOrFail(task *api.Task, err error) {
+	policy := api.TaskRetryPolicy{MaxAttempts: 1}
+	if v, ok := defaultTaskRetryPolicy[task.Type]; ok {
+		policy = v
+	}
+	policyMap, policyErr := api.UnmarshalTaskRetryPolicy(task.Instance.Environment.TaskRetryPolicy)
+	if policyErr != nil {
+		s.l.Error("Failed to parse task retry policy, use the default policy instead",
+			zap.String("environment", task.Instance.Environment.Name),
+			zap.Error(policyErr),
+		)
+	} else if v, ok := policyMap[task.Type]; ok {
+		policy = v
+	}
+
+	attempt := 1
+	for _, taskRun := range task.TaskRunList {
+		if taskRun.Status == api.TaskRunRunning {
+			attempt = taskRun.Attempt
+		}
+	}
+
+	if attempt >= policy.MaxAttempts {
+		taskStatusPatch := &api.TaskStatusPatch{
+			ID:        task.ID,
+			UpdaterId: api.SYSTEM_BOT_ID,
+			Status:    api.TaskFailed,
+			Comment:   fmt.Sprintf("%s (failed after %d attempt(s))", err.Error(), attempt),
+		}
+		s.server.ChangeTaskStatusWithPatch(context.Background(), task, taskStatusPatch)
+		return
+	}
+
+	backoff := time.Duration(policy.BackoffSeconds) * time.Second
+	for i := 1; i < attempt && backoff < TASK_RETRY_MAX_BACKOFF; i++ {
+		backoff *= 2
+	}
+	if backoff > TASK_RETRY_MAX_BACKOFF {
+		backoff = TASK_RETRY_MAX_BACKOFF
+	}
+	taskRetry := &api.TaskRetry{
+		ID:        task.ID,
+		UpdaterId: api.SYSTEM_BOT_ID,
+		Detail:    err.Error(),
+		RetryTs:   time.Now().Add(backoff).Unix(),
+	}
+	if _, retryErr := s.server.TaskService.RetryTask(context.Background(), taskRetry); retryErr != nil {
+		s.l.Error("Failed to retry task",
+			zap.Int("id", task.ID),
+			zap.String("name", task.Name),
+			zap.Error(retryErr),
+		)
+		return
+	}
+	time.AfterFunc(backoff, func() {
+		s.Notify(task.PipelineId)
+	})
+	s.l.Info("Task attempt failed, will retry",
+		zap.Int("id", task.ID),
+		zap.String("name", task.Name),
+		zap.Int("attempt", attempt),
+		zap.Int("max_attempts", policy.MaxAttempts),
+		zap.Duration("backoff", backoff),
+		zap.Error(err),
+	)
+}
@@ -9,7 +9,8 @@ import (
 
 	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
-	"github.com/bytebase/bytebase/external/gitlab"
+	"github.com/bytebase/bytebase/common"
+	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
 	"github.com/google/jsonapi"
 	"github.com/labstack/echo/v4"
 )
@@ -24,7 +25,11 @@ func (s *Server) registerVCSRoutes(g *echo.Group) {
 		}
 		// Trim ending "/"
 		vcsCreate.InstanceURL = strings.TrimRight(vcsCreate.InstanceURL, "/")
-		vcsCreate.ApiURL = fmt.Sprintf("%s/%s", vcsCreate.InstanceURL, gitlab.ApiPath)
+		provider, err := s.getVCSProvider(vcsCreate.Type)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unsupported VCS type: %s", vcsCreate.Type)).SetInternal(err)
+		}
+		vcsCreate.ApiURL = provider.APIURL(vcsCreate.InstanceURL)
 
 		vcs, err := s.VCSService.CreateVCS(context.Background(), vcsCreate)
 		if err != nil {
@@ -139,6 +144,96 @@ func (s *Server) registerVCSRoutes(g *echo.Group) {
 		return nil
 	})
 
+	// Exchanges the OAuth code for the token on behalf of the browser, since some VCS like GitHub and Gitea don't allow
+	// the browser to call their token endpoints.
+	g.POST("/vcs/:vcsId/token", func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("vcsId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("vcsId"))).SetInternal(err)
+		}
+
+		tokenCreate := &api.VCSTokenCreate{}
+		if err := jsonapi.UnmarshalPayload(c.Request().Body, tokenCreate); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted create VCS token request").SetInternal(err)
+		}
+
+		vcs, err := s.ComposeVCSById(context.Background(), id)
+		if err != nil {
+			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("VCS ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch vcs ID: %v", id)).SetInternal(err)
+		}
+
+		provider, err := s.getVCSProvider(vcs.Type)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to exchange OAuth token for vcs ID: %v", id)).SetInternal(err)
+		}
+		oauthCtx := s.getOAuthContext(vcs)
+		oauthCtx.RedirectURL = tokenCreate.RedirectURL
+		oauthToken, err := provider.ExchangeOAuthToken(vcs.InstanceURL, oauthCtx, tokenCreate.Code)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Failed to exchange OAuth token for vcs ID: %v", id)).SetInternal(err)
+		}
+		token := &api.VCSToken{
+			AccessToken:  oauthToken.AccessToken,
+			ExpiresTs:    oauthToken.ExpiresTs,
+			RefreshToken: oauthToken.RefreshToken,
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, token); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal vcs token response: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
+	// Lists the repositories on the VCS accessible to the user, whose OAuth access token is passed in the
+	// accessToken header. Unlike GET /vcs/:vcsId/repository, the repositories are not necessarily linked.
+	g.GET("/vcs/:vcsId/external-repository", func(c echo.Context) error {
+		id, err := strconv.Atoi(c.Param("vcsId"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("ID is not a number: %s", c.Param("vcsId"))).SetInternal(err)
+		}
+
+		accessToken := c.Request().Header.Get("accessToken")
+		if accessToken == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "Missing accessToken header")
+		}
+
+		vcs, err := s.ComposeVCSById(context.Background(), id)
+		if err != nil {
+			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("VCS ID not found: %d", id))
+			}
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch vcs ID: %v", id)).SetInternal(err)
+		}
+
+		provider, err := s.getVCSProvider(vcs.Type)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch external repository list for vcs ID: %v", id)).SetInternal(err)
+		}
+		repositoryList, err := provider.FetchRepositoryList(vcs.InstanceURL, accessToken)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch external repository list for vcs ID: %v", id)).SetInternal(err)
+		}
+		list := []*api.ExternalRepository{}
+		for _, repository := range repositoryList {
+			list = append(list, &api.ExternalRepository{
+				ID:       repository.ID,
+				Name:     repository.Name,
+				FullPath: repository.FullPath,
+				WebURL:   repository.WebURL,
+			})
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+		if err := jsonapi.MarshalPayload(c.Response().Writer, list); err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to marshal external repository list response for vcs ID: %v", id)).SetInternal(err)
+		}
+		return nil
+	})
+
 	g.GET("/vcs/:vcsId/repository", func(c echo.Context) error {
 		id, err := strconv.Atoi(c.Param("vcsId"))
 		if err != nil {
@@ -198,3 +293,18 @@ func (s *Server) ComposeVCSRelationship(ctx context.Context, vcs *api.VCS) error
 
 	return nil
 }
+
+// getVCSProvider returns the provider of the VCS type.
+func (s *Server) getVCSProvider(vcsType common.VCSType) (vcsPlugin.Provider, error) {
+	return vcsPlugin.Get(vcsType, vcsPlugin.ProviderConfig{Logger: s.l})
+}
+
+// getOAuthContext returns the OAuth application of the VCS. The redirect URL is the OAuth callback page of the
+// frontend served by this server, which is required by GitLab to refresh the token.
+func (s *Server) getOAuthContext(vcs *api.VCS) vcsPlugin.OAuthContext {
+	return vcsPlugin.OAuthContext{
+		ClientID:     vcs.ApplicationId,
+		ClientSecret: vcs.Secret,
+		RedirectURL:  fmt.Sprintf("%s:%d/oauth/callback", s.host, s.port),
+	}
+}
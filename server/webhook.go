@@ -2,250 +2,458 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"path/filepath"
-	"strconv"
 	"strings"
-	"time"
 
 	"github.com/bytebase/bytebase"
 	"github.com/bytebase/bytebase/api"
 	"github.com/bytebase/bytebase/common"
 	"github.com/bytebase/bytebase/db"
-	"github.com/bytebase/bytebase/external/gitlab"
+	vcsPlugin "github.com/bytebase/bytebase/plugin/vcs"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
-var (
-	gitLabWebhookPath = "hook/gitlab"
-)
+// webhookPathList maps the VCS type to the path segment of its webhook endpoint under /hook. The paths are kept
+// per VCS type since they are part of the webhook URLs already registered on the VCS.
+var webhookPathList = map[common.VCSType]string{
+	common.GITLAB_SELF_HOST: "gitlab",
+	common.GITHUB:           "github",
+	common.GITEA:            "gitea",
+}
 
 func (s *Server) registerWebhookRoutes(g *echo.Group) {
-	g.POST("/gitlab/:id", func(c echo.Context) error {
-		var b []byte
-		b, err := io.ReadAll(c.Request().Body)
+	for _, webhookPath := range webhookPathList {
+		g.POST(fmt.Sprintf("/%s/:id", webhookPath), s.handlePushEvent)
+	}
+}
+
+// sqlReviewStatusName identifies the SQL review in the commit status list of the merge request.
+const sqlReviewStatusName = "bytebase/sql-review"
+
+// handlePushEvent creates the schema update issues for the migration files added by the push event delivered by the
+// webhook of the linked repository. The merge request events delivered by the same webhook are handled by
+// handleMergeRequestEvent.
+func (s *Server) handlePushEvent(c echo.Context) error {
+	var b []byte
+	b, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+	}
+
+	webhookEndpointId := c.Param("id")
+	repositoryFind := &api.RepositoryFind{
+		WebhookEndpointId: &webhookEndpointId,
+	}
+	repository, err := s.RepositoryService.FindRepository(context.Background(), repositoryFind)
+	if err != nil {
+		if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Endpoint not found: %v", webhookEndpointId))
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to respond webhook event for endpoint: %v", webhookEndpointId)).SetInternal(err)
+	}
+
+	if err := s.ComposeRepositoryRelationship(context.Background(), repository); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository relationship: %v", repository.Name)).SetInternal(err)
+	}
+
+	provider, err := s.getVCSProvider(repository.VCS.Type)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to respond webhook event for endpoint: %v", webhookEndpointId)).SetInternal(err)
+	}
+
+	if mergeRequestProvider, ok := provider.(vcsPlugin.MergeRequestProvider); ok && mergeRequestProvider.IsMergeRequestEvent(c.Request().Header) {
+		return s.handleMergeRequestEvent(c, repository, provider, mergeRequestProvider, b)
+	}
+
+	webhookConfig := s.getWebhookConfig(repository.VCS.Type, repository.WebhookEndpointId, repository.WebhookSecretToken, repository.BranchFilter, repository.TagFilter)
+	pushEvent, err := provider.ParsePushEvent(c.Request().Header, b, webhookConfig)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event: %v", err)).SetInternal(err)
+	}
+	// The event is acknowledged but not processed, e.g. a ping.
+	if pushEvent == nil {
+		return c.String(http.StatusOK, "")
+	}
+
+	if pushEvent.RepositoryID != repository.ExternalId {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository mismatch, got %s, want %s", pushEvent.RepositoryID, repository.ExternalId))
+	}
+	// Only the pushes to the matching branches, or the matching tags in the tag mode, create the issues. The VCS may
+	// not filter the refs itself, e.g. GitHub, or deliver the refs not matching, e.g. the branches in the tag mode.
+	if !webhookConfig.MatchRef(pushEvent.Ref) {
+		s.l.Debug("Skip pushing to ref not matching the repository filter",
+			zap.String("ref", pushEvent.Ref),
+			zap.String("branch_filter", repository.BranchFilter),
+			zap.String("tag_filter", repository.TagFilter),
+		)
+		return c.String(http.StatusOK, "")
+	}
+
+	if err := s.refreshRepositoryToken(context.Background(), repository, repository.VCS, provider); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to refresh OAuth token for repository: %v", repository.Name)).SetInternal(err)
+	}
+
+	externalRepository := vcsPlugin.Repository{
+		ID:       pushEvent.RepositoryID,
+		FullPath: pushEvent.RepositoryFullPath,
+	}
+	commitList := pushEvent.CommitList
+	if repository.TagFilter != "" {
+		commitList, err = s.getTagCommitList(repository, provider, externalRepository, webhookConfig, pushEvent)
 		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, "Failed to read webhook request").SetInternal(err)
+			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch the files added by tag %s", pushEvent.Ref)).SetInternal(err)
 		}
+	}
+	createdMessageList := []string{}
+	for _, commit := range commitList {
+		for _, added := range commit.AddedList {
+			vcsPushEvent := common.VCSPushEvent{
+				VCSType:            repository.VCS.Type,
+				BaseDirectory:      repository.BaseDirectory,
+				Ref:                pushEvent.Ref,
+				RepositoryID:       pushEvent.RepositoryID,
+				RepositoryURL:      pushEvent.RepositoryURL,
+				RepositoryFullPath: pushEvent.RepositoryFullPath,
+				AuthorName:         pushEvent.AuthorName,
+				FileCommit: common.VCSFileCommit{
+					ID:         commit.ID,
+					Title:      commit.Title,
+					Message:    commit.Message,
+					CreatedTs:  commit.CreatedTs,
+					URL:        commit.URL,
+					AuthorName: commit.AuthorName,
+					Added:      added,
+				},
+			}
+			readFile := func() ([]byte, error) {
+				return provider.ReadFileContent(repository.VCS.InstanceURL, externalRepository, vcsPushEvent.FileCommit.Added, vcsPushEvent.FileCommit.ID, repository.AccessToken)
+			}
+			if message := s.createSchemaUpdateIssue(context.Background(), repository, vcsPushEvent, readFile); message != "" {
+				createdMessageList = append(createdMessageList, message)
+			}
+		}
+	}
+
+	return c.String(http.StatusOK, strings.Join(createdMessageList, "\n"))
+}
+
+// handleMergeRequestEvent reviews the migration files added by the merge request, and posts the result to the merge
+// request as a comment and the commit status of its head commit, so that the problems are found before the merge.
+func (s *Server) handleMergeRequestEvent(c echo.Context, repository *api.Repository, provider vcsPlugin.Provider, mergeRequestProvider vcsPlugin.MergeRequestProvider, b []byte) error {
+	mergeRequestEvent, err := mergeRequestProvider.ParseMergeRequestEvent(c.Request().Header, b, s.getWebhookConfig(repository.VCS.Type, repository.WebhookEndpointId, repository.WebhookSecretToken, repository.BranchFilter, repository.TagFilter))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event: %v", err)).SetInternal(err)
+	}
+	// The merge request doesn't need to be reviewed, e.g. it's closed.
+	if mergeRequestEvent == nil {
+		return c.String(http.StatusOK, "")
+	}
+
+	if mergeRequestEvent.RepositoryID != repository.ExternalId {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Repository mismatch, got %s, want %s", mergeRequestEvent.RepositoryID, repository.ExternalId))
+	}
+	// The branch filter of the webhook only applies to the push events.
+	if !vcsPlugin.MatchBranch(repository.BranchFilter, mergeRequestEvent.TargetBranch) {
+		return c.String(http.StatusOK, "")
+	}
 
-		pushEvent := &gitlab.WebhookPushEvent{}
-		if err := json.Unmarshal(b, pushEvent); err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, "Malformatted push event").SetInternal(err)
+	if err := s.refreshRepositoryToken(context.Background(), repository, repository.VCS, provider); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to refresh OAuth token for repository: %v", repository.Name)).SetInternal(err)
+	}
+
+	externalRepository := vcsPlugin.Repository{ID: mergeRequestEvent.RepositoryID}
+	addedList, err := mergeRequestProvider.FetchMergeRequestAddedFileList(repository.VCS.InstanceURL, externalRepository, mergeRequestEvent.ID, repository.AccessToken)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch files of merge request: %v", mergeRequestEvent.URL)).SetInternal(err)
+	}
+
+	var reviewList []sqlFileReview
+	for _, added := range addedList {
+		if !strings.HasPrefix(added, repository.BaseDirectory) || filepath.Ext(added) != ".sql" {
+			continue
 		}
+		readFile := func() ([]byte, error) {
+			return provider.ReadFileContent(repository.VCS.InstanceURL, mergeRequestEvent.SourceRepository, added, mergeRequestEvent.CommitID, repository.AccessToken)
+		}
+		reviewList = append(reviewList, s.reviewMigrationFile(context.Background(), repository, added, readFile))
+	}
+	if len(reviewList) == 0 {
+		return c.String(http.StatusOK, "")
+	}
 
-		// This shouldn't happen as we only setup webhook to receive push event, just in case.
-		if pushEvent.ObjectKind != gitlab.WebhookPush {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid webhook event type, got %s, want push", pushEvent.ObjectKind))
+	status := vcsPlugin.CommitStatus{
+		State:       vcsPlugin.CommitStateSuccess,
+		Name:        sqlReviewStatusName,
+		Description: "SQL review passed",
+	}
+	for _, review := range reviewList {
+		if review.hasError() {
+			status.State = vcsPlugin.CommitStateFailed
+			status.Description = "SQL review found errors"
+			break
 		}
+	}
+	if err := mergeRequestProvider.CreateMergeRequestComment(repository.VCS.InstanceURL, externalRepository, mergeRequestEvent.ID, formatSQLReviewComment(reviewList), repository.AccessToken); err != nil {
+		s.l.Warn("Failed to post SQL review comment to merge request", zap.String("merge_request", mergeRequestEvent.URL), zap.Error(err))
+	}
+	if err := mergeRequestProvider.SetCommitStatus(repository.VCS.InstanceURL, mergeRequestEvent.SourceRepository, mergeRequestEvent.CommitID, status, repository.AccessToken); err != nil {
+		s.l.Warn("Failed to set SQL review commit status", zap.String("merge_request", mergeRequestEvent.URL), zap.String("commit", mergeRequestEvent.CommitID), zap.Error(err))
+	}
+
+	return c.String(http.StatusOK, fmt.Sprintf("Reviewed %d migration files of merge request '%s'", len(reviewList), mergeRequestEvent.Title))
+}
+
+// sqlFileReview is the SQL review result of a migration file in the merge request.
+type sqlFileReview struct {
+	file string
+	// err is the problem preventing the file from being reviewed, e.g. the invalid filename.
+	err error
+	// databaseReviewList is the review result against each database the file applies to.
+	databaseReviewList []sqlDatabaseReview
+}
 
-		webhookEndpointId := c.Param("id")
-		repositoryFind := &api.RepositoryFind{
-			WebhookEndpointId: &webhookEndpointId,
+type sqlDatabaseReview struct {
+	database   *api.Database
+	adviceList []api.SQLReviewAdvice
+}
+
+func (r sqlFileReview) hasError() bool {
+	if r.err != nil {
+		return true
+	}
+	for _, databaseReview := range r.databaseReviewList {
+		if hasSQLReviewError(databaseReview.adviceList) {
+			return true
 		}
-		repository, err := s.RepositoryService.FindRepository(context.Background(), repositoryFind)
+	}
+	return false
+}
+
+// reviewMigrationFile validates the migration file like createSchemaUpdateIssue does, and checks its content, read by
+// readFile, against the SQL review policy of each environment it applies to.
+func (s *Server) reviewMigrationFile(ctx context.Context, repository *api.Repository, file string, readFile func() ([]byte, error)) sqlFileReview {
+	review := sqlFileReview{file: file}
+	mi, err := db.ParseMigrationInfo(file, repository.BaseDirectory)
+	if err != nil {
+		review.err = fmt.Errorf("invalid migration filename: %w", err)
+		return review
+	}
+	databaseList, err := s.findMigrationDatabaseList(ctx, repository, mi)
+	if err != nil {
+		review.err = err
+		return review
+	}
+	b, err := readFile()
+	if err != nil {
+		review.err = fmt.Errorf("failed to read file: %w", err)
+		return review
+	}
+
+	for _, database := range databaseList {
+		adviceList, err := s.reviewSQL(ctx, database.InstanceId, string(b))
 		if err != nil {
-			if bytebase.ErrorCode(err) == bytebase.ENOTFOUND {
-				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("Endpoint not found: %v", webhookEndpointId))
-			}
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to respond webhook event for endpoint: %v", webhookEndpointId)).SetInternal(err)
+			review.err = err
+			return review
 		}
+		review.databaseReviewList = append(review.databaseReviewList, sqlDatabaseReview{
+			database:   database,
+			adviceList: adviceList,
+		})
+	}
+	return review
+}
 
-		if err := s.ComposeRepositoryRelationship(context.Background(), repository); err != nil {
-			return echo.NewHTTPError(http.StatusInternalServerError, fmt.Sprintf("Failed to fetch repository relationship: %v", repository.Name)).SetInternal(err)
+// formatSQLReviewComment formats the SQL review result as the markdown comment of the merge request.
+func formatSQLReviewComment(reviewList []sqlFileReview) string {
+	var sb strings.Builder
+	sb.WriteString("### Bytebase SQL review\n")
+	for _, review := range reviewList {
+		fmt.Fprintf(&sb, "\n#### `%s`\n\n", review.file)
+		if review.err != nil {
+			fmt.Fprintf(&sb, "- **ERROR** %s\n", review.err)
+			continue
 		}
-
-		if c.Request().Header.Get("X-Gitlab-Token") != repository.WebhookSecretToken {
-			return echo.NewHTTPError(http.StatusBadRequest, "Secret token mismatch")
+		for _, databaseReview := range review.databaseReviewList {
+			database := databaseReview.database
+			if len(databaseReview.adviceList) == 0 {
+				fmt.Fprintf(&sb, "- %s (%s): no problem found\n", database.Name, database.Instance.Environment.Name)
+				continue
+			}
+			for _, advice := range databaseReview.adviceList {
+				fmt.Fprintf(&sb, "- %s (%s): **%s** %s: %s\n", database.Name, database.Instance.Environment.Name, advice.Level, advice.Title, advice.Content)
+			}
 		}
+	}
+	return sb.String()
+}
+
+// getTagCommitList returns the tagged commit of the tag push event, whose AddedList is the files added since the
+// previous tag matching the tag filter, or by the tagged commit itself if it's the first tag. It returns nil if the
+// tag is deleted.
+func (s *Server) getTagCommitList(repository *api.Repository, provider vcsPlugin.Provider, externalRepository vcsPlugin.Repository, webhookConfig vcsPlugin.WebhookConfig, pushEvent *vcsPlugin.PushEvent) ([]vcsPlugin.Commit, error) {
+	if len(pushEvent.CommitList) == 0 {
+		return nil, nil
+	}
+	commit := pushEvent.CommitList[0]
+
+	tagList, err := provider.FetchTagList(repository.VCS.InstanceURL, externalRepository, repository.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	from := ""
+	if previousTag := webhookConfig.PreviousTag(tagList, strings.TrimPrefix(pushEvent.Ref, "refs/tags/")); previousTag != nil {
+		from = previousTag.CommitID
+	}
+	commit.AddedList, err = provider.FetchAddedFileList(repository.VCS.InstanceURL, externalRepository, from, commit.ID, repository.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return []vcsPlugin.Commit{commit}, nil
+}
 
-		if strconv.Itoa(pushEvent.Project.ID) != repository.ExternalId {
-			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Project mismatch, got %d, want %s", pushEvent.Project.ID, repository.ExternalId))
+// getWebhookConfig returns the config of the push event webhook of the repository identified by webhookEndpointId.
+func (s *Server) getWebhookConfig(vcsType common.VCSType, webhookEndpointId string, secretToken string, branchFilter string, tagFilter string) vcsPlugin.WebhookConfig {
+	return vcsPlugin.WebhookConfig{
+		URL:          fmt.Sprintf("%s:%d/hook/%s/%s", s.host, s.port, webhookPathList[vcsType], webhookEndpointId),
+		SecretToken:  secretToken,
+		BranchFilter: branchFilter,
+		TagFilter:    tagFilter,
+	}
+}
+
+// createSchemaUpdateIssue creates the schema update issue for the migration file added by the commit of pushEvent,
+// whose content is read by readFile. It returns the message of the created issue, or an empty message if the file
+// is skipped, e.g. it's not a migration file or there is no matching database in the project.
+func (s *Server) createSchemaUpdateIssue(ctx context.Context, repository *api.Repository, pushEvent common.VCSPushEvent, readFile func() ([]byte, error)) string {
+	added := pushEvent.FileCommit.Added
+	if !strings.HasPrefix(added, repository.BaseDirectory) || filepath.Ext(added) != ".sql" {
+		return ""
+	}
+	mi, err := db.ParseMigrationInfo(added, repository.BaseDirectory)
+	if err != nil {
+		s.l.Warn("Invalid migration filename. Skip", zap.String("file", added), zap.Error(err))
+		return ""
+	}
+
+	// Retrieve sql by reading the file content
+	b, err := readFile()
+	if err != nil {
+		s.l.Warn("Failed to read added repository file. Skip", zap.String("file", added), zap.Error(err))
+		return ""
+	}
+
+	filterdDatabaseList, err := s.findMigrationDatabaseList(ctx, repository, mi)
+	if err != nil {
+		s.l.Warn("Failed to find database matching added repository file. Skip",
+			zap.Int("project_id", repository.ProjectId),
+			zap.String("file", added),
+			zap.Error(err),
+		)
+		return ""
+	}
+
+	stageList := []api.StageCreate{}
+	for _, database := range filterdDatabaseList {
+		databaseID := database.ID
+		taskStatus := api.TaskPendingApproval
+		if database.Instance.Environment.ApprovalPolicy == api.ManualApprovalNever {
+			taskStatus = api.TaskPending
+		}
+		task := &api.TaskCreate{
+			InstanceId:   database.InstanceId,
+			DatabaseId:   &databaseID,
+			Name:         mi.Description,
+			Status:       taskStatus,
+			Type:         api.TaskDatabaseSchemaUpdate,
+			Statement:    string(b),
+			VCSPushEvent: &pushEvent,
 		}
+		stageList = append(stageList, api.StageCreate{
+			EnvironmentId: database.Instance.EnvironmentId,
+			TaskList:      []api.TaskCreate{*task},
+			Name:          database.Instance.Environment.Name,
+		})
+	}
+	pipeline := &api.PipelineCreate{
+		StageList: stageList,
+		Name:      fmt.Sprintf("Pipeline - %s", pushEvent.FileCommit.Title),
+	}
+	issueCreate := &api.IssueCreate{
+		ProjectId:   repository.ProjectId,
+		Pipeline:    *pipeline,
+		Name:        pushEvent.FileCommit.Title,
+		Type:        api.IssueDatabaseSchemaUpdate,
+		Description: pushEvent.FileCommit.Message,
+		AssigneeId:  api.SYSTEM_BOT_ID,
+	}
+
+	issue, err := s.CreateIssue(ctx, issueCreate, api.SYSTEM_BOT_ID)
+	if err != nil {
+		s.l.Warn("Failed to create update schema task for added repository file", zap.Error(err),
+			zap.String("file", added))
+		return ""
+	}
 
-		createdMessageList := []string{}
-		for _, commit := range pushEvent.CommitList {
-			for _, added := range commit.AddedList {
-				if strings.HasPrefix(added, repository.BaseDirectory) && filepath.Ext(added) == ".sql" {
-					mi, err := db.ParseMigrationInfo(added, repository.BaseDirectory)
-					if err != nil {
-						s.l.Warn("Invalid migration filename. Skip", zap.String("file", added), zap.Error(err))
-						continue
-					}
-
-					// Retrieve sql by reading the file content
-					resp, err := gitlab.GET(
-						repository.VCS.InstanceURL,
-						fmt.Sprintf("projects/%s/repository/files/%s/raw?ref=%s", repository.ExternalId, url.QueryEscape(added), commit.ID),
-						repository.AccessToken,
-					)
-					if err != nil {
-						s.l.Warn("Failed to read added repository file. Skip", zap.String("file", added), zap.Error(err))
-						continue
-					}
-
-					b, err := io.ReadAll(resp.Body)
-					if err != nil {
-						s.l.Warn("Failed to read added repository file response. Skip", zap.String("file", added), zap.Error(err))
-						continue
-					}
-					defer resp.Body.Close()
-
-					// Find matching database list
-					databaseFind := &api.DatabaseFind{
-						ProjectId: &repository.ProjectId,
-						Name:      &mi.Database,
-					}
-					databaseList, err := s.ComposeDatabaseListByFind(context.Background(), databaseFind)
-					if err != nil {
-						s.l.Warn("Failed to find database matching added repository file. Skip", zap.String("file", added), zap.Error(err))
-						continue
-					} else if len(databaseList) == 0 {
-						s.l.Warn("Project does not own this database. Skip",
-							zap.Int("project_id", repository.ProjectId),
-							zap.String("database_name", mi.Database),
-							zap.String("file", added),
-						)
-						continue
-					}
-
-					// We support 3 patterns on how to organize the schema files.
-					// Pattern 1: 	The database name is the same across all environments. Each environment will have its own directory, so the
-					//              schema file looks like "dev/v1__db1", "staging/v1__db1".
-					//
-					// Pattern 2: 	Like 1, the database name is the same across all environments. All environment shares the same schema file,
-					//              say v1__db1, when a new file is added like v2__db1__add_column, we will create a multi stage pipeline where
-					//              each stage corresponds to an environment.
-					//
-					// Pattern 3:  	The database name is different among different environments. In such case, the database name alone is enough
-					//             	to identify ambiguity.
-
-					// Further filter by environment name if applicable.
-					filterdDatabaseList := []*api.Database{}
-					if mi.Environment != "" {
-						for _, database := range databaseList {
-							// Environment name comparision is case insensitive
-							if strings.EqualFold(database.Instance.Environment.Name, mi.Environment) {
-								filterdDatabaseList = append(filterdDatabaseList, database)
-							}
-						}
-						if len(filterdDatabaseList) == 0 {
-							s.l.Warn(fmt.Sprintf("Project ID %d does not contain database %s for environment %s. Skip", repository.ProjectId, mi.Database, mi.Environment),
-								zap.Int("project_id", repository.ProjectId),
-								zap.String("environment", mi.Environment),
-								zap.String("file", added),
-							)
-							continue
-						}
-					} else {
-						filterdDatabaseList = databaseList
-					}
-
-					{
-						// It could happen that for a particular environment a project contain 2 database with the same name.
-						// We will emit warning in this case.
-						var databaseListByEnv = map[int][]*api.Database{}
-						for _, database := range filterdDatabaseList {
-							list, ok := databaseListByEnv[database.Instance.EnvironmentId]
-							if ok {
-								databaseListByEnv[database.Instance.EnvironmentId] = append(list, database)
-							} else {
-								list := make([]*api.Database, 0)
-								databaseListByEnv[database.Instance.EnvironmentId] = append(list, database)
-							}
-						}
-
-						var multipleDatabaseForSameEnv = false
-						for environemntId, databaseList := range databaseListByEnv {
-							if len(databaseList) > 1 {
-								multipleDatabaseForSameEnv = true
-
-								s.l.Warn(fmt.Sprintf("Project ID %d contain multiple database %s for environment %d. Skip", repository.ProjectId, mi.Database, environemntId),
-									zap.Int("project_id", repository.ProjectId),
-									zap.String("file", added),
-								)
-							}
-						}
-
-						if multipleDatabaseForSameEnv {
-							continue
-						}
-					}
-
-					// Compose the new issue
-					createdTime, err := time.Parse(time.RFC3339, commit.Timestamp)
-					if err != nil {
-						s.l.Warn("Failed to parse timestamp. Skip", zap.String("file", added), zap.Error(err))
-					}
-					vcsPushEvent := common.VCSPushEvent{
-						VCSType:            repository.VCS.Type,
-						BaseDirectory:      repository.BaseDirectory,
-						Ref:                pushEvent.Ref,
-						RepositoryID:       strconv.Itoa(pushEvent.Project.ID),
-						RepositoryURL:      pushEvent.Project.WebURL,
-						RepositoryFullPath: pushEvent.Project.FullPath,
-						AuthorName:         pushEvent.AuthorName,
-						FileCommit: common.VCSFileCommit{
-							ID:         commit.ID,
-							Title:      commit.Title,
-							Message:    commit.Message,
-							CreatedTs:  createdTime.Unix(),
-							URL:        commit.URL,
-							AuthorName: commit.Author.Name,
-							Added:      added,
-						},
-					}
-
-					stageList := []api.StageCreate{}
-					for _, database := range filterdDatabaseList {
-						databaseID := database.ID
-						taskStatus := api.TaskPendingApproval
-						if database.Instance.Environment.ApprovalPolicy == api.ManualApprovalNever {
-							taskStatus = api.TaskPending
-						}
-						task := &api.TaskCreate{
-							InstanceId:   database.InstanceId,
-							DatabaseId:   &databaseID,
-							Name:         mi.Description,
-							Status:       taskStatus,
-							Type:         api.TaskDatabaseSchemaUpdate,
-							Statement:    string(b),
-							VCSPushEvent: &vcsPushEvent,
-						}
-						stageList = append(stageList, api.StageCreate{
-							EnvironmentId: database.Instance.EnvironmentId,
-							TaskList:      []api.TaskCreate{*task},
-							Name:          database.Instance.Environment.Name,
-						})
-					}
-					pipeline := &api.PipelineCreate{
-						StageList: stageList,
-						Name:      fmt.Sprintf("Pipeline - %s", commit.Title),
-					}
-					issueCreate := &api.IssueCreate{
-						ProjectId:   repository.ProjectId,
-						Pipeline:    *pipeline,
-						Name:        commit.Title,
-						Type:        api.IssueDatabaseSchemaUpdate,
-						Description: commit.Message,
-						AssigneeId:  api.SYSTEM_BOT_ID,
-					}
-
-					issue, err := s.CreateIssue(context.Background(), issueCreate, api.SYSTEM_BOT_ID)
-					if err != nil {
-						s.l.Warn("Failed to create update schema task for added repository file", zap.Error(err),
-							zap.String("file", added))
-						continue
-					}
-					createdMessageList = append(createdMessageList, fmt.Sprintf("Created issue '%s' on adding %s", issue.Name, added))
-				}
+	return fmt.Sprintf("Created issue '%s' on adding %s", issue.Name, added)
+}
+
+// findMigrationDatabaseList returns the databases in the project of the repository to apply the migration to, with at
+// most one database for each environment. It returns an error if there is no such database, or the database is
+// ambiguous for an environment.
+func (s *Server) findMigrationDatabaseList(ctx context.Context, repository *api.Repository, mi *db.MigrationInfo) ([]*api.Database, error) {
+	// Find matching database list
+	databaseFind := &api.DatabaseFind{
+		ProjectId: &repository.ProjectId,
+		Name:      &mi.Database,
+	}
+	databaseList, err := s.ComposeDatabaseListByFind(ctx, databaseFind)
+	if err != nil {
+		return nil, err
+	} else if len(databaseList) == 0 {
+		return nil, fmt.Errorf("project ID %d does not own database %s", repository.ProjectId, mi.Database)
+	}
+
+	// We support 3 patterns on how to organize the schema files.
+	// Pattern 1: 	The database name is the same across all environments. Each environment will have its own directory, so the
+	//              schema file looks like "dev/v1__db1", "staging/v1__db1".
+	//
+	// Pattern 2: 	Like 1, the database name is the same across all environments. All environment shares the same schema file,
+	//              say v1__db1, when a new file is added like v2__db1__add_column, we will create a multi stage pipeline where
+	//              each stage corresponds to an environment.
+	//
+	// Pattern 3:  	The database name is different among different environments. In such case, the database name alone is enough
+	//             	to identify ambiguity.
+
+	// Further filter by environment name if applicable.
+	filterdDatabaseList := []*api.Database{}
+	if mi.Environment != "" {
+		for _, database := range databaseList {
+			// Environment name comparision is case insensitive
+			if strings.EqualFold(database.Instance.Environment.Name, mi.Environment) {
+				filterdDatabaseList = append(filterdDatabaseList, database)
 			}
 		}
+		if len(filterdDatabaseList) == 0 {
+			return nil, fmt.Errorf("project ID %d does not contain database %s for environment %s", repository.ProjectId, mi.Database, mi.Environment)
+		}
+	} else {
+		filterdDatabaseList = databaseList
+	}
+
+	// It could happen that for a particular environment a project contain 2 database with the same name.
+	var databaseListByEnv = map[int][]*api.Database{}
+	for _, database := range filterdDatabaseList {
+		databaseListByEnv[database.Instance.EnvironmentId] = append(databaseListByEnv[database.Instance.EnvironmentId], database)
+	}
+	for _, database := range filterdDatabaseList {
+		if len(databaseListByEnv[database.Instance.EnvironmentId]) > 1 {
+			return nil, fmt.Errorf("project ID %d contains multiple database %s for environment %s", repository.ProjectId, mi.Database, database.Instance.Environment.Name)
+		}
+	}
 
-		return c.String(http.StatusOK, strings.Join(createdMessageList, "\n"))
-	})
+	return filterdDatabaseList, nil
 }
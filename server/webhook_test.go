@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/bytebase/bytebase/api"
+)
+
+func TestFormatSQLReviewComment(t *testing.T) {
+	database := func(name string, environment string) *api.Database {
+		return &api.Database{
+			Name: name,
+			Instance: &api.Instance{
+				Environment: &api.Environment{Name: environment},
+			},
+		}
+	}
+
+	reviewList := []sqlFileReview{
+		{
+			file: "bytebase/prod/v1__db1__add_index.sql",
+			databaseReviewList: []sqlDatabaseReview{
+				{
+					database: database("db1", "Prod"),
+					adviceList: []api.SQLReviewAdvice{
+						{Type: "bb.rule.table.require-pk", Level: api.SQLReviewAdviceError, Title: "Require primary key", Content: "Table t1 has no primary key"},
+						{Type: "bb.rule.where.require", Level: api.SQLReviewAdviceWarning, Title: "Require WHERE", Content: "DELETE without WHERE"},
+					},
+				},
+				{
+					database: database("db1", "Staging"),
+				},
+			},
+		},
+		{
+			file: "bytebase/prod/invalid.sql",
+			err:  fmt.Errorf("invalid filename format"),
+		},
+		{
+			file: "bytebase/prod/v2__db2.sql",
+		},
+	}
+
+	want := "### Bytebase SQL review\n" +
+		"\n#### `bytebase/prod/v1__db1__add_index.sql`\n\n" +
+		"- db1 (Prod): **ERROR** Require primary key: Table t1 has no primary key\n" +
+		"- db1 (Prod): **WARNING** Require WHERE: DELETE without WHERE\n" +
+		"- db1 (Staging): no problem found\n" +
+		"\n#### `bytebase/prod/invalid.sql`\n\n" +
+		"- **ERROR** invalid filename format\n" +
+		"\n#### `bytebase/prod/v2__db2.sql`\n\n"
+	if got := formatSQLReviewComment(reviewList); got != want {
+		t.Errorf("expected comment:\n%s\ngot:\n%s", want, got)
+	}
+
+	hasErrorList := []bool{true, true, false}
+	for i, review := range reviewList {
+		if got := review.hasError(); got != hasErrorList[i] {
+			t.Errorf("file %s: expected hasError %v, got %v", review.file, hasErrorList[i], got)
+		}
+	}
+}
@@ -106,6 +106,10 @@ func (s *BackupService) PatchBackup(ctx context.Context, patch *api.BackupPatch)
 
 // createBackup creates a new backup.
 func (s *BackupService) createBackup(ctx context.Context, tx *Tx, create *api.BackupCreate) (*api.Backup, error) {
+	verificationStatus := api.BackupVerificationStatusNone
+	if create.VerificationEnabled {
+		verificationStatus = api.BackupVerificationStatusPending
+	}
 	// Insert row into backup.
 	row, err := tx.QueryContext(ctx, `
 		INSERT INTO backup (
@@ -118,10 +122,14 @@ func (s *BackupService) createBackup(ctx context.Context, tx *Tx, create *api.Ba
 			storage_backend,
 			migration_history_version,
 			path,
-			comment
+			comment,
+			compression,
+			encrypted,
+			storage_location,
+			verification_status
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, `+"`status`,"+` `+"`type`, storage_backend, migration_history_version, path, comment"+`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, database_id, name, `+"`status`,"+` `+"`type`, storage_backend, migration_history_version, path, comment, compression, encrypted, storage_location, payload, verification_status"+`
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -133,6 +141,10 @@ func (s *BackupService) createBackup(ctx context.Context, tx *Tx, create *api.Ba
 		create.MigrationHistoryVersion,
 		create.Path,
 		create.Comment,
+		create.Compression,
+		create.Encrypted,
+		create.StorageLocation,
+		verificationStatus,
 	)
 
 	if err != nil {
@@ -144,6 +156,7 @@ func (s *BackupService) createBackup(ctx context.Context, tx *Tx, create *api.Ba
 	var backup api.Backup
 	if err := row.Scan(
 		&backup.ID,
+		&backup.RowStatus,
 		&backup.CreatorId,
 		&backup.CreatedTs,
 		&backup.UpdaterId,
@@ -156,6 +169,11 @@ func (s *BackupService) createBackup(ctx context.Context, tx *Tx, create *api.Ba
 		&backup.MigrationHistoryVersion,
 		&backup.Path,
 		&backup.Comment,
+		&backup.Compression,
+		&backup.Encrypted,
+		&backup.StorageLocation,
+		&backup.Payload,
+		&backup.VerificationStatus,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -169,6 +187,9 @@ func (s *BackupService) findBackupList(ctx context.Context, tx *Tx, find *api.Ba
 	if v := find.ID; v != nil {
 		where, args = append(where, "id = ?"), append(args, *v)
 	}
+	if v := find.RowStatus; v != nil {
+		where, args = append(where, "row_status = ?"), append(args, *v)
+	}
 	if v := find.DatabaseId; v != nil {
 		where, args = append(where, "database_id = ?"), append(args, *v)
 	}
@@ -179,6 +200,7 @@ func (s *BackupService) findBackupList(ctx context.Context, tx *Tx, find *api.Ba
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
 		    id,
+		    row_status,
 		    creator_id,
 		    created_ts,
 		    updater_id,
@@ -190,7 +212,12 @@ func (s *BackupService) findBackupList(ctx context.Context, tx *Tx, find *api.Ba
 			storage_backend,
 			migration_history_version,
 			path,
-			comment
+			comment,
+			compression,
+			encrypted,
+			storage_location,
+			payload,
+			verification_status
 		FROM backup
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -206,6 +233,7 @@ func (s *BackupService) findBackupList(ctx context.Context, tx *Tx, find *api.Ba
 		var backup api.Backup
 		if err := rows.Scan(
 			&backup.ID,
+			&backup.RowStatus,
 			&backup.CreatorId,
 			&backup.CreatedTs,
 			&backup.UpdaterId,
@@ -218,6 +246,11 @@ func (s *BackupService) findBackupList(ctx context.Context, tx *Tx, find *api.Ba
 			&backup.MigrationHistoryVersion,
 			&backup.Path,
 			&backup.Comment,
+			&backup.Compression,
+			&backup.Encrypted,
+			&backup.StorageLocation,
+			&backup.Payload,
+			&backup.VerificationStatus,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -235,7 +268,18 @@ func (s *BackupService) findBackupList(ctx context.Context, tx *Tx, find *api.Ba
 func (s *BackupService) patchBackup(ctx context.Context, tx *Tx, patch *api.BackupPatch) (*api.Backup, error) {
 	// Build UPDATE clause.
 	set, args := []string{"updater_id = ?"}, []interface{}{patch.UpdaterId}
-	set, args = append(set, "status = ?"), append(args, patch.Status)
+	if v := patch.RowStatus; v != nil {
+		set, args = append(set, "row_status = ?"), append(args, *v)
+	}
+	if v := patch.Status; v != nil {
+		set, args = append(set, "status = ?"), append(args, *v)
+	}
+	if v := patch.Payload; v != nil {
+		set, args = append(set, "payload = ?"), append(args, *v)
+	}
+	if v := patch.VerificationStatus; v != nil {
+		set, args = append(set, "verification_status = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -244,7 +288,7 @@ func (s *BackupService) patchBackup(ctx context.Context, tx *Tx, patch *api.Back
 		UPDATE backup
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, name, `+"`status`,"+` `+"`type`, storage_backend, migration_history_version, path, comment"+`
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, database_id, name, `+"`status`,"+` `+"`type`, storage_backend, migration_history_version, path, comment, compression, encrypted, storage_location, payload, verification_status"+`
 	`,
 		args...,
 	)
@@ -257,6 +301,7 @@ func (s *BackupService) patchBackup(ctx context.Context, tx *Tx, patch *api.Back
 		var backup api.Backup
 		if err := row.Scan(
 			&backup.ID,
+			&backup.RowStatus,
 			&backup.CreatorId,
 			&backup.CreatedTs,
 			&backup.UpdaterId,
@@ -269,6 +314,11 @@ func (s *BackupService) patchBackup(ctx context.Context, tx *Tx, patch *api.Back
 			&backup.MigrationHistoryVersion,
 			&backup.Path,
 			&backup.Comment,
+			&backup.Compression,
+			&backup.Encrypted,
+			&backup.StorageLocation,
+			&backup.Payload,
+			&backup.VerificationStatus,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -300,6 +350,22 @@ func (s *BackupService) FindBackupSetting(ctx context.Context, find *api.BackupS
 	return list[0], nil
 }
 
+// FindBackupSettingList retrieves a list of backup settings based on find.
+func (s *BackupService) FindBackupSettingList(ctx context.Context, find *api.BackupSettingFind) ([]*api.BackupSetting, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := s.findBackupSetting(ctx, tx, find)
+	if err != nil {
+		return []*api.BackupSetting{}, err
+	}
+
+	return list, nil
+}
+
 func (s *BackupService) findBackupSetting(ctx context.Context, tx *Tx, find *api.BackupSettingFind) (_ []*api.BackupSetting, err error) {
 	// Build WHERE clause.
 	where, args := []string{"1 = 1"}, []interface{}{}
@@ -309,6 +375,9 @@ func (s *BackupService) findBackupSetting(ctx context.Context, tx *Tx, find *api
 	if v := find.DatabaseId; v != nil {
 		where, args = append(where, "database_id = ?"), append(args, *v)
 	}
+	if v := find.Enabled; v != nil {
+		where, args = append(where, "enabled = ?"), append(args, *v)
+	}
 
 	rows, err := tx.QueryContext(ctx, `
 		SELECT
@@ -320,7 +389,14 @@ func (s *BackupService) findBackupSetting(ctx context.Context, tx *Tx, find *api
 			database_id,
 			enabled,
 			hour,
-			day_of_week
+			day_of_week,
+			retention_count,
+			retention_days,
+			verification_enabled,
+			verification_instance_id,
+			schedule,
+			compression,
+			encrypted
 		FROM backup_setting
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -344,6 +420,13 @@ func (s *BackupService) findBackupSetting(ctx context.Context, tx *Tx, find *api
 			&backupSetting.Enabled,
 			&backupSetting.Hour,
 			&backupSetting.DayOfWeek,
+			&backupSetting.RetentionCount,
+			&backupSetting.RetentionDays,
+			&backupSetting.VerificationEnabled,
+			&backupSetting.VerificationInstanceId,
+			&backupSetting.Schedule,
+			&backupSetting.Compression,
+			&backupSetting.Encrypted,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -387,14 +470,28 @@ func (s *BackupService) upsertBackupSetting(ctx context.Context, tx *Tx, upsert
 			database_id,
 			`+"`enabled`,"+`
 			hour,
-			day_of_week
+			day_of_week,
+			retention_count,
+			retention_days,
+			verification_enabled,
+			verification_instance_id,
+			schedule,
+			compression,
+			encrypted
 		)
-		VALUES (?, ?, ?, ?, ?, ?)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(database_id) DO UPDATE SET
 				enabled = excluded.enabled,
 				hour = excluded.hour,
-				day_of_week = excluded.day_of_week
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, `+"`enabled`,"+` `+"hour, day_of_week"+`
+				day_of_week = excluded.day_of_week,
+				retention_count = excluded.retention_count,
+				retention_days = excluded.retention_days,
+				verification_enabled = excluded.verification_enabled,
+				verification_instance_id = excluded.verification_instance_id,
+				schedule = excluded.schedule,
+				compression = excluded.compression,
+				encrypted = excluded.encrypted
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, database_id, `+"`enabled`,"+` `+"hour, day_of_week, retention_count, retention_days, verification_enabled, verification_instance_id, schedule, compression, encrypted"+`
 		`,
 		upsert.UpdaterId,
 		upsert.UpdaterId,
@@ -402,6 +499,13 @@ func (s *BackupService) upsertBackupSetting(ctx context.Context, tx *Tx, upsert
 		upsert.Enabled,
 		upsert.Hour,
 		upsert.DayOfWeek,
+		upsert.RetentionCount,
+		upsert.RetentionDays,
+		upsert.VerificationEnabled,
+		upsert.VerificationInstanceId,
+		upsert.Schedule,
+		upsert.Compression,
+		upsert.Encrypted,
 	)
 
 	if err != nil {
@@ -421,73 +525,16 @@ func (s *BackupService) upsertBackupSetting(ctx context.Context, tx *Tx, upsert
 		&backupSetting.Enabled,
 		&backupSetting.Hour,
 		&backupSetting.DayOfWeek,
+		&backupSetting.RetentionCount,
+		&backupSetting.RetentionDays,
+		&backupSetting.VerificationEnabled,
+		&backupSetting.VerificationInstanceId,
+		&backupSetting.Schedule,
+		&backupSetting.Compression,
+		&backupSetting.Encrypted,
 	); err != nil {
 		return nil, FormatError(err)
 	}
 
 	return &backupSetting, nil
 }
-
-// FindBackupSettingsMatch retrieves a list of backup settings based on match condition.
-func (s *BackupService) FindBackupSettingsMatch(ctx context.Context, match *api.BackupSettingsMatch) ([]*api.BackupSetting, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, FormatError(err)
-	}
-	defer tx.Rollback()
-
-	rows, err := tx.QueryContext(ctx, `
-		SELECT
-			id,
-			creator_id,
-			created_ts,
-			updater_id,
-			updated_ts,
-			database_id,
-			enabled,
-			hour,
-			day_of_week
-		FROM backup_setting
-		WHERE
-			enabled = 1
-			AND (
-				(hour = ? AND day_of_week = ?)
-				OR
-				(hour = ? AND day_of_week = -1)
-				OR
-				(hour = -1 AND day_of_week = ?)
-			)
-		`,
-		match.Hour, match.DayOfWeek, match.Hour, match.DayOfWeek,
-	)
-	if err != nil {
-		return nil, FormatError(err)
-	}
-	defer rows.Close()
-
-	// Iterate over result set and deserialize rows into list.
-	list := make([]*api.BackupSetting, 0)
-	for rows.Next() {
-		var backupSetting api.BackupSetting
-		if err := rows.Scan(
-			&backupSetting.ID,
-			&backupSetting.CreatorId,
-			&backupSetting.CreatedTs,
-			&backupSetting.UpdaterId,
-			&backupSetting.UpdatedTs,
-			&backupSetting.DatabaseId,
-			&backupSetting.Enabled,
-			&backupSetting.Hour,
-			&backupSetting.DayOfWeek,
-		); err != nil {
-			return nil, FormatError(err)
-		}
-
-		list = append(list, &backupSetting)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, FormatError(err)
-	}
-
-	return list, nil
-}
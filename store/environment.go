@@ -167,16 +167,24 @@ func (s *EnvironmentService) createEnvironment(ctx context.Context, tx *Tx, crea
 			updater_id,
 			name,
 			`+"`order`"+`,
-			approval_policy
+			approval_policy,
+			sql_review_policy,
+			maintenance_window,
+			task_retry_policy,
+			backup_storage
 		)
-		VALUES (?, ?, ?, ?, ?)
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, name, `+"`order`, approval_policy"+`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, name, `+"`order`, approval_policy, sql_review_policy, maintenance_window, task_retry_policy, backup_storage"+`
 	`,
 		create.CreatorId,
 		create.CreatorId,
 		create.Name,
 		order+1,
 		create.ApprovalPolicy,
+		create.SQLReviewPolicy,
+		create.MaintenanceWindow,
+		create.TaskRetryPolicy,
+		create.BackupStorage,
 	)
 
 	if err2 != nil {
@@ -196,6 +204,10 @@ func (s *EnvironmentService) createEnvironment(ctx context.Context, tx *Tx, crea
 		&environment.Name,
 		&environment.Order,
 		&environment.ApprovalPolicy,
+		&environment.SQLReviewPolicy,
+		&environment.MaintenanceWindow,
+		&environment.TaskRetryPolicy,
+		&environment.BackupStorage,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -223,7 +235,11 @@ func (s *EnvironmentService) findEnvironmentList(ctx context.Context, tx *Tx, fi
 		    updated_ts,
 		    name,
 		    `+"`order`"+`,
-			approval_policy
+			approval_policy,
+			sql_review_policy,
+			maintenance_window,
+			task_retry_policy,
+			backup_storage
 		FROM environment
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -247,6 +263,10 @@ func (s *EnvironmentService) findEnvironmentList(ctx context.Context, tx *Tx, fi
 			&environment.Name,
 			&environment.Order,
 			&environment.ApprovalPolicy,
+			&environment.SQLReviewPolicy,
+			&environment.MaintenanceWindow,
+			&environment.TaskRetryPolicy,
+			&environment.BackupStorage,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -276,6 +296,18 @@ func (s *EnvironmentService) patchEnvironment(ctx context.Context, tx *Tx, patch
 	if v := patch.ApprovalPolicy; v != nil {
 		set, args = append(set, "approval_policy = ?"), append(args, *v)
 	}
+	if v := patch.SQLReviewPolicy; v != nil {
+		set, args = append(set, "sql_review_policy = ?"), append(args, *v)
+	}
+	if v := patch.MaintenanceWindow; v != nil {
+		set, args = append(set, "maintenance_window = ?"), append(args, *v)
+	}
+	if v := patch.TaskRetryPolicy; v != nil {
+		set, args = append(set, "task_retry_policy = ?"), append(args, *v)
+	}
+	if v := patch.BackupStorage; v != nil {
+		set, args = append(set, "backup_storage = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -284,7 +316,7 @@ func (s *EnvironmentService) patchEnvironment(ctx context.Context, tx *Tx, patch
 		UPDATE environment
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, name, `+"`order`, approval_policy"+`
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, name, `+"`order`, approval_policy, sql_review_policy, maintenance_window, task_retry_policy, backup_storage"+`
 	`,
 		args...,
 	)
@@ -305,6 +337,10 @@ func (s *EnvironmentService) patchEnvironment(ctx context.Context, tx *Tx, patch
 			&environment.Name,
 			&environment.Order,
 			&environment.ApprovalPolicy,
+			&environment.SQLReviewPolicy,
+			&environment.MaintenanceWindow,
+			&environment.TaskRetryPolicy,
+			&environment.BackupStorage,
 		); err != nil {
 			return nil, FormatError(err)
 		}
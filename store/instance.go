@@ -183,10 +183,12 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 			engine,
 			external_link,
 			host,
-			port
+			port,
+			binlog_archive_enabled,
+			ssl_mode
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, external_link, host, port
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, external_link, host, port, binlog_archive_enabled, ssl_mode
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -196,6 +198,8 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 		create.ExternalLink,
 		create.Host,
 		create.Port,
+		create.BinlogArchiveEnabled,
+		create.SSLMode,
 	)
 
 	if err != nil {
@@ -218,6 +222,8 @@ func createInstance(ctx context.Context, tx *Tx, create *api.InstanceCreate) (*a
 		&instance.ExternalLink,
 		&instance.Host,
 		&instance.Port,
+		&instance.BinlogArchiveEnabled,
+		&instance.SSLMode,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -248,7 +254,9 @@ func findInstanceList(ctx context.Context, tx *Tx, find *api.InstanceFind) (_ []
 			engine,
 			external_link,
 			host,
-			port
+			port,
+			binlog_archive_enabled,
+			ssl_mode
 		FROM instance
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -275,6 +283,8 @@ func findInstanceList(ctx context.Context, tx *Tx, find *api.InstanceFind) (_ []
 			&instance.ExternalLink,
 			&instance.Host,
 			&instance.Port,
+			&instance.BinlogArchiveEnabled,
+			&instance.SSLMode,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -307,6 +317,12 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 	if v := patch.Port; v != nil {
 		set, args = append(set, "port = ?"), append(args, *v)
 	}
+	if v := patch.BinlogArchiveEnabled; v != nil {
+		set, args = append(set, "binlog_archive_enabled = ?"), append(args, *v)
+	}
+	if v := patch.SSLMode; v != nil {
+		set, args = append(set, "ssl_mode = ?"), append(args, *v)
+	}
 
 	args = append(args, patch.ID)
 
@@ -315,7 +331,7 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 		UPDATE instance
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, external_link, host, port
+		RETURNING id, row_status, creator_id, created_ts, updater_id, updated_ts, environment_id, name, engine, external_link, host, port, binlog_archive_enabled, ssl_mode
 	`,
 		args...,
 	)
@@ -339,6 +355,8 @@ func patchInstance(ctx context.Context, tx *Tx, patch *api.InstancePatch) (*api.
 			&instance.ExternalLink,
 			&instance.Host,
 			&instance.Port,
+			&instance.BinlogArchiveEnabled,
+			&instance.SSLMode,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -0,0 +1,21 @@
+PRAGMA user_version = 10002;
+
+-- Add SQLITE to the instance engine CHECK constraint.
+-- SQLite doesn't support altering constraint, and recreating the instance table would violate the foreign keys
+-- referencing it, so we rewrite the table definition directly. This is safe because the change doesn't affect
+-- the on-disk format of the existing rows. See https://www.sqlite.org/lang_altertable.html#otheralter
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        '`engine` IN (''MYSQL'', ''POSTGRES'')',
+        '`engine` IN (''MYSQL'', ''POSTGRES'', ''SQLITE'')'
+    )
+WHERE
+    type = 'table'
+    AND name = 'instance';
+
+PRAGMA writable_schema = OFF;
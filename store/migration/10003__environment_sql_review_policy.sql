@@ -0,0 +1,8 @@
+PRAGMA user_version = 10003;
+
+-- SQL review policy is a json object mapping the advisor rule type to its level (ERROR, WARNING or DISABLED).
+-- Empty string means all rules use the WARNING level.
+ALTER TABLE
+    environment
+ADD
+    COLUMN sql_review_policy TEXT NOT NULL DEFAULT '';
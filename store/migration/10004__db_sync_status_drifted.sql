@@ -0,0 +1,19 @@
+PRAGMA user_version = 10004;
+
+-- Add DRIFTED to the database sync_status CHECK constraint, which means the live schema differs from the schema
+-- recorded by the latest migration. Most tables reference db, so its definition is rewritten in place.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'sync_status IN (''OK'', ''NOT_FOUND'')',
+        'sync_status IN (''OK'', ''NOT_FOUND'', ''DRIFTED'')'
+    )
+WHERE
+    type = 'table'
+    AND name = 'db';
+
+PRAGMA writable_schema = OFF;
@@ -0,0 +1,15 @@
+PRAGMA user_version = 10005;
+
+-- The task stays PENDING until earliest_allowed_ts (unix timestamp). 0 means no restriction.
+ALTER TABLE
+    task
+ADD
+    COLUMN earliest_allowed_ts BIGINT NOT NULL DEFAULT 0;
+
+-- Maintenance window is a json object with the daily startTime and endTime in HH:MM UTC, e.g.
+-- {"startTime":"02:00","endTime":"04:00"}. Database create and schema update tasks only start within the window.
+-- Empty string means no maintenance window.
+ALTER TABLE
+    environment
+ADD
+    COLUMN maintenance_window TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+PRAGMA user_version = 10006;
+
+-- Each attempt to run a task is recorded as its own task run, attempt starts from 1.
+ALTER TABLE
+    task_run
+ADD
+    COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+
+-- Task retry policy is a json object mapping the task type to its retry policy, e.g.
+-- {"bb.task.database.backup":{"maxAttempts":5,"backoffSeconds":60}}. Task types not in the policy use the default.
+ALTER TABLE
+    environment
+ADD
+    COLUMN task_retry_policy TEXT NOT NULL DEFAULT '';
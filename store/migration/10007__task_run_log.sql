@@ -0,0 +1,18 @@
+PRAGMA user_version = 10007;
+
+-- task_run_log records the structured logs of a task run while it's running, e.g. each executed statement
+-- and the backup progress. The payload is a json object whose schema depends on the type.
+CREATE TABLE task_run_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_ts BIGINT NOT NULL DEFAULT (strftime('%s', 'now')),
+    task_run_id INTEGER NOT NULL REFERENCES task_run (id),
+    `type` TEXT NOT NULL CHECK (`type` LIKE 'bb.task-run-log.%'),
+    payload TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_task_run_log_task_run_id ON task_run_log(task_run_id);
+
+INSERT INTO
+    sqlite_sequence (name, seq)
+VALUES
+    ('task_run_log', 100);
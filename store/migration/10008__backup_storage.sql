@@ -0,0 +1,26 @@
+PRAGMA user_version = 10008;
+
+-- Backup storage is a json object of the storage for the backups of the databases in the environment, e.g.
+-- {"backend":"S3","endpoint":"http://localhost:9000","bucket":"backup","prefix":"bytebase/","accessKeyId":"...","secretAccessKey":"...","usePathStyle":true}.
+-- Empty means the local storage under --data.
+ALTER TABLE
+    environment
+ADD
+    COLUMN backup_storage TEXT NOT NULL DEFAULT '';
+
+-- Add S3 to the backup storage_backend CHECK constraint, rewriting the definition avoids copying every backup row.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        'storage_backend IN (''LOCAL'')',
+        'storage_backend IN (''LOCAL'', ''S3'')'
+    )
+WHERE
+    type = 'table'
+    AND name = 'backup';
+
+PRAGMA writable_schema = OFF;
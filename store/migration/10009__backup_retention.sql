@@ -0,0 +1,14 @@
+PRAGMA user_version = 10009;
+
+-- The retention of the automatic backups. A backup is kept if it's one of the latest retention_count backups, or if
+-- it's created within retention_days days. 0 disables the corresponding rule, and the backups are kept forever if
+-- both are 0.
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN retention_count INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN retention_days INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+PRAGMA user_version = 10010;
+
+-- The encoding of the backup file. The dump is compressed first, and then encrypted with the key passed to the server
+-- by --backup-encryption-key if encrypted is set. The key is never stored in the metadata database.
+ALTER TABLE
+    backup
+ADD
+    COLUMN compression TEXT NOT NULL CHECK (compression IN ('NONE', 'GZIP')) DEFAULT 'NONE';
+
+ALTER TABLE
+    backup
+ADD
+    COLUMN encrypted INTEGER NOT NULL CHECK (encrypted IN (0, 1)) DEFAULT 0;
@@ -0,0 +1,13 @@
+PRAGMA user_version = 10011;
+
+-- Whether to archive the binlog of the MySQL instance for the point-in-time recovery.
+ALTER TABLE
+    instance
+ADD
+    COLUMN binlog_archive_enabled INTEGER NOT NULL CHECK (binlog_archive_enabled IN (0, 1)) DEFAULT 0;
+
+-- The JSON encoded api.BackupPayload, e.g. the binlog position of the backup snapshot.
+ALTER TABLE
+    backup
+ADD
+    COLUMN payload TEXT NOT NULL DEFAULT '';
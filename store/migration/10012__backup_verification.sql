@@ -0,0 +1,21 @@
+PRAGMA user_version = 10012;
+
+-- The result of restoring the backup into a scratch database and comparing the table row counts with the dump.
+ALTER TABLE
+    backup
+ADD
+    COLUMN verification_status TEXT NOT NULL CHECK (
+        verification_status IN ('NONE', 'PENDING', 'PASSED', 'FAILED')
+    ) DEFAULT 'NONE';
+
+-- Whether to verify the automatic backups, and the sandbox instance to verify them on. The backups are verified on
+-- the instance of the database if verification_instance_id is 0.
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN verification_enabled INTEGER NOT NULL CHECK (verification_enabled IN (0, 1)) DEFAULT 0;
+
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN verification_instance_id INTEGER NOT NULL DEFAULT 0;
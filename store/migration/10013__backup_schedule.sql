@@ -0,0 +1,8 @@
+PRAGMA user_version = 10013;
+
+-- schedule is the cron expression in UTC to take the automatic backups, e.g. "0 * * * *" for hourly backups.
+-- The automatic backups are taken at hour on day_of_week if it's empty.
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN schedule TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,18 @@
+PRAGMA user_version = 10014;
+
+-- Add GITHUB to the vcs type CHECK constraint, the definition is rewritten in place since repo references vcs.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        '`type` IN (''GITLAB_SELF_HOST'')',
+        '`type` IN (''GITLAB_SELF_HOST'', ''GITHUB'')'
+    )
+WHERE
+    type = 'table'
+    AND name = 'vcs';
+
+PRAGMA writable_schema = OFF;
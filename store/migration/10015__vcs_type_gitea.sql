@@ -0,0 +1,18 @@
+PRAGMA user_version = 10015;
+
+-- Add GITEA to the vcs type CHECK constraint, the replaced text must match the definition left by 10014.
+PRAGMA writable_schema = ON;
+
+UPDATE
+    sqlite_master
+SET
+    sql = replace(
+        sql,
+        '`type` IN (''GITLAB_SELF_HOST'', ''GITHUB'')',
+        '`type` IN (''GITLAB_SELF_HOST'', ''GITHUB'', ''GITEA'')'
+    )
+WHERE
+    type = 'table'
+    AND name = 'vcs';
+
+PRAGMA writable_schema = OFF;
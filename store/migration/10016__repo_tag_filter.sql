@@ -0,0 +1,8 @@
+PRAGMA user_version = 10016;
+
+-- Tags we are interested, e.g. release-*. If set, only the pushes of the tags matching it create issues, and the
+-- pushes of the branches are ignored.
+ALTER TABLE
+    repo
+ADD
+    COLUMN tag_filter TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,24 @@
+PRAGMA user_version = 10017;
+
+-- The attempt waiting for the retry backoff starts after scheduled_ts (unix timestamp), so the backoff no longer
+-- overwrites the earliest_allowed_ts set by the user. 0 means the attempt starts right away.
+ALTER TABLE
+    task_run
+ADD
+    COLUMN scheduled_ts BIGINT NOT NULL DEFAULT 0;
+
+-- Move the backoff of the attempts waiting for retry from the task.
+UPDATE
+    task_run
+SET
+    scheduled_ts = (
+        SELECT
+            earliest_allowed_ts
+        FROM
+            task
+        WHERE
+            task.id = task_run.task_id
+    )
+WHERE
+    `status` = 'RUNNING'
+    AND attempt > 1;
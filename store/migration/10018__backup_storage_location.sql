@@ -0,0 +1,10 @@
+PRAGMA user_version = 10018;
+
+-- The JSON encoded api.BackupStorageLocation where the backup is stored, e.g.
+-- {"endpoint":"http://localhost:9000","region":"","bucket":"backup","prefix":"bytebase/","usePathStyle":true}.
+-- Empty for the local storage, and for the S3 backups created before, which are in the current backup storage of
+-- their environment.
+ALTER TABLE
+    backup
+ADD
+    COLUMN storage_location TEXT NOT NULL DEFAULT '';
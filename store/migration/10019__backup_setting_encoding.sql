@@ -0,0 +1,12 @@
+PRAGMA user_version = 10019;
+
+-- The encoding of the automatic backups, see the compression and encrypted of backup.
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN compression TEXT NOT NULL CHECK (compression IN ('NONE', 'GZIP')) DEFAULT 'NONE';
+
+ALTER TABLE
+    backup_setting
+ADD
+    COLUMN encrypted INTEGER NOT NULL CHECK (encrypted IN (0, 1)) DEFAULT 0;
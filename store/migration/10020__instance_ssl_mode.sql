@@ -0,0 +1,7 @@
+PRAGMA user_version = 10020;
+
+-- The sslmode of the PostgreSQL instance, empty for "disable".
+ALTER TABLE
+    instance
+ADD
+    COLUMN ssl_mode TEXT NOT NULL CHECK (ssl_mode IN ('', 'disable', 'require', 'verify-ca', 'verify-full')) DEFAULT '';
@@ -26,6 +26,9 @@ DELETE FROM
 DELETE FROM
     issue;
 
+DELETE FROM
+    task_run_log;
+
 DELETE FROM
     task_run;
 
@@ -248,6 +248,12 @@ func (db *DB) migrate() error {
 		}
 	}
 
+	// Migration may rewrite the table definition in sqlite_master directly (e.g. changing CHECK constraint),
+	// which doesn't bump the schema cookie. Bump it so that all connections reload the schema.
+	if err := db.bumpSchemaCookie(); err != nil {
+		return fmt.Errorf("failed to reload schema after migration: %w", err)
+	}
+
 	major, minor, err = db.version()
 	if err != nil {
 		return fmt.Errorf("failed to get current schema version: %w", err)
@@ -264,6 +270,16 @@ func (db *DB) migrate() error {
 	return nil
 }
 
+// bumpSchemaCookie increments the "PRAGMA schema_version" to force SQLite to reload the schema.
+func (db *DB) bumpSchemaCookie() error {
+	var cookie int
+	if err := db.Db.QueryRow("PRAGMA schema_version").Scan(&cookie); err != nil {
+		return err
+	}
+	_, err := db.Db.Exec(fmt.Sprintf("PRAGMA schema_version = %d", cookie+1))
+	return err
+}
+
 // migrateFile runs a migration file within a transaction.
 func (db *DB) migrateFile(name string, up bool) error {
 	if up {
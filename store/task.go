@@ -99,6 +99,84 @@ func (s *TaskService) PatchTaskStatus(ctx context.Context, patch *api.TaskStatus
 	return task, nil
 }
 
+// PatchTask updates an existing task by ID.
+// Returns ENOTFOUND if task does not exist.
+func (s *TaskService) PatchTask(ctx context.Context, patch *api.TaskPatch) (*api.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	task, err := s.patchTask(ctx, tx, patch)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return task, nil
+}
+
+// RetryTask fails the running task run and creates the task run for the next attempt atomically.
+// The task stays RUNNING and the new task run won't start until retry.RetryTs.
+func (s *TaskService) RetryTask(ctx context.Context, retry *api.TaskRetry) (*api.Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	task, err := s.retryTask(ctx, tx, retry)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return task, nil
+}
+
+// CreateTaskRunLog creates a new log for the task run.
+func (s *TaskService) CreateTaskRunLog(ctx context.Context, create *api.TaskRunLogCreate) (*api.TaskRunLog, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	taskRunLog, err := s.TaskRunService.CreateTaskRunLog(ctx, tx.Tx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return taskRunLog, nil
+}
+
+// FindTaskRunLogList retrieves a list of task run logs based on find.
+func (s *TaskService) FindTaskRunLogList(ctx context.Context, find *api.TaskRunLogFind) ([]*api.TaskRunLog, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer tx.Rollback()
+
+	list, err := s.TaskRunService.FindTaskRunLogList(ctx, tx.Tx, find)
+	if err != nil {
+		return []*api.TaskRunLog{}, err
+	}
+
+	return list, nil
+}
+
 // createTask creates a new task.
 func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCreate) (*api.Task, error) {
 	var row *sql.Rows
@@ -115,10 +193,11 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			name,
 			`+"`status`,"+`	
 			`+"`type`,"+`
-			payload	
+			payload,
+			earliest_allowed_ts
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 			create.CreatorId,
 			create.CreatorId,
@@ -129,6 +208,7 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			create.Status,
 			create.Type,
 			create.Payload,
+			create.EarliestAllowedTs,
 		)
 	} else {
 		row, err = tx.QueryContext(ctx, `
@@ -142,10 +222,11 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			name,
 			`+"`status`,"+`	
 			`+"`type`,"+`
-			payload	
+			payload,
+			earliest_allowed_ts
 		)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 			create.CreatorId,
 			create.CreatorId,
@@ -157,6 +238,7 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 			create.Status,
 			create.Type,
 			create.Payload,
+			create.EarliestAllowedTs,
 		)
 	}
 
@@ -183,6 +265,7 @@ func (s *TaskService) createTask(ctx context.Context, tx *Tx, create *api.TaskCr
 		&task.Status,
 		&task.Type,
 		&task.Payload,
+		&task.EarliestAllowedTs,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -237,7 +320,8 @@ func (s *TaskService) findTaskList(ctx context.Context, tx *Tx, find *api.TaskFi
 		    name,
 		    `+"`status`,"+`
 			`+"`type`,"+`
-			payload
+			payload,
+			earliest_allowed_ts
 		FROM task
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -265,6 +349,7 @@ func (s *TaskService) findTaskList(ctx context.Context, tx *Tx, find *api.TaskFi
 			&task.Status,
 			&task.Type,
 			&task.Payload,
+			&task.EarliestAllowedTs,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -297,6 +382,9 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 	if err != nil {
 		return nil, err
 	}
+	if patch.OldStatus != "" && task.Status != patch.OldStatus {
+		return nil, &bytebase.Error{Code: bytebase.ECONFLICT, Message: fmt.Sprintf("task %v status has changed from %v to %v", task.Name, patch.OldStatus, task.Status)}
+	}
 
 	if !(task.Status == api.TaskPendingApproval && patch.Status == api.TaskPending) {
 		taskRunFind := &api.TaskRunFind{
@@ -325,6 +413,7 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 				Name:      fmt.Sprintf("%s %d", task.Name, time.Now().Unix()),
 				Type:      task.Type,
 				Payload:   task.Payload,
+				Attempt:   1,
 			}
 			if _, err := s.TaskRunService.CreateTaskRun(ctx, tx.Tx, taskRunCreate); err != nil {
 				return nil, err
@@ -362,7 +451,65 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 		UPDATE task
 		SET `+strings.Join(set, ", ")+`
 		WHERE id = ?
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload"+`
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
+	`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	if row.Next() {
+		var task api.Task
+		if err := row.Scan(
+			&task.ID,
+			&task.CreatorId,
+			&task.CreatedTs,
+			&task.UpdaterId,
+			&task.UpdatedTs,
+			&task.PipelineId,
+			&task.StageId,
+			&task.InstanceId,
+			&task.DatabaseId,
+			&task.Name,
+			&task.Status,
+			&task.Type,
+			&task.Payload,
+			&task.EarliestAllowedTs,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		taskRunFind := &api.TaskRunFind{
+			TaskId: &task.ID,
+		}
+		task.TaskRunList, err = s.TaskRunService.FindTaskRunList(ctx, tx.Tx, taskRunFind)
+		if err != nil {
+			return nil, err
+		}
+
+		return &task, nil
+	}
+
+	return nil, &bytebase.Error{Code: bytebase.ENOTFOUND, Message: fmt.Sprintf("task ID not found: %d", patch.ID)}
+}
+
+// patchTask updates a task by ID. Returns the new state of the task after update.
+func (s *TaskService) patchTask(ctx context.Context, tx *Tx, patch *api.TaskPatch) (*api.Task, error) {
+	// Build UPDATE clause.
+	set, args := []string{"updater_id = ?"}, []interface{}{patch.UpdaterId}
+	if v := patch.EarliestAllowedTs; v != nil {
+		set, args = append(set, "earliest_allowed_ts = ?"), append(args, *v)
+	}
+	args = append(args, patch.ID)
+
+	// Execute update query with RETURNING.
+	row, err := tx.QueryContext(ctx, `
+		UPDATE task
+		SET `+strings.Join(set, ", ")+`
+		WHERE id = ?
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, pipeline_id, stage_id, instance_id, database_id, name, `+"`status`, `type`, payload, earliest_allowed_ts"+`
 	`,
 		args...,
 	)
@@ -387,6 +534,7 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 			&task.Status,
 			&task.Type,
 			&task.Payload,
+			&task.EarliestAllowedTs,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -404,3 +552,56 @@ func (s *TaskService) patchTaskStatus(ctx context.Context, tx *Tx, patch *api.Ta
 
 	return nil, &bytebase.Error{Code: bytebase.ENOTFOUND, Message: fmt.Sprintf("task ID not found: %d", patch.ID)}
 }
+
+// retryTask fails the running task run, creates the task run for the next attempt and postpones the task until retry.RetryTs.
+func (s *TaskService) retryTask(ctx context.Context, tx *Tx, retry *api.TaskRetry) (*api.Task, error) {
+	taskFind := &api.TaskFind{
+		ID: &retry.ID,
+	}
+	task, err := s.findTask(ctx, tx, taskFind)
+	if err != nil {
+		return nil, err
+	}
+	if task.Status != api.TaskRunning {
+		return nil, fmt.Errorf("can only retry running task, task %v is %v", task.Name, task.Status)
+	}
+
+	taskRunFind := &api.TaskRunFind{
+		TaskId: &task.ID,
+		StatusList: []api.TaskRunStatus{
+			api.TaskRunRunning,
+		},
+	}
+	taskRun, err := s.TaskRunService.FindTaskRun(ctx, tx.Tx, taskRunFind)
+	if err != nil {
+		return nil, err
+	}
+	taskRunStatusPatch := &api.TaskRunStatusPatch{
+		ID:     &taskRun.ID,
+		TaskId: &task.ID,
+		Status: api.TaskRunFailed,
+		Detail: retry.Detail,
+	}
+	if _, err := s.TaskRunService.PatchTaskRunStatus(ctx, tx.Tx, taskRunStatusPatch); err != nil {
+		return nil, err
+	}
+
+	taskRunCreate := &api.TaskRunCreate{
+		CreatorId:   retry.UpdaterId,
+		TaskId:      task.ID,
+		Name:        fmt.Sprintf("%s %d", task.Name, time.Now().Unix()),
+		Type:        task.Type,
+		Payload:     task.Payload,
+		Attempt:     taskRun.Attempt + 1,
+		ScheduledTs: retry.RetryTs,
+	}
+	if _, err := s.TaskRunService.CreateTaskRun(ctx, tx.Tx, taskRunCreate); err != nil {
+		return nil, err
+	}
+
+	taskPatch := &api.TaskPatch{
+		ID:        task.ID,
+		UpdaterId: retry.UpdaterId,
+	}
+	return s.patchTask(ctx, tx, taskPatch)
+}
@@ -36,10 +36,12 @@ func (s *TaskRunService) CreateTaskRun(ctx context.Context, tx *sql.Tx, create *
 			name,
 			`+"`status`,"+`
 			`+"`type`,"+`
-			payload
+			payload,
+			attempt,
+			scheduled_ts
 		)
-		VALUES (?, ?, ?, ?, 'RUNNING', ?, ?)
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, `+"`status`, `type`, detail, payload"+`
+		VALUES (?, ?, ?, ?, 'RUNNING', ?, ?, ?, ?)
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, `+"`status`, `type`, detail, payload, attempt, scheduled_ts"+`
 	`,
 		create.CreatorId,
 		create.CreatorId,
@@ -47,6 +49,8 @@ func (s *TaskRunService) CreateTaskRun(ctx context.Context, tx *sql.Tx, create *
 		create.Name,
 		create.Type,
 		create.Payload,
+		create.Attempt,
+		create.ScheduledTs,
 	)
 
 	if err != nil {
@@ -68,6 +72,8 @@ func (s *TaskRunService) CreateTaskRun(ctx context.Context, tx *sql.Tx, create *
 		&taskRun.Type,
 		&taskRun.Detail,
 		&taskRun.Payload,
+		&taskRun.Attempt,
+		&taskRun.ScheduledTs,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -120,7 +126,7 @@ func (s *TaskRunService) PatchTaskRunStatus(ctx context.Context, tx *sql.Tx, pat
 		UPDATE task_run
 		SET `+strings.Join(set, ", ")+`
 		WHERE `+strings.Join(where, " AND ")+`
-		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, `+"`status`, `type`, detail, payload"+`
+		RETURNING id, creator_id, created_ts, updater_id, updated_ts, task_id, name, `+"`status`, `type`, detail, payload, attempt, scheduled_ts"+`
 	`,
 		args...,
 	)
@@ -144,6 +150,8 @@ func (s *TaskRunService) PatchTaskRunStatus(ctx context.Context, tx *sql.Tx, pat
 		&taskRun.Type,
 		&taskRun.Detail,
 		&taskRun.Payload,
+		&taskRun.Attempt,
+		&taskRun.ScheduledTs,
 	); err != nil {
 		return nil, FormatError(err)
 	}
@@ -181,7 +189,9 @@ func (s *TaskRunService) findTaskRunList(ctx context.Context, tx *sql.Tx, find *
 			`+"`status`,"+`
 			`+"`type`,"+`
 			detail,
-			payload
+			payload,
+			attempt,
+			scheduled_ts
 		FROM task_run
 		WHERE `+strings.Join(where, " AND "),
 		args...,
@@ -207,6 +217,8 @@ func (s *TaskRunService) findTaskRunList(ctx context.Context, tx *sql.Tx, find *
 			&taskRun.Type,
 			&taskRun.Detail,
 			&taskRun.Payload,
+			&taskRun.Attempt,
+			&taskRun.ScheduledTs,
 		); err != nil {
 			return nil, FormatError(err)
 		}
@@ -219,3 +231,90 @@ func (s *TaskRunService) findTaskRunList(ctx context.Context, tx *sql.Tx, find *
 
 	return list, nil
 }
+
+// CreateTaskRunLog creates a new taskRunLog.
+func (s *TaskRunService) CreateTaskRunLog(ctx context.Context, tx *sql.Tx, create *api.TaskRunLogCreate) (*api.TaskRunLog, error) {
+	row, err := tx.QueryContext(ctx, `
+		INSERT INTO task_run_log (
+			task_run_id,
+			`+"`type`,"+`
+			payload
+		)
+		VALUES (?, ?, ?)
+		RETURNING id, created_ts, task_run_id, `+"`type`, payload"+`
+	`,
+		create.TaskRunId,
+		create.Type,
+		create.Payload,
+	)
+
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer row.Close()
+
+	row.Next()
+	var taskRunLog api.TaskRunLog
+	if err := row.Scan(
+		&taskRunLog.ID,
+		&taskRunLog.CreatedTs,
+		&taskRunLog.TaskRunId,
+		&taskRunLog.Type,
+		&taskRunLog.Payload,
+	); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return &taskRunLog, nil
+}
+
+// FindTaskRunLogList retrieves a list of taskRunLogs based on find, ordered by id.
+func (s *TaskRunService) FindTaskRunLogList(ctx context.Context, tx *sql.Tx, find *api.TaskRunLogFind) ([]*api.TaskRunLog, error) {
+	// Build WHERE clause.
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := find.TaskRunId; v != nil {
+		where, args = append(where, "task_run_id = ?"), append(args, *v)
+	}
+	if v := find.AfterId; v != nil {
+		where, args = append(where, "id > ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			id,
+			created_ts,
+			task_run_id,
+			`+"`type`,"+`
+			payload
+		FROM task_run_log
+		WHERE `+strings.Join(where, " AND ")+`
+		ORDER BY id ASC`,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	// Iterate over result set and deserialize rows into list.
+	list := make([]*api.TaskRunLog, 0)
+	for rows.Next() {
+		var taskRunLog api.TaskRunLog
+		if err := rows.Scan(
+			&taskRunLog.ID,
+			&taskRunLog.CreatedTs,
+			&taskRunLog.TaskRunId,
+			&taskRunLog.Type,
+			&taskRunLog.Payload,
+		); err != nil {
+			return nil, FormatError(err)
+		}
+
+		list = append(list, &taskRunLog)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, FormatError(err)
+	}
+
+	return list, nil
+}